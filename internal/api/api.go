@@ -1,10 +1,17 @@
 package api
 
 import (
+	"task-scheduler/internal/attachments"
+	"task-scheduler/internal/caldav"
+	"task-scheduler/internal/comments"
 	"task-scheduler/internal/emailService"
 	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/projects"
+	"task-scheduler/internal/reminders"
 	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/templates"
 	"task-scheduler/internal/users"
+	"task-scheduler/internal/worklogs"
 	"time"
 )
 
@@ -18,6 +25,13 @@ type API struct {
 	users        *users.Users
 	tasks        *tasks.Tasks
 	emailService *emailService.Mailer
+	reminders    *reminders.Reminders
+	comments     *comments.Comments
+	attachments  *attachments.Attachments
+	templates    *templates.Templates
+	worklogs     *worklogs.Worklogs
+	projects     *projects.Projects
+	caldav       *caldav.CalDAV
 }
 
 // Health returns the health of the app along with other info like version
@@ -34,11 +48,30 @@ func (a *API) Health() (map[string]interface{}, error) {
 }
 
 // NewService returns a new instance of API with all the dependencies initialized
-func NewService(l logger.Logger, us *users.Users, ts *tasks.Tasks, es *emailService.Mailer) (*API, error) {
+func NewService(
+	l logger.Logger,
+	us *users.Users,
+	ts *tasks.Tasks,
+	es *emailService.Mailer,
+	rs *reminders.Reminders,
+	cs *comments.Comments,
+	as *attachments.Attachments,
+	tps *templates.Templates,
+	wls *worklogs.Worklogs,
+	ps *projects.Projects,
+	cds *caldav.CalDAV,
+) (*API, error) {
 	return &API{
 		logger:       l,
 		users:        us,
 		tasks:        ts,
 		emailService: es,
+		reminders:    rs,
+		comments:     cs,
+		attachments:  as,
+		templates:    tps,
+		worklogs:     wls,
+		projects:     ps,
+		caldav:       cds,
 	}, nil
 }
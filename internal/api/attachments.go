@@ -0,0 +1,72 @@
+package api
+
+import (
+	"context"
+	"io"
+	"task-scheduler/internal/attachments"
+)
+
+// UploadAttachment attaches a file to a task the user can edit
+func (a *API) UploadAttachment(ctx context.Context, tid int64, uid int64, filename string, r io.Reader) (*attachments.Attachment, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	att, err := a.attachments.Upload(ctx, tid, uid, filename, r)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return att, nil
+}
+
+// DownloadAttachment returns an attachment of a task the user can view, along with its content
+func (a *API) DownloadAttachment(ctx context.Context, tid int64, aid int64, uid int64) (*attachments.Attachment, io.ReadCloser, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	att, content, err := a.attachments.Download(ctx, tid, aid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, nil, err
+	}
+
+	return att, content, nil
+}
+
+// GetAttachments returns the attachments of a task the user can view
+func (a *API) GetAttachments(ctx context.Context, tid int64, uid int64) ([]attachments.Attachment, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.attachments.GetAll(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// DeleteAttachment deletes an attachment of a task the user can edit, only the user who uploaded
+// it can delete it
+func (a *API) DeleteAttachment(ctx context.Context, tid int64, aid int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return err
+	}
+
+	err = a.attachments.Delete(ctx, tid, aid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+
+	"task-scheduler/internal/projects"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// Actions on a task, each allowed to the users who have one of its relations in taskPolicy
+const (
+	// actionView is reading the task and what belongs to it, and commenting on it
+	actionView = "view"
+	// actionEdit is changing the task, its dependencies, attachments and logging time on it
+	actionEdit = "edit"
+	// actionManage is deleting the task, and changing who it is shared with and its reminders
+	actionManage = "manage"
+)
+
+// Relations a user can have with a task. A user can have many, e.g. the owner of a task who is a
+// viewer of its project
+const (
+	relationOwner         = tasks.RoleOwner
+	relationAssignee      = tasks.RoleAssignee
+	relationWatcher       = tasks.RoleWatcher
+	relationProjectOwner  = "project " + projects.RoleOwner
+	relationProjectEditor = "project " + projects.RoleEditor
+	relationProjectViewer = "project " + projects.RoleViewer
+)
+
+// taskPolicy are the relations allowed to do each action
+var taskPolicy = map[string]map[string]bool{
+	actionView: {
+		relationOwner:         true,
+		relationAssignee:      true,
+		relationWatcher:       true,
+		relationProjectOwner:  true,
+		relationProjectEditor: true,
+		relationProjectViewer: true,
+	},
+	actionEdit: {
+		relationOwner:         true,
+		relationAssignee:      true,
+		relationProjectOwner:  true,
+		relationProjectEditor: true,
+	},
+	actionManage: {
+		relationOwner:        true,
+		relationProjectOwner: true,
+	},
+}
+
+// authorize returns nil if any of the relations is allowed to do the action. Users without any
+// relation with the task get a NotFound error, so the existence of tasks which are not shared with
+// them is not disclosed. The others get an Unauthorized error
+func authorize(relations map[string]bool, action string) error {
+	if len(relations) == 0 {
+		return errors.NotFound("task not found")
+	}
+
+	for relation := range relations {
+		if taskPolicy[action][relation] {
+			return nil
+		}
+	}
+
+	return errors.Unauthorizedf("you are not allowed to %s this task", action)
+}
+
+// taskRelations returns the relations of the user with the task
+func (a *API) taskRelations(ctx context.Context, t *tasks.Task, uid int64) (map[string]bool, error) {
+	relations := map[string]bool{}
+	if t.UID == uid {
+		relations[relationOwner] = true
+	}
+
+	roles, err := a.tasks.Roles(ctx, t.TID, uid)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		relations[role] = true
+	}
+
+	if t.ProjectID != 0 {
+		p, err := a.projects.Authorize(ctx, t.ProjectID, uid, projects.RoleViewer)
+		if err != nil && !errors.HasType(err, errors.TypeNotFound) {
+			return nil, err
+		}
+		if p != nil {
+			relations["project "+p.Role] = true
+		}
+	}
+
+	return relations, nil
+}
+
+// authorizeTask returns the task if the user is allowed to do the action on it
+func (a *API) authorizeTask(ctx context.Context, tid int64, uid int64, action string) (*tasks.Task, error) {
+	t, err := a.tasks.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.authorizeOn(ctx, t, uid, action)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// authorizeTrashed returns the task in the trash if the user is allowed to do the action on it
+func (a *API) authorizeTrashed(ctx context.Context, tid int64, uid int64, action string) (*tasks.Task, error) {
+	t, err := a.tasks.Trashed(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.authorizeOn(ctx, t, uid, action)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// authorizeOn checks if the user is allowed to do the action on the task
+func (a *API) authorizeOn(ctx context.Context, t *tasks.Task, uid int64, action string) error {
+	relations, err := a.taskRelations(ctx, t, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return authorize(relations, action)
+}
+
+// authorizeOwner checks if the user can change the owner of the task to owner. It is part of
+// managing the task, except for users taking a task which has no owner
+func (a *API) authorizeOwner(ctx context.Context, t *tasks.Task, owner int64, uid int64) error {
+	if owner == t.UID || (t.UID == 0 && owner == uid) {
+		return nil
+	}
+
+	return a.authorizeOn(ctx, t, uid, actionManage)
+}
+
+// authorizeParent checks if the user can add subtasks to the task parentID, 0 being no parent
+func (a *API) authorizeParent(ctx context.Context, parentID int64, uid int64) error {
+	if parentID == 0 {
+		return nil
+	}
+
+	_, err := a.authorizeTask(ctx, parentID, uid, actionEdit)
+	return err
+}
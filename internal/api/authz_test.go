@@ -0,0 +1,109 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	allowed   = "allowed"
+	forbidden = "forbidden"
+	notFound  = "not found"
+)
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name      string
+		relations []string
+		want      map[string]string
+	}{
+		{
+			name:      "no relation",
+			relations: nil,
+			want:      map[string]string{actionView: notFound, actionEdit: notFound, actionManage: notFound},
+		},
+		{
+			name:      "owner",
+			relations: []string{relationOwner},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: allowed},
+		},
+		{
+			name:      "assignee",
+			relations: []string{relationAssignee},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: forbidden},
+		},
+		{
+			name:      "watcher",
+			relations: []string{relationWatcher},
+			want:      map[string]string{actionView: allowed, actionEdit: forbidden, actionManage: forbidden},
+		},
+		{
+			name:      "project owner",
+			relations: []string{relationProjectOwner},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: allowed},
+		},
+		{
+			name:      "project editor",
+			relations: []string{relationProjectEditor},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: forbidden},
+		},
+		{
+			name:      "project viewer",
+			relations: []string{relationProjectViewer},
+			want:      map[string]string{actionView: allowed, actionEdit: forbidden, actionManage: forbidden},
+		},
+		{
+			name:      "owner and project viewer",
+			relations: []string{relationOwner, relationProjectViewer},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: allowed},
+		},
+		{
+			name:      "watcher and project viewer",
+			relations: []string{relationWatcher, relationProjectViewer},
+			want:      map[string]string{actionView: allowed, actionEdit: forbidden, actionManage: forbidden},
+		},
+		{
+			name:      "watcher and project editor",
+			relations: []string{relationWatcher, relationProjectEditor},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: forbidden},
+		},
+		{
+			name:      "assignee and project editor",
+			relations: []string{relationAssignee, relationProjectEditor},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: forbidden},
+		},
+		{
+			name:      "assignee and project owner",
+			relations: []string{relationAssignee, relationProjectOwner},
+			want:      map[string]string{actionView: allowed, actionEdit: allowed, actionManage: allowed},
+		},
+	}
+
+	for _, tt := range tests {
+		relations := map[string]bool{}
+		for _, relation := range tt.relations {
+			relations[relation] = true
+		}
+
+		for _, action := range []string{actionView, actionEdit, actionManage} {
+			err := authorize(relations, action)
+
+			got := allowed
+			switch {
+			case err == nil:
+			case errors.HasType(err, errors.TypeNotFound):
+				got = notFound
+			case errors.HasType(err, errors.TypeUnauthorized):
+				got = forbidden
+			default:
+				t.Errorf("%s, %s: unexpected error %v", tt.name, action, err)
+				continue
+			}
+
+			if got != tt.want[action] {
+				t.Errorf("%s, %s: got %s, want %s", tt.name, action, got, tt.want[action])
+			}
+		}
+	}
+}
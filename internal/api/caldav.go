@@ -0,0 +1,68 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"task-scheduler/internal/caldav"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+)
+
+// Authenticate checks the credentials of clients which use basic authentication
+func (a *API) Authenticate(ctx context.Context, email string, password string) (*users.User, error) {
+	u, err := a.users.Authenticate(ctx, email, password)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return u, nil
+}
+
+func (a *API) CalDAVResources(ctx context.Context, uid int64) ([]caldav.Resource, error) {
+	list, err := a.caldav.Resources(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (a *API) CalDAVResource(ctx context.Context, uid int64, name string) (*caldav.Resource, error) {
+	r, err := a.caldav.Get(ctx, name, a.caldavAuthorize(uid, actionView))
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (a *API) PutCalDAVResource(ctx context.Context, uid int64, name string, body io.Reader, ifMatch string, ifNoneMatch string) (*caldav.Resource, bool, error) {
+	r, created, err := a.caldav.Put(ctx, uid, name, body, ifMatch, ifNoneMatch, a.caldavAuthorize(uid, actionEdit))
+	if err != nil {
+		a.logger.Error(err)
+		return nil, false, err
+	}
+
+	return r, created, nil
+}
+
+func (a *API) DeleteCalDAVResource(ctx context.Context, uid int64, name string, ifMatch string) error {
+	err := a.caldav.Delete(ctx, uid, name, ifMatch, a.caldavAuthorize(uid, actionManage))
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// caldavAuthorize returns the check of the task policy for the action, for CalDAV requests
+func (a *API) caldavAuthorize(uid int64, action string) caldav.Authorize {
+	return func(ctx context.Context, tid int64) (*tasks.Task, error) {
+		return a.authorizeTask(ctx, tid, uid, action)
+	}
+}
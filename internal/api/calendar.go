@@ -0,0 +1,50 @@
+package api
+
+import (
+	"context"
+	"task-scheduler/internal/ical"
+	"task-scheduler/internal/tasks"
+)
+
+func (a *API) CalendarToken(ctx context.Context, uid int64) (string, error) {
+	token, err := a.users.CalendarToken(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+func (a *API) RegenerateCalendarToken(ctx context.Context, uid int64) (string, error) {
+	token, err := a.users.RegenerateCalendarToken(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return "", err
+	}
+
+	return token, nil
+}
+
+// TaskCalendar returns the calendar of the user who owns the token, with the tasks rendered as
+// component (tasks.ComponentTodo or tasks.ComponentEvent)
+func (a *API) TaskCalendar(ctx context.Context, token string, component string) (*ical.Component, error) {
+	u, err := a.users.GetUserByCalendarToken(ctx, token)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	list, err := a.tasks.Calendar(ctx, u.UID)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	name := "Tasks"
+	if u.Name != "" {
+		name = u.Name + "'s tasks"
+	}
+
+	return tasks.NewCalendar(name, list, component), nil
+}
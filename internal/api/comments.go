@@ -0,0 +1,70 @@
+package api
+
+import (
+	"context"
+	"task-scheduler/internal/comments"
+)
+
+// AddComment adds a comment to a task, any user who can view the task can comment on it
+func (a *API) AddComment(ctx context.Context, c *comments.Comment) (*comments.Comment, error) {
+	_, err := a.authorizeTask(ctx, c.TID, c.UID, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err = a.comments.Create(ctx, c)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// EditComment changes a comment of a task the user can still view, only its author can change it
+func (a *API) EditComment(ctx context.Context, tid int64, cid int64, uid int64, body string) (*comments.Comment, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	c, err := a.comments.Edit(ctx, tid, cid, uid, body)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// DeleteComment deletes a comment of a task the user can still view, only its author can delete it
+func (a *API) DeleteComment(ctx context.Context, tid int64, cid int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return err
+	}
+
+	err = a.comments.Delete(ctx, tid, cid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// GetComments returns the comments of a task the user can view
+func (a *API) GetComments(ctx context.Context, tid int64, uid int64) ([]comments.Comment, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.comments.GetAll(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"html"
+	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/tasks"
+)
+
+func (a *API) AddTaskDependency(ctx context.Context, tid int64, blockedBy int64, uid int64) (*tasks.Dependency, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	// the task it depends on should be visible too
+	_, err = a.authorizeTask(ctx, blockedBy, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := a.tasks.AddDependency(ctx, tid, blockedBy)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (a *API) RemoveTaskDependency(ctx context.Context, tid int64, blockedBy int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return err
+	}
+
+	err = a.tasks.RemoveDependency(ctx, tid, blockedBy)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *API) GetTaskDependencies(ctx context.Context, tid int64, uid int64) (*tasks.DependencyGraph, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	graph, err := a.tasks.Dependencies(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	// the tasks of the graph which the user cannot view are only shown by their ID
+	visible := map[int64]bool{tid: true}
+	for i := range graph.Tasks {
+		t := &graph.Tasks[i]
+		if t.TID == tid {
+			continue
+		}
+
+		relations, err := a.taskRelations(ctx, t, uid)
+		if err != nil {
+			a.logger.Error(err)
+			return nil, err
+		}
+		visible[t.TID] = authorize(relations, actionView) == nil
+	}
+	graph.Redact(visible)
+
+	return graph, nil
+}
+
+// notifyUnblocked emails the assignees of the tasks which were waiting only on the completed task tid
+func (a *API) notifyUnblocked(ctx context.Context, tid int64) {
+	unblocked, err := a.tasks.Unblocked(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return
+	}
+
+	for _, t := range unblocked {
+		to := t.AssignedTo
+		if to == "" {
+			u, err := a.users.GetUserByID(ctx, t.UID)
+			if err != nil {
+				a.logger.Error(err)
+				continue
+			}
+			to = u.Email
+		}
+
+		email := new(emailService.Email)
+		email.Subject = "Task unblocked"
+		email.To = to
+		email.HtmlContent = `<p>Hello</p>
+		<p>All the tasks blocking the following task are now complete, you can start working on it.</p>
+		<p>` + html.EscapeString(t.Detail) + `</p>`
+		err := a.emailService.SendEmail(ctx, *email)
+		if err != nil {
+			a.logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"context"
+	"io"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// ExportTasks calls each with every task of the user matching the filter
+func (a *API) ExportTasks(ctx context.Context, uid int64, filter *tasks.Filter, each func(t *tasks.Task) error) error {
+	err := a.tasks.Export(ctx, uid, filter, each)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+// ImportTasks imports the tasks of a file in format on behalf of the user. Tasks of projects are
+// checked and get the project's defaults, like when they are created one by one
+func (a *API) ImportTasks(ctx context.Context, uid int64, r io.Reader, format string, dryRun bool) (*tasks.ImportResult, error) {
+	rows, err := tasks.DecodeImport(r, format)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	err = a.prepareImport(ctx, uid, rows)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	result, err := a.tasks.Import(ctx, uid, rows, dryRun, nil)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// prepareImport sets the project defaults of the tasks of the rows, rows which are not valid for
+// their project get the error
+func (a *API) prepareImport(ctx context.Context, uid int64, rows []tasks.ImportRow) error {
+	list := make([]*tasks.Task, 0, len(rows))
+	for _, row := range rows {
+		if row.Err == nil {
+			list = append(list, row.Task)
+		}
+	}
+
+	errs := a.projects.PrepareTasks(ctx, uid, list)
+	idx := 0
+	for i := range rows {
+		if rows[i].Err != nil {
+			continue
+		}
+
+		err := errs[idx]
+		idx++
+		if err != nil && errors.HasType(err, errors.TypeInternal) {
+			return err
+		}
+		rows[i].Err = err
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"task-scheduler/internal/comments"
+	"task-scheduler/internal/importers"
+	"task-scheduler/internal/tasks"
+)
+
+// PreviewImport reads the exported file of the source as tasks, without importing them. The
+// records are checked like they would be on import, records which cannot be imported have their error
+func (a *API) PreviewImport(ctx context.Context, uid int64, source string, r io.Reader) ([]importers.Record, *importers.Report, error) {
+	records, report, err := a.importFile(ctx, uid, source, r, true)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, nil, err
+	}
+
+	return records, report, nil
+}
+
+// RunImport imports the exported file of the source on behalf of the user, along with the
+// comments of its items. Nothing is imported unless all the records are valid
+func (a *API) RunImport(ctx context.Context, uid int64, source string, r io.Reader) (*importers.Report, error) {
+	_, report, err := a.importFile(ctx, uid, source, r, false)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return report, nil
+}
+
+func (a *API) importFile(ctx context.Context, uid int64, source string, r io.Reader, dryRun bool) ([]importers.Record, *importers.Report, error) {
+	records, err := importers.Parse(source, r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := make([]tasks.ImportRow, 0, len(records))
+	byRow := make(map[int]*importers.Record, len(records))
+	for idx := range records {
+		record := &records[idx]
+		byRow[record.Row] = record
+		rows = append(rows, tasks.ImportRow{Row: record.Row, Task: record.Task, Err: record.Err})
+	}
+
+	err = a.prepareImport(ctx, uid, rows)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	commentCount := 0
+	result, err := a.tasks.Import(ctx, uid, rows, dryRun, func(ctx context.Context, imported []tasks.ImportRow) error {
+		list := []comments.Comment{}
+		for _, row := range imported {
+			for _, c := range byRow[row.Row].Comments {
+				body := c.Body
+				if c.Author != "" {
+					body = fmt.Sprintf("%s wrote:\n\n%s", c.Author, c.Body)
+				}
+				list = append(list, comments.Comment{
+					TID:       row.Task.TID,
+					UID:       uid,
+					Body:      body,
+					CreatedAt: c.CreatedAt,
+				})
+			}
+		}
+		var err error
+		commentCount, err = a.comments.Import(ctx, list)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	report := &importers.Report{
+		Source:   strings.ToLower(strings.TrimSpace(source)),
+		DryRun:   result.DryRun,
+		Total:    result.Total,
+		Valid:    result.Valid,
+		Imported: result.Imported,
+		Comments: commentCount,
+		Failed:   make([]importers.Record, 0, len(result.Failed)),
+	}
+	for idx, row := range rows {
+		records[idx].Err = row.Err
+		if row.Err != nil {
+			report.Failed = append(report.Failed, records[idx])
+		}
+	}
+
+	return records, report, nil
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"context"
+	"html"
+	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/tasks"
+)
+
+func (a *API) AddTaskMember(ctx context.Context, tid int64, m *tasks.Member, uid int64) (*tasks.Member, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err = a.tasks.AddMember(ctx, tid, m)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (a *API) RemoveTaskMember(ctx context.Context, tid int64, mid int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionManage)
+	if err != nil {
+		return err
+	}
+
+	err = a.tasks.RemoveMember(ctx, tid, mid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *API) GetTaskMembers(ctx context.Context, tid int64, uid int64) ([]tasks.Member, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := a.tasks.Members(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return members, nil
+}
+
+// notifyWatchers emails the watchers of task tid, except the user who made the change
+func (a *API) notifyWatchers(ctx context.Context, tid int64, actorUID int64, subject string, message string) {
+	watchers, err := a.tasks.Watchers(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return
+	}
+
+	if len(watchers) == 0 {
+		return
+	}
+
+	t, err := a.tasks.Get(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return
+	}
+
+	for _, w := range watchers {
+		if w.UID != 0 && w.UID == actorUID {
+			continue
+		}
+
+		email := new(emailService.Email)
+		email.Subject = subject
+		email.To = w.Email
+		email.HtmlContent = `<p>Hello</p>
+		<p>` + message + `</p>
+		<p>` + html.EscapeString(t.Detail) + `</p>`
+		err := a.emailService.SendEmail(ctx, *email)
+		if err != nil {
+			a.logger.Error(err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"context"
+	"task-scheduler/internal/projects"
+	"task-scheduler/internal/tasks"
+)
+
+func (a *API) CreateProject(ctx context.Context, p *projects.Project, uid int64) (*projects.Project, error) {
+	p, err := a.projects.Create(ctx, p, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (a *API) EditProject(ctx context.Context, pid int64, uid int64, p *projects.Project) (*projects.Project, error) {
+	p, err := a.projects.Edit(ctx, pid, uid, p)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (a *API) GetProject(ctx context.Context, pid int64, uid int64) (*projects.Project, error) {
+	p, err := a.projects.Get(ctx, pid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (a *API) GetProjects(ctx context.Context, uid int64, archived bool) ([]projects.Project, error) {
+	list, err := a.projects.GetAll(ctx, uid, archived)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (a *API) ArchiveProject(ctx context.Context, pid int64, uid int64, archive bool) (*projects.Project, error) {
+	p, err := a.projects.Archive(ctx, pid, uid, archive)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (a *API) GetProjectMembers(ctx context.Context, pid int64, uid int64) ([]projects.Member, error) {
+	list, err := a.projects.Members(ctx, pid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (a *API) AddProjectMember(ctx context.Context, pid int64, uid int64, email string, role string) (*projects.Member, error) {
+	m, err := a.projects.AddMember(ctx, pid, uid, email, role)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (a *API) RemoveProjectMember(ctx context.Context, pid int64, uid int64, memberUID int64) error {
+	err := a.projects.RemoveMember(ctx, pid, uid, memberUID)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *API) GetProjectTasks(ctx context.Context, pid int64, uid int64, filter *tasks.Filter) (*tasks.Page, error) {
+	page, err := a.projects.Tasks(ctx, pid, uid, filter)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (a *API) MoveTask(ctx context.Context, tid int64, pid int64, uid int64) (*tasks.Task, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := a.projects.MoveTask(ctx, tid, pid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
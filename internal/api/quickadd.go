@@ -0,0 +1,55 @@
+package api
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/quickadd"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// QuickAdd reads a task from text, with dates in the timezone of the user. The task is only
+// created if save is true, otherwise the result is returned to be confirmed. Assignees who are not
+// registered are invited, like the ones of assigned tasks
+func (a *API) QuickAdd(ctx context.Context, uid int64, text string, save bool) (*quickadd.Result, error) {
+	u, err := a.users.GetUserByID(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	result, err := quickadd.Parse(text, time.Now().In(u.Location()))
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+	result.Task.UID = uid
+
+	if !save {
+		return result, nil
+	}
+
+	emails := append([]string{result.Task.AssignedTo}, result.Task.Assignees...)
+	result.Task, err = a.CreateTask(ctx, result.Task)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		_, err := a.users.GetUserByEmail(ctx, email)
+		if errors.HasType(err, errors.TypeNotFound) {
+			a.invite(ctx, result.Task.TID, email)
+		} else if err != nil {
+			a.logger.Error(err)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"context"
+
+	"task-scheduler/internal/reminders"
+)
+
+func (a *API) GetTaskReminders(ctx context.Context, tid int64, uid int64) (*reminders.Override, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err := a.reminders.Override(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// SetTaskReminders overrides the reminder offsets of a task. Only the owner of the task, or of its
+// project, can change its reminders
+func (a *API) SetTaskReminders(ctx context.Context, tid int64, uid int64, o *reminders.Override) (*reminders.Override, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	o, err = a.reminders.SaveOverride(ctx, tid, o)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return o, nil
+}
+
+func (a *API) ResetTaskReminders(ctx context.Context, tid int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionManage)
+	if err != nil {
+		return err
+	}
+
+	err = a.reminders.DeleteOverride(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
@@ -3,12 +3,33 @@ package api
 import (
 	"context"
 	"strconv"
+	"strings"
 	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/projects"
 	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+	"time"
 )
 
 func (a *API) CreateTask(ctx context.Context, t *tasks.Task) (*tasks.Task, error) {
-	t, err := a.tasks.Create(ctx, t)
+	err := a.authorizeParent(ctx, t.ParentID, t.UID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.projects.PrepareTask(ctx, t.UID, t)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	err = a.dueTimezone(ctx, t.UID, t)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	t, err = a.tasks.Create(ctx, t)
 	if err != nil {
 		a.logger.Error(err)
 		return nil, err
@@ -17,34 +38,99 @@ func (a *API) CreateTask(ctx context.Context, t *tasks.Task) (*tasks.Task, error
 	return t, nil
 }
 
-func (a *API) AssignTask(ctx context.Context, t *tasks.Task) (*tasks.Task, error) {
-	u, err := a.users.GetUserByEmail(ctx, t.AssignedTo)
-	if err == nil {
-		t.UID = u.UID
+// dueTimezone sets the timezone of the due date of a new task to the one of its owner, unless
+// it was given one or has no owner. Every path creating tasks should call it
+func (a *API) dueTimezone(ctx context.Context, owner int64, t *tasks.Task) error {
+	if owner == 0 || t.DueTimezone != "" || (t.DueDate == "" && t.CompleteBy.IsZero()) {
+		return nil
+	}
+
+	u, err := a.users.GetUserByID(ctx, owner)
+	if err != nil {
+		return err
+	}
+
+	if u.Timezone != users.DefaultTimezone {
+		t.DueTimezone = u.Timezone
+	}
+	return nil
+}
+
+// AssignTask creates a task assigned to one or more people on behalf of the user. The task belongs
+// to the first assignee who has registered, the others are invited to register. The user should be
+// able to add tasks to its parent and project, like with CreateTask
+func (a *API) AssignTask(ctx context.Context, t *tasks.Task, uid int64) (*tasks.Task, error) {
+	err := a.authorizeParent(ctx, t.ParentID, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = a.projects.PrepareTask(ctx, uid, t)
+	if err != nil {
+		return nil, err
 	}
 
-	t, err2 := a.tasks.Create(ctx, t)
-	if err2 != nil {
+	t.UID = 0
+	emails := append([]string{t.AssignedTo}, t.Assignees...)
+	unregistered := []string{}
+	for _, email := range emails {
+		if strings.TrimSpace(email) == "" {
+			continue
+		}
+
+		u, err := a.users.GetUserByEmail(ctx, strings.TrimSpace(email))
+		if err != nil {
+			unregistered = append(unregistered, strings.TrimSpace(email))
+			continue
+		}
+
+		if t.UID == 0 {
+			t.UID = u.UID
+		}
+	}
+
+	err = a.dueTimezone(ctx, t.UID, t)
+	if err != nil {
 		a.logger.Error(err)
 		return nil, err
 	}
 
+	t, err = a.tasks.Assign(ctx, t, uid)
 	if err != nil {
-		link := "http://localhost:8080/api/auth/register?tid=" + strconv.FormatInt(t.TID, 10)
-		email := new(emailService.Email)
-		email.Subject = "New Task Assigned"
-		email.To = t.AssignedTo
-		email.HtmlContent = `<p>Hello</p>
-		<p>You've been assigned a new task. Please follow the link to register a new account and see your tasks.</p>
-		<p><a href=` + link + `>Register (` + link + `)</a></p>`
-		a.emailService.SendEmail(ctx, *email)
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	for _, email := range unregistered {
+		a.invite(ctx, t.TID, email)
 	}
 
 	return t, nil
 }
 
-func (a *API) DeleteTask(ctx context.Context, tid int64) error {
-	err := a.tasks.Delete(ctx, tid)
+// invite emails someone who has been assigned a task without being registered
+func (a *API) invite(ctx context.Context, tid int64, to string) {
+	link := "http://localhost:8080/api/auth/register?tid=" + strconv.FormatInt(tid, 10)
+	email := new(emailService.Email)
+	email.Subject = "New Task Assigned"
+	email.To = to
+	email.HtmlContent = `<p>Hello</p>
+		<p>You've been assigned a new task. Please follow the link to register a new account and see your tasks.</p>
+		<p><a href=` + link + `>Register (` + link + `)</a></p>`
+	err := a.emailService.SendEmail(ctx, *email)
+	if err != nil {
+		a.logger.Error(err)
+	}
+}
+
+// DeleteTask deletes the task, if version is not 0 it is only deleted if it is still at that version
+func (a *API) DeleteTask(ctx context.Context, tid int64, children string, version int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionManage)
+	if err != nil {
+		return err
+	}
+
+	_, err = a.tasks.Delete(ctx, tid, children, version, uid)
 	if err != nil {
 		a.logger.Error(err)
 		return err
@@ -53,32 +139,332 @@ func (a *API) DeleteTask(ctx context.Context, tid int64) error {
 	return nil
 }
 
-func (a *API) EditTask(ctx context.Context, tid int64, t *tasks.Task) (*tasks.Task, error) {
-	t, err := a.tasks.Edit(ctx, tid, t)
+// BulkTasks applies a batch of operations, it returns whether they were committed
+func (a *API) BulkTasks(ctx context.Context, mode string, ops []tasks.Operation, uid int64) ([]tasks.OperationResult, bool, error) {
+	results, committed, err := a.tasks.Bulk(ctx, mode, ops, uid, func(ctx context.Context, op *tasks.Operation) error {
+		switch op.Op {
+		case tasks.OpCreate:
+			err := a.authorizeParent(ctx, op.Task.ParentID, uid)
+			if err != nil {
+				return err
+			}
+			err = a.projects.PrepareTask(ctx, uid, op.Task)
+			if err != nil {
+				return err
+			}
+			return a.dueTimezone(ctx, uid, op.Task)
+		case tasks.OpEdit:
+			current, err := a.authorizeTask(ctx, op.TID, uid, actionEdit)
+			if err != nil {
+				return err
+			}
+
+			if op.Task.UID != 0 {
+				err = a.authorizeOwner(ctx, current, op.Task.UID, uid)
+				if err != nil {
+					return err
+				}
+			}
+
+			if op.Task.ParentID != current.ParentID {
+				err = a.authorizeParent(ctx, op.Task.ParentID, uid)
+				if err != nil {
+					return err
+				}
+			}
+
+			// edits do not change the project, so another project is a move, which checks
+			// both projects. Moving changes the version, so a conditional edit is checked
+			// before the move and then expects the version after it
+			if op.Task.ProjectID != 0 && op.Task.ProjectID != current.ProjectID {
+				if op.Version != 0 && op.Version != current.Version {
+					return tasks.ErrVersionMismatch
+				}
+				moved, err := a.projects.MoveTask(ctx, op.TID, op.Task.ProjectID, uid)
+				if err != nil {
+					return err
+				}
+				if op.Version != 0 {
+					op.Version = moved.Version
+				}
+			}
+			return nil
+		case tasks.OpDelete:
+			_, err := a.authorizeTask(ctx, op.TID, uid, actionManage)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		a.logger.Error(err)
+		return nil, false, err
+	}
+
+	return results, committed, nil
+}
+
+// GetTrash returns the deleted tasks the user can restore, theirs and those of the projects they own
+func (a *API) GetTrash(ctx context.Context, uid int64) ([]tasks.Task, error) {
+	owned, err := a.projects.GetAll(ctx, uid, true)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	projectIDs := []int64{}
+	for _, p := range owned {
+		if p.Role == projects.RoleOwner {
+			projectIDs = append(projectIDs, p.ID)
+		}
+	}
+
+	list, err := a.tasks.Trash(ctx, uid, projectIDs)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// RestoreTask moves a deleted task out of the trash, it needs the same permission as deleting it
+func (a *API) RestoreTask(ctx context.Context, tid int64, uid int64) (*tasks.Task, error) {
+	_, err := a.authorizeTrashed(ctx, tid, uid, actionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := a.tasks.Restore(ctx, tid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// PurgeTrash permanently deletes the tasks which have been in the trash for longer than retention,
+// along with their attachments
+func (a *API) PurgeTrash(ctx context.Context, retention time.Duration) error {
+	purged, err := a.tasks.Purge(ctx, retention)
+	// the tasks purged before an error still need their attachments deleted
+	if len(purged) > 0 {
+		aerr := a.attachments.DeleteForTasks(ctx, purged)
+		if aerr != nil {
+			a.logger.Error(aerr)
+		}
+	}
+
+	return err
+}
+
+func (a *API) EditTask(ctx context.Context, tid int64, t *tasks.Task, uid int64) (*tasks.Task, error) {
+	current, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	// the owner is kept if it is not set
+	if t.UID != 0 {
+		err = a.authorizeOwner(ctx, current, t.UID, uid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if t.ParentID != current.ParentID {
+		err = a.authorizeParent(ctx, t.ParentID, uid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, err = a.tasks.Edit(ctx, tid, t, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	a.notifyWatchers(ctx, tid, uid, "Task updated", "The following task you are watching has been updated.")
+
+	return t, nil
+}
+
+// PatchTask updates the fields of the task changed by the patch, if version is not 0 it is only
+// patched if it is still at that version
+func (a *API) PatchTask(ctx context.Context, tid int64, p *tasks.Patch, version int64, uid int64) (*tasks.Task, error) {
+	current, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := a.tasks.Patch(ctx, tid, p, version, uid, func(ctx context.Context, t *tasks.Task) error {
+		err := a.authorizeOwner(ctx, current, t.UID, uid)
+		if err != nil || t.ParentID == current.ParentID {
+			return err
+		}
+		return a.authorizeParent(ctx, t.ParentID, uid)
+	})
 	if err != nil {
 		a.logger.Error(err)
 		return nil, err
 	}
 
+	a.notifyWatchers(ctx, tid, uid, "Task updated", "The following task you are watching has been updated.")
+
 	return t, nil
 }
 
-func (a *API) GetTask(ctx context.Context, tid int64) (*tasks.Task, error) {
-	task, err := a.tasks.Get(ctx, tid)
+// GetTask returns the task if the user can see it
+func (a *API) GetTask(ctx context.Context, tid int64, uid int64) (*tasks.Task, error) {
+	return a.authorizeTask(ctx, tid, uid, actionView)
+}
+
+func (a *API) GetSubtree(ctx context.Context, tid int64, uid int64) (*tasks.Node, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := a.tasks.Subtree(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return node, nil
+}
+
+func (a *API) GetAllTasks(ctx context.Context, uid int64, filter *tasks.Filter) (*tasks.Page, error) {
+	page, err := a.tasks.GetAll(ctx, uid, filter)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return page, nil
+}
+
+func (a *API) GetTaskActivity(ctx context.Context, tid int64, uid int64) ([]tasks.Event, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := a.tasks.Activity(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (a *API) GetActivityFeed(ctx context.Context, uid int64, before int64, limit uint64) ([]tasks.Event, error) {
+	events, err := a.tasks.Feed(ctx, uid, before, limit)
 	if err != nil {
 		a.logger.Error(err)
 		return nil, err
 	}
 
+	return events, nil
+}
+
+// SearchTasks searches the tasks the user can view, the tasks of archived projects are left out
+func (a *API) SearchTasks(ctx context.Context, uid int64, q string, limit uint64) ([]tasks.SearchResult, error) {
+	list, err := a.projects.GetAll(ctx, uid, false)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	projectIDs := make([]int64, 0, len(list))
+	for _, p := range list {
+		projectIDs = append(projectIDs, p.ID)
+	}
+
+	results, err := a.tasks.Search(ctx, uid, projectIDs, q, limit)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return results, nil
+}
+
+func (a *API) PreviewRecurrence(ctx context.Context, r *tasks.Recurrence, after time.Time, count int) ([]time.Time, error) {
+	occurrences, err := tasks.PreviewRecurrence(r, after, count)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return occurrences, nil
+}
+
+func (a *API) TransitionTask(ctx context.Context, tid int64, uid int64, to string, note string) (*tasks.Task, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := a.tasks.Transition(ctx, tid, to, uid, note)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	if task.CompletedAt != nil {
+		a.notifyUnblocked(ctx, tid)
+		a.notifyWatchers(ctx, tid, uid, "Task completed", "The following task you are watching has been completed.")
+	}
+
 	return task, nil
 }
 
-func (a *API) GetAllTasks(ctx context.Context, uid int64) ([]tasks.Task, error) {
-	tasks, err := a.tasks.GetAll(ctx, uid)
+func (a *API) GetTaskTransitions(ctx context.Context, tid int64, uid int64) ([]tasks.Transition, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	transitions, err := a.tasks.Transitions(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return transitions, nil
+}
+
+func (a *API) GetWorkflow(ctx context.Context, projectID int64, uid int64) (*tasks.Workflow, error) {
+	_, err := a.projects.Authorize(ctx, projectID, uid, projects.RoleViewer)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	w, err := a.tasks.Workflow(ctx, projectID)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func (a *API) SaveWorkflow(ctx context.Context, projectID int64, uid int64, w *tasks.Workflow) (*tasks.Workflow, error) {
+	_, err := a.projects.Authorize(ctx, projectID, uid, projects.RoleOwner)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	w, err = a.tasks.SaveWorkflow(ctx, projectID, w)
 	if err != nil {
 		a.logger.Error(err)
 		return nil, err
 	}
 
-	return tasks, nil
+	return w, nil
 }
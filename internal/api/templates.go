@@ -0,0 +1,69 @@
+package api
+
+import (
+	"context"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/templates"
+)
+
+func (a *API) CreateTemplate(ctx context.Context, t *templates.Template) (*templates.Template, error) {
+	t, err := a.templates.Create(ctx, t)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (a *API) EditTemplate(ctx context.Context, id int64, uid int64, t *templates.Template) (*templates.Template, error) {
+	t, err := a.templates.Edit(ctx, id, uid, t)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (a *API) GetTemplate(ctx context.Context, id int64, uid int64) (*templates.Template, error) {
+	t, err := a.templates.Get(ctx, id, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (a *API) GetTemplates(ctx context.Context, uid int64) ([]templates.Template, error) {
+	list, err := a.templates.GetAll(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (a *API) DeleteTemplate(ctx context.Context, id int64, uid int64) error {
+	err := a.templates.Delete(ctx, id, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *API) InstantiateTemplate(ctx context.Context, id int64, uid int64, in *templates.Instantiation) ([]tasks.Task, error) {
+	list, err := a.templates.Instantiate(ctx, id, uid, in, func(ctx context.Context, t *tasks.Task) error {
+		return a.dueTimezone(ctx, uid, t)
+	})
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
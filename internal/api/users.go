@@ -24,3 +24,25 @@ func (a *API) Login(ctx context.Context, email string, password string) (users.J
 	}
 	return token, nil
 }
+
+// User returns the profile of the user
+func (a *API) User(ctx context.Context, uid int64) (*users.User, error) {
+	u, err := a.users.GetUserByID(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// SetTimezone changes the timezone dates are shown in to the user
+func (a *API) SetTimezone(ctx context.Context, uid int64, timezone string) (*users.User, error) {
+	u, err := a.users.SetTimezone(ctx, uid, timezone)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return u, nil
+}
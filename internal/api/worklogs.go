@@ -0,0 +1,111 @@
+package api
+
+import (
+	"context"
+	"task-scheduler/internal/worklogs"
+)
+
+func (a *API) StartTimer(ctx context.Context, tid int64, uid int64, note string) (*worklogs.Worklog, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	wl, err := a.worklogs.Start(ctx, tid, uid, note)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+func (a *API) StopTimer(ctx context.Context, uid int64) (*worklogs.Worklog, error) {
+	wl, err := a.worklogs.Stop(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+func (a *API) GetTimer(ctx context.Context, uid int64) (*worklogs.Worklog, error) {
+	wl, err := a.worklogs.Running(ctx, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+func (a *API) AddWorklog(ctx context.Context, wl *worklogs.Worklog) (*worklogs.Worklog, error) {
+	_, err := a.authorizeTask(ctx, wl.TID, wl.UID, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	wl, err = a.worklogs.Create(ctx, wl)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+func (a *API) EditWorklog(ctx context.Context, tid int64, wid int64, uid int64, wl *worklogs.Worklog) (*worklogs.Worklog, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return nil, err
+	}
+
+	wl, err = a.worklogs.Edit(ctx, tid, wid, uid, wl)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return wl, nil
+}
+
+func (a *API) DeleteWorklog(ctx context.Context, tid int64, wid int64, uid int64) error {
+	_, err := a.authorizeTask(ctx, tid, uid, actionEdit)
+	if err != nil {
+		return err
+	}
+
+	err = a.worklogs.Delete(ctx, tid, wid, uid)
+	if err != nil {
+		a.logger.Error(err)
+		return err
+	}
+
+	return nil
+}
+
+func (a *API) GetWorklogs(ctx context.Context, tid int64, uid int64) ([]worklogs.Worklog, error) {
+	_, err := a.authorizeTask(ctx, tid, uid, actionView)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := a.worklogs.GetAll(ctx, tid)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (a *API) WorklogsReport(ctx context.Context, uid int64, filter *worklogs.ReportFilter) ([]worklogs.ReportRow, error) {
+	list, err := a.worklogs.Report(ctx, uid, filter)
+	if err != nil {
+		a.logger.Error(err)
+		return nil, err
+	}
+
+	return list, nil
+}
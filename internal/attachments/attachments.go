@@ -0,0 +1,288 @@
+package attachments
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/blobstore"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// sniffLength is the number of bytes used to detect the type of a file
+const sniffLength = 512
+
+// Config holds the limits of attachments
+type Config struct {
+	// Dir is the directory of the local blob store
+	Dir string
+	// MaxSize is the maximum size of a file in bytes
+	MaxSize int64
+	// AllowedTypes are the MIME types which can be uploaded, the type is detected from the content
+	// of the file rather than trusting the client
+	AllowedTypes []string
+}
+
+type Attachment struct {
+	ID          int64  `json:"id,omitempty"`
+	TID         int64  `json:"tid,omitempty"`
+	UID         int64  `json:"uid,omitempty"`
+	Filename    string `json:"filename,omitempty"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	// Checksum is the hex encoded SHA-256 of the content
+	Checksum  string     `json:"checksum,omitempty"`
+	Key       string     `json:"-"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type Attachments struct {
+	logHandler logger.Logger
+	store      store
+	blobs      blobstore.BlobStore
+	tasks      *tasks.Tasks
+	cfg        *Config
+}
+
+// Upload stores the content of r as a new attachment of the task
+func (as *Attachments) Upload(ctx context.Context, tid int64, uid int64, filename string, r io.Reader) (*Attachment, error) {
+	_, err := as.tasks.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	filename = sanitizeFilename(filename)
+	if filename == "" {
+		return nil, errors.Validation("file name is required")
+	}
+
+	br := bufio.NewReaderSize(r, sniffLength)
+	head, err := br.Peek(sniffLength)
+	if err != nil && err != io.EOF {
+		return nil, errors.InputBodyErr(err, "could not read the file")
+	}
+
+	contentType := detectContentType(head, filename)
+	if !as.allowed(contentType) {
+		return nil, errors.Validationf("files of type '%s' are not allowed", contentType)
+	}
+
+	key, err := newKey(tid)
+	if err != nil {
+		return nil, err
+	}
+
+	// one byte more than the limit is read, so an oversized file can be told apart from one
+	// exactly at the limit
+	counter := &countingReader{r: io.LimitReader(br, as.cfg.MaxSize+1)}
+	hash := sha256.New()
+	err = as.blobs.Put(ctx, key, io.TeeReader(counter, hash))
+	if err != nil {
+		// errors from the blob store are already typed, others come from reading the upload
+		if _, ok := err.(*errors.Error); ok {
+			return nil, err
+		}
+		return nil, errors.InputBodyErr(err, "could not read the file")
+	}
+
+	if counter.n > as.cfg.MaxSize {
+		as.deleteBlob(ctx, key)
+		return nil, errors.Validationf("files cannot be larger than %d bytes", as.cfg.MaxSize)
+	}
+
+	now := time.Now()
+	a := &Attachment{
+		TID:         tid,
+		UID:         uid,
+		Filename:    filename,
+		ContentType: contentType,
+		Size:        counter.n,
+		Checksum:    hex.EncodeToString(hash.Sum(nil)),
+		Key:         key,
+		CreatedAt:   &now,
+	}
+
+	a.ID, err = as.store.Create(ctx, a)
+	if err != nil {
+		as.deleteBlob(ctx, key)
+		return nil, err
+	}
+
+	return a, nil
+}
+
+// Download returns an attachment of a task along with its content, which the caller should close
+func (as *Attachments) Download(ctx context.Context, tid int64, aid int64) (*Attachment, io.ReadCloser, error) {
+	a, err := as.get(ctx, tid, aid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	content, err := as.blobs.Get(ctx, a.Key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return a, content, nil
+}
+
+func (as *Attachments) GetAll(ctx context.Context, tid int64) ([]Attachment, error) {
+	return as.store.GetAll(ctx, []int64{tid})
+}
+
+// Delete deletes an attachment, only the user who uploaded it can delete it
+func (as *Attachments) Delete(ctx context.Context, tid int64, aid int64, uid int64) error {
+	a, err := as.get(ctx, tid, aid)
+	if err != nil {
+		return err
+	}
+
+	if a.UID != uid {
+		return errors.Unauthorized("only the user who uploaded an attachment can delete it")
+	}
+
+	err = as.store.Delete(ctx, []int64{a.ID})
+	if err != nil {
+		return err
+	}
+
+	as.deleteBlob(ctx, a.Key)
+	return nil
+}
+
+// DeleteForTasks deletes all the attachments of the tasks, along with their blobs. It is meant to
+// be called once the tasks are deleted
+func (as *Attachments) DeleteForTasks(ctx context.Context, tids []int64) error {
+	if len(tids) == 0 {
+		return nil
+	}
+
+	list, err := as.store.GetAll(ctx, tids)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]int64, 0, len(list))
+	for _, a := range list {
+		err = as.blobs.Delete(ctx, a.Key)
+		if err != nil {
+			// the attachment is kept, so the blob is not lost track of
+			as.logHandler.Error(err)
+			continue
+		}
+		ids = append(ids, a.ID)
+	}
+
+	return as.store.Delete(ctx, ids)
+}
+
+// get returns an attachment if it belongs to the task
+func (as *Attachments) get(ctx context.Context, tid int64, aid int64) (*Attachment, error) {
+	a, err := as.store.Get(ctx, aid)
+	if err != nil {
+		return nil, err
+	}
+
+	if a.TID != tid {
+		return nil, errors.NotFound("attachment not found")
+	}
+
+	return a, nil
+}
+
+func (as *Attachments) deleteBlob(ctx context.Context, key string) {
+	err := as.blobs.Delete(ctx, key)
+	if err != nil {
+		as.logHandler.Error(err)
+	}
+}
+
+func (as *Attachments) allowed(contentType string) bool {
+	for _, t := range as.cfg.AllowedTypes {
+		if strings.EqualFold(t, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectContentType detects the MIME type from the content. Plain text is refined using the file
+// extension, since formats like markdown or CSV cannot be told apart from their content
+func detectContentType(head []byte, filename string) string {
+	contentType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	if contentType != "text/plain" {
+		return contentType
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".md", ".markdown":
+		return "text/markdown"
+	case ".csv":
+		return "text/csv"
+	}
+	return contentType
+}
+
+// sanitizeFilename drops any directory from the file name provided by the client
+func sanitizeFilename(filename string) string {
+	filename = filepath.Base(strings.ReplaceAll(strings.TrimSpace(filename), "\\", "/"))
+	if filename == "." || filename == "/" {
+		return ""
+	}
+	return filename
+}
+
+// newKey returns a new random blob key for an attachment of the task
+func newKey(tid int64) (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.InternalErr(err, errors.DefaultMessage)
+	}
+	return fmt.Sprintf("tasks/%d/%s", tid, hex.EncodeToString(b)), nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func NewService(
+	l logger.Logger,
+	pqdriver *pgxpool.Pool,
+	blobs blobstore.BlobStore,
+	ts *tasks.Tasks,
+	cfg *Config,
+) (*Attachments, error) {
+	astore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Attachments{
+		logHandler: l,
+		store:      astore,
+		blobs:      blobs,
+		tasks:      ts,
+		cfg:        cfg,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package attachments
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Create(ctx context.Context, a *Attachment) (int64, error)
+	Get(ctx context.Context, aid int64) (*Attachment, error)
+	GetAll(ctx context.Context, tids []int64) ([]Attachment, error)
+	Delete(ctx context.Context, aids []int64) error
+}
+
+type attachmentStore struct {
+	qbuilder  squirrel.StatementBuilderType
+	pqdriver  *pgxpool.Pool
+	tableName string
+}
+
+var attachmentColumns = []string{
+	"id",
+	"tid",
+	"uid",
+	"filename",
+	"contentType",
+	"size",
+	"checksum",
+	"blobKey",
+	"createdAt",
+}
+
+func (as *attachmentStore) Create(ctx context.Context, a *Attachment) (int64, error) {
+	query, args, err := as.qbuilder.Insert(as.tableName).SetMap(map[string]interface{}{
+		"tid":         a.TID,
+		"uid":         a.UID,
+		"filename":    a.Filename,
+		"contentType": a.ContentType,
+		"size":        a.Size,
+		"checksum":    a.Checksum,
+		"blobKey":     a.Key,
+		"createdAt":   a.CreatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	var id int64
+	err = as.pqdriver.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return id, nil
+}
+
+func (as *attachmentStore) Get(ctx context.Context, aid int64) (*Attachment, error) {
+	query, args, err := as.qbuilder.Select(
+		attachmentColumns...,
+	).From(
+		as.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": aid,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	a, err := scanAttachment(as.pqdriver.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("attachment not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return a, nil
+}
+
+func (as *attachmentStore) GetAll(ctx context.Context, tids []int64) ([]Attachment, error) {
+	query, args, err := as.qbuilder.Select(
+		attachmentColumns...,
+	).From(
+		as.tableName,
+	).Where(
+		squirrel.Eq{
+			"tid": tids,
+		},
+	).OrderBy(
+		"createdAt",
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := as.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Attachment{}
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *a)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+func (as *attachmentStore) Delete(ctx context.Context, aids []int64) error {
+	if len(aids) == 0 {
+		return nil
+	}
+
+	query, args, err := as.qbuilder.Delete(as.tableName).Where(squirrel.Eq{
+		"id": aids,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = as.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// scanAttachment scans a row selected with attachmentColumns
+func scanAttachment(row pgx.Row) (*Attachment, error) {
+	a := new(Attachment)
+	err := row.Scan(
+		&a.ID,
+		&a.TID,
+		&a.UID,
+		&a.Filename,
+		&a.ContentType,
+		&a.Size,
+		&a.Checksum,
+		&a.Key,
+		&a.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return a, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*attachmentStore, error) {
+	return &attachmentStore{
+		pqdriver:  pqdriver,
+		qbuilder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName: "task_attachments",
+	}, nil
+}
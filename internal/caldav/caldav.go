@@ -0,0 +1,388 @@
+// Package caldav exposes tasks as the VTODO resources of a CalDAV calendar, so they can be synced
+// both ways with calendar clients
+package caldav
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/ical"
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// Extension is the extension of the name of every resource
+	Extension = ".ics"
+	// syncNote is the note of the status changes made by calendar clients
+	syncNote = "synced from a calendar client"
+)
+
+// ErrPreconditionFailed is returned when the If-Match or If-None-Match header of a request does not
+// match the current state of the resource
+var ErrPreconditionFailed = errors.New("the resource was changed by someone else")
+
+// Authorize returns a task if the user is allowed to access it as needed by the request, and an
+// error otherwise. Tasks which are not shared with the user should be reported as not found
+type Authorize func(ctx context.Context, tid int64) (*tasks.Task, error)
+
+// Resource is a task as a calendar resource
+type Resource struct {
+	// Name is the last segment of the resource's URL, chosen by the client for the tasks it created
+	Name string
+	// UID is the iCalendar UID of the task
+	UID  string
+	ETag string
+	Task tasks.Task
+}
+
+// Calendar returns the resource as a VCALENDAR with a single VTODO
+func (r *Resource) Calendar() *ical.Component {
+	cal := tasks.NewCalendar("", []tasks.Task{r.Task}, tasks.ComponentTodo)
+	todo := cal.Components[0]
+	todo.Get("UID").Value = ical.EscapeText(r.UID)
+	return cal
+}
+
+func newResource(t *tasks.Task, m *mapping) *Resource {
+	r := &Resource{
+		Name: strconv.FormatInt(t.TID, 10) + Extension,
+		UID:  t.CalendarUID(),
+		ETag: t.ETag(),
+		Task: *t,
+	}
+	if m != nil {
+		r.Name = m.Name
+		r.UID = m.UID
+	}
+	return r
+}
+
+// CTag changes whenever any of the resources changes, or when resources are added or removed
+func CTag(list []Resource) string {
+	b := strings.Builder{}
+	b.WriteString(strconv.Itoa(len(list)))
+	latest := time.Time{}
+	for _, r := range list {
+		if r.Task.UpdatedAt != nil && r.Task.UpdatedAt.After(latest) {
+			latest = *r.Task.UpdatedAt
+		}
+	}
+	b.WriteString("-")
+	b.WriteString(strconv.FormatInt(latest.UnixNano(), 36))
+	return b.String()
+}
+
+type CalDAV struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+	pqdriver   *pgxpool.Pool
+}
+
+// Resources returns all the resources of the user's calendar
+func (cs *CalDAV) Resources(ctx context.Context, uid int64) ([]Resource, error) {
+	list, err := cs.tasks.Todos(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	tids := make([]int64, 0, len(list))
+	for _, t := range list {
+		tids = append(tids, t.TID)
+	}
+
+	mappings, err := cs.store.GetMany(ctx, tids)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := make([]Resource, 0, len(list))
+	for idx := range list {
+		resources = append(resources, *newResource(&list[idx], mappings[list[idx].TID]))
+	}
+
+	return resources, nil
+}
+
+// Get returns a resource by its name, if authorize allows the user to see its task
+func (cs *CalDAV) Get(ctx context.Context, name string, authorize Authorize) (*Resource, error) {
+	tid, m, err := cs.resolve(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := authorize(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	return newResource(t, m), nil
+}
+
+// Put creates or updates a task from a VTODO. Existing tasks are only updated if authorize allows
+// the user to change them. ifMatch and ifNoneMatch are the conditional request headers, a mismatch
+// returns ErrPreconditionFailed. It returns the resource and whether it was created
+func (cs *CalDAV) Put(ctx context.Context, uid int64, name string, body io.Reader, ifMatch string, ifNoneMatch string, authorize Authorize) (*Resource, bool, error) {
+	if !strings.HasSuffix(name, Extension) || strings.Contains(name, "/") {
+		return nil, false, errors.Validationf("resource names should end with %s", Extension)
+	}
+
+	obj, err := ical.Decode(body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	todo := firstTodo(obj)
+	if todo == nil {
+		return nil, false, errors.Validation("only VTODO resources are supported")
+	}
+
+	existing, err := cs.Get(ctx, name, authorize)
+	if err != nil && !errors.HasType(err, errors.TypeNotFound) {
+		return nil, false, err
+	}
+
+	switch {
+	case existing == nil && ifMatch != "":
+		return nil, false, ErrPreconditionFailed
+	case existing != nil && ifNoneMatch == "*":
+		return nil, false, ErrPreconditionFailed
+	case existing != nil && ifMatch != "" && ifMatch != "*" && ifMatch != existing.ETag:
+		return nil, false, ErrPreconditionFailed
+	}
+
+	var tid int64
+	err = datastore.WithTx(ctx, cs.pqdriver, func(ctx context.Context) error {
+		if existing == nil {
+			tid, err = cs.create(ctx, uid, name, todo)
+		} else {
+			tid = existing.Task.TID
+			// the task is only updated if it is still the version the ETag was checked against
+			if ifMatch == "" || ifMatch == "*" {
+				existing.Task.Version = 0
+			}
+			err = cs.update(ctx, uid, &existing.Task, todo)
+		}
+		if err != nil {
+			return err
+		}
+
+		return cs.syncStatus(ctx, uid, tid, todo)
+	})
+	if err == tasks.ErrVersionMismatch {
+		return nil, false, ErrPreconditionFailed
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	r, err := cs.Get(ctx, name, authorize)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return r, existing == nil, nil
+}
+
+// Delete moves the task of a resource to the trash if authorize allows the user to delete it, its
+// subtasks are kept
+func (cs *CalDAV) Delete(ctx context.Context, uid int64, name string, ifMatch string, authorize Authorize) error {
+	r, err := cs.Get(ctx, name, authorize)
+	if err != nil {
+		return err
+	}
+
+	version := int64(0)
+	if ifMatch != "" && ifMatch != "*" {
+		if ifMatch != r.ETag {
+			return ErrPreconditionFailed
+		}
+		version = r.Task.Version
+	}
+
+	_, err = cs.tasks.Delete(ctx, r.Task.TID, tasks.ChildrenReparent, version, uid)
+	if err == tasks.ErrVersionMismatch {
+		return ErrPreconditionFailed
+	}
+	return err
+}
+
+// resolve returns the ID of the task of a resource. Resources created by clients are mapped to
+// their task, the others are named after the ID of their task
+func (cs *CalDAV) resolve(ctx context.Context, name string) (int64, *mapping, error) {
+	m, err := cs.store.GetByName(ctx, name)
+	if err == nil {
+		return m.TID, m, nil
+	}
+	if !errors.HasType(err, errors.TypeNotFound) {
+		return 0, nil, err
+	}
+
+	tid, perr := strconv.ParseInt(strings.TrimSuffix(name, Extension), 10, 64)
+	if perr != nil || !strings.HasSuffix(name, Extension) {
+		return 0, nil, errors.NotFound("resource not found")
+	}
+
+	return tid, nil, nil
+}
+
+func (cs *CalDAV) create(ctx context.Context, uid int64, name string, todo *ical.Component) (int64, error) {
+	// names of tasks which were not created by clients are the task IDs
+	_, err := strconv.ParseInt(strings.TrimSuffix(name, Extension), 10, 64)
+	if err == nil {
+		return 0, errors.NotFound("resource not found")
+	}
+
+	t := &tasks.Task{UID: uid}
+	err = fromTodo(t, todo)
+	if err != nil {
+		return 0, err
+	}
+
+	t, err = cs.tasks.Create(ctx, t)
+	if err != nil {
+		return 0, err
+	}
+
+	icalUID := t.CalendarUID()
+	if p := todo.Get("UID"); p != nil && p.Text() != "" {
+		icalUID = p.Text()
+	}
+
+	err = cs.store.Create(ctx, &mapping{
+		TID:  t.TID,
+		Name: name,
+		UID:  icalUID,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return t.TID, nil
+}
+
+func (cs *CalDAV) update(ctx context.Context, uid int64, existing *tasks.Task, todo *ical.Component) error {
+	t := *existing
+	err := fromTodo(&t, todo)
+	if err != nil {
+		return err
+	}
+
+	_, err = cs.tasks.Edit(ctx, t.TID, &t, uid)
+	return err
+}
+
+// syncStatus transitions the task to the status matching the one of the VTODO, within the
+// workflow of the task
+func (cs *CalDAV) syncStatus(ctx context.Context, uid int64, tid int64, todo *ical.Component) error {
+	t, err := cs.tasks.Get(ctx, tid)
+	if err != nil {
+		return err
+	}
+
+	w, err := cs.tasks.Workflow(ctx, t.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	status := ""
+	if p := todo.Get("STATUS"); p != nil {
+		status = strings.ToUpper(strings.TrimSpace(p.Value))
+	}
+	if status == "" && todo.Get("COMPLETED") != nil {
+		status = "COMPLETED"
+	}
+
+	to := ""
+	switch status {
+	case "COMPLETED":
+		if t.CompletedAt == nil && len(w.Completed) > 0 {
+			to = w.Completed[0]
+		}
+	case "CANCELLED":
+		to = tasks.StatusCancelled
+	case "IN-PROCESS":
+		to = tasks.StatusInProgress
+	default:
+		if t.CompletedAt != nil || t.Status == tasks.StatusCancelled {
+			to = w.Initial
+		}
+	}
+
+	if to == "" || to == t.Status || !w.HasStatus(to) {
+		return nil
+	}
+
+	_, err = cs.tasks.Transition(ctx, tid, to, uid, syncNote)
+	return err
+}
+
+// fromTodo sets the fields of the task which can be changed by calendar clients
+func fromTodo(t *tasks.Task, todo *ical.Component) error {
+	t.Detail = ""
+	if p := todo.Get("SUMMARY"); p != nil {
+		t.Detail = strings.TrimSpace(p.Text())
+	}
+
+	t.Priority = ""
+	if p := todo.Get("PRIORITY"); p != nil {
+		priority, _ := strconv.Atoi(strings.TrimSpace(p.Value))
+		t.Priority = tasks.PriorityOfCalendar(priority)
+	}
+
+	t.CompleteBy = time.Time{}
+	t.DueDate = ""
+	if p := todo.Get("DUE"); p != nil {
+		due, err := p.Time(time.UTC)
+		if err != nil {
+			return err
+		}
+		t.CompleteBy = due
+
+		// dates are all-day to-dos, times keep the timezone they were set in
+		if p.IsDate() {
+			t.DueDate = due.Format(tasks.DateLayout)
+		} else if tzid := strings.TrimPrefix(p.Params["TZID"], "/"); tzid != "" {
+			if _, err := time.LoadLocation(tzid); err == nil {
+				t.DueTimezone = tzid
+			}
+		}
+	}
+
+	return nil
+}
+
+func firstTodo(obj *ical.Component) *ical.Component {
+	if obj.Name == tasks.ComponentTodo {
+		return obj
+	}
+
+	for _, c := range obj.Components {
+		if c.Name == tasks.ComponentTodo {
+			return c
+		}
+	}
+	return nil
+}
+
+func NewService(l logger.Logger, pqdriver *pgxpool.Pool, ts *tasks.Tasks) (*CalDAV, error) {
+	cstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CalDAV{
+		logHandler: l,
+		store:      cstore,
+		tasks:      ts,
+		pqdriver:   pqdriver,
+	}, nil
+}
@@ -0,0 +1,138 @@
+package caldav
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// mapping keeps the resource name and iCalendar UID chosen by the client which created a task
+type mapping struct {
+	TID       int64
+	Name      string
+	UID       string
+	CreatedAt time.Time
+}
+
+type store interface {
+	Create(ctx context.Context, m *mapping) error
+	GetByName(ctx context.Context, name string) (*mapping, error)
+	GetMany(ctx context.Context, tids []int64) (map[int64]*mapping, error)
+}
+
+type caldavStore struct {
+	qbuilder  squirrel.StatementBuilderType
+	pqdriver  *pgxpool.Pool
+	tableName string
+}
+
+var mappingColumns = []string{
+	"tid",
+	"name",
+	"icalUid",
+	"createdAt",
+}
+
+func (cs *caldavStore) Create(ctx context.Context, m *mapping) error {
+	query, args, err := cs.qbuilder.Insert(cs.tableName).SetMap(map[string]interface{}{
+		"tid":     m.TID,
+		"name":    m.Name,
+		"icalUid": m.UID,
+	}).Suffix("ON CONFLICT (name) DO NOTHING").ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := datastore.Conn(ctx, cs.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Duplicatef("resource '%s' already exists", m.Name)
+	}
+
+	return nil
+}
+
+func (cs *caldavStore) GetByName(ctx context.Context, name string) (*mapping, error) {
+	query, args, err := cs.qbuilder.Select(mappingColumns...).From(cs.tableName).Where(
+		squirrel.Eq{"name": name},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	m, err := scanMapping(datastore.Conn(ctx, cs.pqdriver).QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("resource not found")
+		}
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	return m, nil
+}
+
+func (cs *caldavStore) GetMany(ctx context.Context, tids []int64) (map[int64]*mapping, error) {
+	mappings := make(map[int64]*mapping, len(tids))
+	if len(tids) == 0 {
+		return mappings, nil
+	}
+
+	query, args, err := cs.qbuilder.Select(mappingColumns...).From(cs.tableName).Where(
+		squirrel.Eq{"tid": tids},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, cs.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		m, err := scanMapping(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		mappings[m.TID] = m
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return mappings, nil
+}
+
+func scanMapping(row pgx.Row) (*mapping, error) {
+	m := new(mapping)
+	err := row.Scan(
+		&m.TID,
+		&m.Name,
+		&m.UID,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*caldavStore, error) {
+	return &caldavStore{
+		pqdriver:  pqdriver,
+		qbuilder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName: "caldav_resources",
+	}, nil
+}
@@ -0,0 +1,278 @@
+package comments
+
+import (
+	"context"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+// MaxLength is the maximum number of characters in a comment
+const MaxLength = 10000
+
+// mentionPattern matches an email address prefixed with '@', e.g. @jane@example.com
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([\w.%+\-]+@[\w\-]+(?:\.[\w\-]+)*\.[A-Za-z]{2,})`)
+
+type Comment struct {
+	ID   int64  `json:"id,omitempty"`
+	TID  int64  `json:"tid,omitempty"`
+	UID  int64  `json:"uid,omitempty"`
+	Body string `json:"body,omitempty"`
+	// Mentions are the email addresses mentioned in the body
+	Mentions  []string   `json:"mentions"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (c *Comment) Sanitize() {
+	c.Body = strings.TrimSpace(c.Body)
+	c.Mentions = mentions(c.Body)
+}
+
+func (c *Comment) Validate() error {
+	if c.Body == "" {
+		return errors.Validation("comment cannot be empty")
+	}
+
+	if len([]rune(c.Body)) > MaxLength {
+		return errors.Validationf("comment cannot be longer than %d characters", MaxLength)
+	}
+
+	return nil
+}
+
+// mentions returns the unique email addresses mentioned in the body, in lowercase
+func mentions(body string) []string {
+	list := []string{}
+	seen := map[string]bool{}
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		email := strings.ToLower(match[1])
+		if !seen[email] {
+			seen[email] = true
+			list = append(list, email)
+		}
+	}
+	return list
+}
+
+type Comments struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+	users      *users.Users
+	mailer     *emailService.Mailer
+}
+
+func (cs *Comments) Create(ctx context.Context, c *Comment) (*Comment, error) {
+	c.Sanitize()
+	err := c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := cs.tasks.Get(ctx, c.TID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.CreatedAt = &now
+	c.UpdatedAt = &now
+
+	c.ID, err = cs.store.Create(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	cs.notify(ctx, task, c, c.Mentions)
+
+	return c, nil
+}
+
+// Import adds comments copied from another tool, keeping their dates. Empty comments are skipped
+// and long ones are truncated to MaxLength, mentions are not notified
+func (cs *Comments) Import(ctx context.Context, list []Comment) (int, error) {
+	valid := make([]Comment, 0, len(list))
+	for _, c := range list {
+		if body := []rune(c.Body); len(body) > MaxLength {
+			c.Body = string(body[:MaxLength])
+		}
+		c.Sanitize()
+		if c.Body == "" {
+			continue
+		}
+
+		if c.CreatedAt == nil {
+			now := time.Now()
+			c.CreatedAt = &now
+		}
+		c.UpdatedAt = c.CreatedAt
+		valid = append(valid, c)
+	}
+
+	if len(valid) == 0 {
+		return 0, nil
+	}
+
+	err := cs.store.CreateMany(ctx, valid)
+	if err != nil {
+		return 0, err
+	}
+
+	return len(valid), nil
+}
+
+// Edit updates the body of a comment, only its author can edit it. Addresses which were not
+// mentioned before the edit are notified
+func (cs *Comments) Edit(ctx context.Context, tid int64, cid int64, uid int64, body string) (*Comment, error) {
+	c, err := cs.authored(ctx, tid, cid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := c.Mentions
+	c.Body = body
+	c.Sanitize()
+	err = c.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	c.UpdatedAt = &now
+	err = cs.store.Edit(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+
+	added := []string{}
+	for _, email := range c.Mentions {
+		if !containsString(previous, email) {
+			added = append(added, email)
+		}
+	}
+
+	task, err := cs.tasks.Get(ctx, tid)
+	if err != nil {
+		cs.logHandler.Error(err)
+		return c, nil
+	}
+	cs.notify(ctx, task, c, added)
+
+	return c, nil
+}
+
+// Delete deletes a comment, only its author can delete it
+func (cs *Comments) Delete(ctx context.Context, tid int64, cid int64, uid int64) error {
+	_, err := cs.authored(ctx, tid, cid, uid)
+	if err != nil {
+		return err
+	}
+
+	return cs.store.Delete(ctx, cid)
+}
+
+// GetAll returns the comments of a task, oldest first
+func (cs *Comments) GetAll(ctx context.Context, tid int64) ([]Comment, error) {
+	_, err := cs.tasks.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	return cs.store.GetAll(ctx, tid)
+}
+
+// authored returns the comment if it belongs to the task and was written by uid
+func (cs *Comments) authored(ctx context.Context, tid int64, cid int64, uid int64) (*Comment, error) {
+	c, err := cs.store.Get(ctx, cid)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.TID != tid {
+		return nil, errors.NotFound("comment not found")
+	}
+
+	if c.UID != uid {
+		return nil, errors.Unauthorized("only the author of a comment can change it")
+	}
+
+	return c, nil
+}
+
+// notify emails the mentioned addresses which belong to a registered user or to the assignee of
+// the task, except the author of the comment. Failures are only logged, the comment is saved anyway
+func (cs *Comments) notify(ctx context.Context, task *tasks.Task, c *Comment, emails []string) {
+	if len(emails) == 0 {
+		return
+	}
+
+	author, err := cs.users.GetUserByID(ctx, c.UID)
+	if err != nil {
+		cs.logHandler.Error(err)
+		return
+	}
+
+	for _, email := range emails {
+		if strings.EqualFold(email, author.Email) {
+			continue
+		}
+
+		if !strings.EqualFold(email, task.AssignedTo) {
+			_, err = cs.users.GetUserByEmail(ctx, email)
+			if err != nil {
+				continue
+			}
+		}
+
+		mail := new(emailService.Email)
+		mail.Subject = author.Name + " mentioned you on a task"
+		mail.To = email
+		mail.HtmlContent = `<p>Hello</p>
+		<p>` + html.EscapeString(author.Name) + ` mentioned you in a comment on the task "` + html.EscapeString(task.Detail) + `".</p>
+		<blockquote>` + html.EscapeString(c.Body) + `</blockquote>`
+		err = cs.mailer.SendEmail(ctx, *mail)
+		if err != nil {
+			cs.logHandler.Error(err)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+func NewService(
+	l logger.Logger,
+	pqdriver *pgxpool.Pool,
+	ts *tasks.Tasks,
+	us *users.Users,
+	es *emailService.Mailer,
+) (*Comments, error) {
+	cstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Comments{
+		logHandler: l,
+		store:      cstore,
+		tasks:      ts,
+		users:      us,
+		mailer:     es,
+	}, nil
+}
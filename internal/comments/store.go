@@ -0,0 +1,216 @@
+package comments
+
+import (
+	"context"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Create(ctx context.Context, c *Comment) (int64, error)
+	Edit(ctx context.Context, c *Comment) error
+	Delete(ctx context.Context, cid int64) error
+	Get(ctx context.Context, cid int64) (*Comment, error)
+	GetAll(ctx context.Context, tid int64) ([]Comment, error)
+	CreateMany(ctx context.Context, list []Comment) error
+}
+
+type commentStore struct {
+	qbuilder  squirrel.StatementBuilderType
+	pqdriver  *pgxpool.Pool
+	tableName string
+}
+
+var commentColumns = []string{
+	"id",
+	"tid",
+	"uid",
+	"body",
+	"mentions",
+	"createdAt",
+	"updatedAt",
+}
+
+func (cs *commentStore) Create(ctx context.Context, c *Comment) (int64, error) {
+	query, args, err := cs.qbuilder.Insert(cs.tableName).SetMap(map[string]interface{}{
+		"tid":       c.TID,
+		"uid":       c.UID,
+		"body":      c.Body,
+		"mentions":  c.Mentions,
+		"createdAt": c.CreatedAt,
+		"updatedAt": c.UpdatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	var id int64
+	err = cs.pqdriver.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return id, nil
+}
+
+// CreateMany writes comments using COPY
+func (cs *commentStore) CreateMany(ctx context.Context, list []Comment) error {
+	_, err := datastore.Conn(ctx, cs.pqdriver).CopyFrom(
+		ctx,
+		pgx.Identifier{cs.tableName},
+		[]string{"tid", "uid", "body", "mentions", "createdat", "updatedat"},
+		pgx.CopyFromSlice(len(list), func(idx int) ([]interface{}, error) {
+			c := &list[idx]
+			return []interface{}{c.TID, c.UID, c.Body, c.Mentions, c.CreatedAt, c.UpdatedAt}, nil
+		}),
+	)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (cs *commentStore) Edit(ctx context.Context, c *Comment) error {
+	query, args, err := cs.qbuilder.Update(cs.tableName).SetMap(map[string]interface{}{
+		"body":      c.Body,
+		"mentions":  c.Mentions,
+		"updatedAt": c.UpdatedAt,
+	}).Where(squirrel.Eq{
+		"id": c.ID,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := cs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("comment not found")
+	}
+
+	return nil
+}
+
+func (cs *commentStore) Delete(ctx context.Context, cid int64) error {
+	query, args, err := cs.qbuilder.Delete(cs.tableName).Where(squirrel.Eq{
+		"id": cid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := cs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("comment not found")
+	}
+
+	return nil
+}
+
+func (cs *commentStore) Get(ctx context.Context, cid int64) (*Comment, error) {
+	query, args, err := cs.qbuilder.Select(
+		commentColumns...,
+	).From(
+		cs.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": cid,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	c, err := scanComment(cs.pqdriver.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("comment not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return c, nil
+}
+
+func (cs *commentStore) GetAll(ctx context.Context, tid int64) ([]Comment, error) {
+	query, args, err := cs.qbuilder.Select(
+		commentColumns...,
+	).From(
+		cs.tableName,
+	).Where(
+		squirrel.Eq{
+			"tid": tid,
+		},
+	).OrderBy(
+		"createdAt",
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := cs.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Comment{}
+	for rows.Next() {
+		c, err := scanComment(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *c)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+// scanComment scans a row selected with commentColumns
+func scanComment(row pgx.Row) (*Comment, error) {
+	c := new(Comment)
+	err := row.Scan(
+		&c.ID,
+		&c.TID,
+		&c.UID,
+		&c.Body,
+		&c.Mentions,
+		&c.CreatedAt,
+		&c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.Mentions == nil {
+		c.Mentions = []string{}
+	}
+
+	return c, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*commentStore, error) {
+	return &commentStore{
+		pqdriver:  pqdriver,
+		qbuilder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName: "task_comments",
+	}, nil
+}
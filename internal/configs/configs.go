@@ -2,11 +2,15 @@ package configs
 
 import (
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
+	"task-scheduler/internal/attachments"
 	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/reminders"
 	"task-scheduler/internal/server/http"
+	"task-scheduler/internal/tasks"
 )
 
 type Configs struct {
@@ -42,6 +46,83 @@ func (cfg *Configs) Datastore() (*datastore.Config, error) {
 	}, nil
 }
 
+func (cfg *Configs) Recurrence() (*tasks.RecurrenceConfig, error) {
+	return &tasks.RecurrenceConfig{
+		Interval: durationEnv("RECURRENCE_INTERVAL", time.Minute),
+	}, nil
+}
+
+func (cfg *Configs) Reminders() (*reminders.Config, error) {
+	offsets, err := reminders.ParseOffsets(os.Getenv("REMINDER_OFFSETS"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(offsets) == 0 {
+		offsets = []time.Duration{time.Hour * 24, time.Hour}
+	}
+
+	return &reminders.Config{
+		Interval: durationEnv("REMINDER_INTERVAL", time.Minute),
+		Offsets:  offsets,
+	}, nil
+}
+
+func (cfg *Configs) Trash() (*tasks.TrashConfig, error) {
+	return &tasks.TrashConfig{
+		Interval:  durationEnv("TRASH_PURGE_INTERVAL", time.Hour),
+		Retention: durationEnv("TRASH_RETENTION", time.Hour*24*30),
+	}, nil
+}
+
+func (cfg *Configs) Attachments() (*attachments.Config, error) {
+	dir := strings.TrimSpace(os.Getenv("ATTACHMENTS_DIR"))
+	if dir == "" {
+		dir = "./data/attachments"
+	}
+
+	maxSize, err := strconv.ParseInt(strings.TrimSpace(os.Getenv("ATTACHMENTS_MAX_SIZE")), 10, 64)
+	if err != nil || maxSize <= 0 {
+		maxSize = 10 << 20
+	}
+
+	allowed := []string{}
+	for _, t := range strings.Split(os.Getenv("ATTACHMENTS_ALLOWED_TYPES"), ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			allowed = append(allowed, t)
+		}
+	}
+	if len(allowed) == 0 {
+		allowed = []string{
+			"image/png",
+			"image/jpeg",
+			"image/gif",
+			"image/webp",
+			"application/pdf",
+			"application/zip",
+			"text/plain",
+			"text/markdown",
+			"text/csv",
+		}
+	}
+
+	return &attachments.Config{
+		Dir:          dir,
+		MaxSize:      maxSize,
+		AllowedTypes: allowed,
+	}, nil
+}
+
+// durationEnv reads a duration (e.g. "30s", "5m") from the environment variable key,
+// falling back to def if it is empty or invalid
+func durationEnv(key string, def time.Duration) time.Duration {
+	d, err := time.ParseDuration(strings.TrimSpace(os.Getenv(key)))
+	if err != nil || d <= 0 {
+		return def
+	}
+	return d
+}
+
 func NewService() (*Configs, error) {
 	return &Configs{}, nil
 }
@@ -0,0 +1,186 @@
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// MaxSize is the maximum size of an object which can be decoded
+const MaxSize = 1 << 20
+
+var textUnescaper = strings.NewReplacer(
+	`\\`, `\`,
+	`\;`, ";",
+	`\,`, ",",
+	`\n`, "\n",
+	`\N`, "\n",
+)
+
+// UnescapeText unescapes a TEXT value
+func UnescapeText(text string) string {
+	return textUnescaper.Replace(text)
+}
+
+// Decode parses an object, e.g. a VCALENDAR, and returns its outermost component
+func Decode(r io.Reader) (*Component, error) {
+	lines, err := unfold(io.LimitReader(r, MaxSize))
+	if err != nil {
+		return nil, err
+	}
+
+	var root *Component
+	stack := []*Component{}
+	for _, line := range lines {
+		p, err := parseLine(line)
+		if err != nil {
+			return nil, err
+		}
+
+		switch strings.ToUpper(p.Name) {
+		case "BEGIN":
+			c := NewComponent(strings.ToUpper(p.Value))
+			if len(stack) > 0 {
+				stack[len(stack)-1].AddComponent(c)
+			} else if root == nil {
+				root = c
+			} else {
+				return nil, errors.Validation("only one object can be provided")
+			}
+			stack = append(stack, c)
+		case "END":
+			if len(stack) == 0 || stack[len(stack)-1].Name != strings.ToUpper(p.Value) {
+				return nil, errors.Validationf("unexpected END:%s", p.Value)
+			}
+			stack = stack[:len(stack)-1]
+		default:
+			if len(stack) == 0 {
+				return nil, errors.Validation("property outside of a component")
+			}
+			c := stack[len(stack)-1]
+			c.Properties = append(c.Properties, *p)
+		}
+	}
+
+	if root == nil || len(stack) > 0 {
+		return nil, errors.Validation("incomplete iCalendar object")
+	}
+
+	return root, nil
+}
+
+// unfold returns the content lines, joining the folded ones
+func unfold(r io.Reader) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), MaxSize)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+
+		if (line[0] == ' ' || line[0] == '\t') && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.ValidationErr(err, "invalid iCalendar object")
+	}
+
+	return lines, nil
+}
+
+// parseLine parses a content line, name *(";" param) ":" value. Parameter values can be quoted
+func parseLine(line string) (*Property, error) {
+	p := &Property{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(line); i++ {
+		switch {
+		case line[i] == '"':
+			quoted = !quoted
+		case quoted:
+		case line[i] == ';' || line[i] == ':':
+			segment := line[start:i]
+			if start == 0 {
+				p.Name = strings.ToUpper(strings.TrimSpace(segment))
+			} else {
+				p.addParam(segment)
+			}
+			start = i + 1
+
+			if line[i] == ':' {
+				if p.Name == "" {
+					return nil, errors.Validationf("invalid content line '%s'", line)
+				}
+				p.Value = line[i+1:]
+				return p, nil
+			}
+		}
+	}
+
+	return nil, errors.Validationf("invalid content line '%s'", line)
+}
+
+func (p *Property) addParam(param string) {
+	idx := strings.Index(param, "=")
+	if idx < 0 {
+		return
+	}
+
+	if p.Params == nil {
+		p.Params = map[string]string{}
+	}
+	p.Params[strings.ToUpper(param[:idx])] = strings.Trim(param[idx+1:], `"`)
+}
+
+// Text returns the unescaped value of a TEXT property
+func (p *Property) Text() string {
+	return UnescapeText(p.Value)
+}
+
+// IsDate returns true if the value is a DATE rather than a DATE-TIME
+func (p *Property) IsDate() bool {
+	return p.Params["VALUE"] == "DATE" || len(strings.TrimSpace(p.Value)) == len(dateFormat)
+}
+
+// Time parses a DATE or DATE-TIME value. Times in UTC end with 'Z', others are in the timezone of
+// their TZID parameter, or in loc if they have none (floating times). Dates are at midnight in loc
+func (p *Property) Time(loc *time.Location) (time.Time, error) {
+	value := strings.TrimSpace(p.Value)
+	if p.IsDate() {
+		t, err := time.ParseInLocation(dateFormat, value, loc)
+		if err != nil {
+			return time.Time{}, errors.ValidationErrf(err, "invalid date in %s", p.Name)
+		}
+		return t, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(timeFormat, value)
+		if err != nil {
+			return time.Time{}, errors.ValidationErrf(err, "invalid date-time in %s", p.Name)
+		}
+		return t, nil
+	}
+
+	if tzid := p.Params["TZID"]; tzid != "" {
+		l, err := time.LoadLocation(strings.TrimPrefix(tzid, "/"))
+		if err == nil {
+			loc = l
+		}
+	}
+
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	if err != nil {
+		return time.Time{}, errors.ValidationErrf(err, "invalid date-time in %s", p.Name)
+	}
+	return t, nil
+}
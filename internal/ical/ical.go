@@ -0,0 +1,172 @@
+// Package ical encodes and decodes iCalendar (RFC 5545) objects
+package ical
+
+import (
+	"bufio"
+	"io"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// maxLineLength is the maximum length of a content line in octets, excluding the line break
+	maxLineLength = 75
+
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+)
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+// Property is a content line of a component, e.g. DUE;VALUE=DATE:20210601
+type Property struct {
+	Name   string
+	Params map[string]string
+	// Value is the raw value, already escaped
+	Value string
+}
+
+// Component is a calendar object such as VCALENDAR, VTODO or VEVENT
+type Component struct {
+	Name       string
+	Properties []Property
+	Components []*Component
+}
+
+// NewComponent returns an empty component
+func NewComponent(name string) *Component {
+	return &Component{Name: name}
+}
+
+// Add adds a property with a raw value
+func (c *Component) Add(name string, value string) *Component {
+	c.Properties = append(c.Properties, Property{Name: name, Value: value})
+	return c
+}
+
+// AddText adds a property with a text value, which is escaped
+func (c *Component) AddText(name string, text string) *Component {
+	return c.Add(name, EscapeText(text))
+}
+
+// AddTime adds a property with a date-time value in UTC
+func (c *Component) AddTime(name string, t time.Time) *Component {
+	return c.Add(name, FormatTime(t))
+}
+
+// AddDate adds a property with a date value, in the timezone of t
+func (c *Component) AddDate(name string, t time.Time) *Component {
+	c.Properties = append(c.Properties, Property{
+		Name:   name,
+		Params: map[string]string{"VALUE": "DATE"},
+		Value:  t.Format(dateFormat),
+	})
+	return c
+}
+
+// AddComponent adds a sub component
+func (c *Component) AddComponent(sub *Component) *Component {
+	c.Components = append(c.Components, sub)
+	return c
+}
+
+// Get returns the first property with the name, or nil if there is none
+func (c *Component) Get(name string) *Property {
+	for idx := range c.Properties {
+		if strings.EqualFold(c.Properties[idx].Name, name) {
+			return &c.Properties[idx]
+		}
+	}
+	return nil
+}
+
+// EscapeText escapes a TEXT value
+func EscapeText(text string) string {
+	return textEscaper.Replace(text)
+}
+
+// FormatTime formats a DATE-TIME value in UTC
+func FormatTime(t time.Time) string {
+	return t.UTC().Format(timeFormat)
+}
+
+// Encoder writes components as content lines, folded at 75 octets and ending with CRLF
+type Encoder struct {
+	w *bufio.Writer
+}
+
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: bufio.NewWriter(w)}
+}
+
+// Encode writes the component along with its sub components
+func (e *Encoder) Encode(c *Component) error {
+	e.encode(c)
+	return e.w.Flush()
+}
+
+// encode does not check the errors of the buffered writer, they are returned by Flush
+func (e *Encoder) encode(c *Component) {
+	e.line("BEGIN:" + c.Name)
+	for _, p := range c.Properties {
+		e.line(p.String())
+	}
+	for _, sub := range c.Components {
+		e.encode(sub)
+	}
+	e.line("END:" + c.Name)
+}
+
+// line writes a content line, folding it without splitting multi-byte characters
+func (e *Encoder) line(s string) {
+	limit := maxLineLength
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(s[cut]) {
+			cut--
+		}
+		e.w.WriteString(s[:cut])
+		e.w.WriteString("\r\n ")
+		s = s[cut:]
+		// the leading space of a continuation line counts towards its length
+		limit = maxLineLength - 1
+	}
+	e.w.WriteString(s)
+	e.w.WriteString("\r\n")
+}
+
+// String returns the property as an unfolded content line
+func (p *Property) String() string {
+	b := strings.Builder{}
+	b.WriteString(p.Name)
+
+	names := make([]string, 0, len(p.Params))
+	for name := range p.Params {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		b.WriteString(";")
+		b.WriteString(name)
+		b.WriteString("=")
+		value := p.Params[name]
+		if strings.ContainsAny(value, ";:,") {
+			value = `"` + strings.ReplaceAll(value, `"`, "") + `"`
+		}
+		b.WriteString(value)
+	}
+
+	b.WriteString(":")
+	b.WriteString(p.Value)
+	return b.String()
+}
@@ -0,0 +1,118 @@
+// Package importers reads the exports of other task managers, like Trello boards, Jira issues and
+// todo.txt files, as tasks which can be imported
+package importers
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	SourceTrello  = "trello"
+	SourceJira    = "jira"
+	SourceTodoTxt = "todotxt"
+)
+
+// MaxFileSize is the maximum size of an exported file, in bytes
+const MaxFileSize = 32 << 20
+
+// Comment is a comment on an item of an exported file. Its author is a name of the source, which
+// may not be a user here
+type Comment struct {
+	Author    string     `json:"author,omitempty"`
+	Body      string     `json:"body"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// Record is an item of an exported file as a task. Row is the position of the item in the file,
+// starting at 1, and Ref is its identifier in the source, like a card ID or an issue key. Err is
+// set if the item cannot be read as a task
+type Record struct {
+	Row      int
+	Ref      string
+	Task     *tasks.Task
+	Comments []Comment
+	Err      error
+}
+
+// Importer reads the items of an exported file. Items which cannot be read are returned with their
+// error, the returned error is for files which cannot be read at all
+type Importer interface {
+	Parse(r io.Reader) ([]Record, error)
+}
+
+var importers = map[string]Importer{
+	SourceTrello:  trello{},
+	SourceJira:    jira{},
+	SourceTodoTxt: todoTxt{},
+}
+
+// Get returns the importer of the source
+func Get(source string) (Importer, error) {
+	im, ok := importers[strings.ToLower(strings.TrimSpace(source))]
+	if !ok {
+		return nil, errors.Validationf("unsupported source '%s', it should be one of %s, %s, %s", source, SourceTrello, SourceJira, SourceTodoTxt)
+	}
+	return im, nil
+}
+
+// Parse reads the file of the source, it is limited to MaxFileSize bytes and tasks.MaxImportRows items
+func Parse(source string, r io.Reader) ([]Record, error) {
+	im, err := Get(source)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := im.Parse(io.LimitReader(r, MaxFileSize))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(records) > tasks.MaxImportRows {
+		return nil, errors.Validationf("an import cannot have more than %d tasks", tasks.MaxImportRows)
+	}
+
+	return records, nil
+}
+
+// label returns a name of a label which can be used as a tag
+func label(name string) string {
+	name = strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(name), ",", " ")), " ")
+	if runes := []rune(name); len(runes) > tasks.MaxTagLength {
+		name = strings.TrimSpace(string(runes[:tasks.MaxTagLength]))
+	}
+	return name
+}
+
+// isEmail is a loose check of an email address, used to skip names where addresses are expected
+func isEmail(s string) bool {
+	at := strings.Index(s, "@")
+	return at > 0 && at < len(s)-1 && !strings.ContainsAny(s, " \t")
+}
+
+// detail joins the title and the description of an item
+func detail(title string, description string) string {
+	title, description = strings.TrimSpace(title), strings.TrimSpace(description)
+	if description == "" {
+		return title
+	}
+	return title + "\n\n" + description
+}
+
+// Report is the outcome of an import of an exported file
+type Report struct {
+	Source   string
+	DryRun   bool
+	Total    int
+	Valid    int
+	Imported int
+	// Comments is the number of comments imported along with the tasks
+	Comments int
+	// Failed are the records which cannot be imported
+	Failed []Record
+}
@@ -0,0 +1,232 @@
+package importers
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// jiraTimeLayouts are the layouts of dates in Jira CSV exports, which depend on the settings of
+// the instance
+var jiraTimeLayouts = []string{
+	"02/Jan/06 3:04 PM",
+	"02/Jan/06",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	time.RFC3339,
+}
+
+// jiraPriorities are the default priorities of Jira, custom ones are not kept
+var jiraPriorities = map[string]string{
+	"highest": tasks.PriorityUrgent,
+	"high":    tasks.PriorityHigh,
+	"medium":  tasks.PriorityMedium,
+	"low":     tasks.PriorityLow,
+	"lowest":  tasks.PriorityLow,
+}
+
+// jira reads the CSV export of Jira issues. Subtasks are kept under their parents if the parents
+// are exported too. Assignees are only kept if the export has their email addresses
+type jira struct{}
+
+func (jira) Parse(r io.Reader) ([]Record, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.Validation("the file is empty")
+	}
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid CSV provided")
+	}
+
+	// labels and comments are in repeated columns, one per value
+	columns := map[string][]int{}
+	for idx, name := range header {
+		if idx == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+		name = strings.ToLower(strings.TrimSpace(name))
+		columns[name] = append(columns[name], idx)
+	}
+	if len(columns["summary"]) == 0 {
+		return nil, errors.Validation("the file has no Summary column, it is not a Jira export")
+	}
+
+	records := []Record{}
+	issueIDs := map[int64]bool{}
+	for row := 1; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			perr, ok := err.(*csv.ParseError)
+			if !ok || perr.Err != csv.ErrFieldCount {
+				return nil, errors.InputBodyErr(err, "Invalid CSV provided")
+			}
+			records = append(records, Record{
+				Row: row,
+				Err: errors.Validationf("expected %d fields, found %d", len(header), len(fields)),
+			})
+			continue
+		}
+
+		values := func(column string) []string {
+			list := []string{}
+			for _, idx := range columns[column] {
+				if v := strings.TrimSpace(fields[idx]); v != "" {
+					list = append(list, v)
+				}
+			}
+			return list
+		}
+		value := func(column string) string {
+			if list := values(column); len(list) > 0 {
+				return list[0]
+			}
+			return ""
+		}
+
+		record, err := jiraRecord(value, values)
+		if err != nil {
+			records = append(records, Record{Row: row, Ref: value("issue key"), Err: err})
+			continue
+		}
+		record.Row = row
+		if record.Task.TID != 0 {
+			issueIDs[record.Task.TID] = true
+		}
+		records = append(records, *record)
+	}
+
+	// parents which are not part of the export are dropped, so their subtasks can still be imported
+	for _, record := range records {
+		if record.Task != nil && !issueIDs[record.Task.ParentID] {
+			record.Task.ParentID = 0
+		}
+	}
+
+	return records, nil
+}
+
+// jiraRecord reads an issue, value returns the first value of a column and values all of them
+func jiraRecord(value func(string) string, values func(string) []string) (*Record, error) {
+	t := &tasks.Task{
+		Detail:   detail(value("summary"), value("description")),
+		Status:   jiraStatus(value("status category"), value("status"), value("resolved")),
+		Priority: jiraPriorities[strings.ToLower(value("priority"))],
+	}
+
+	var err error
+	if id := value("issue id"); id != "" {
+		t.TID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "invalid issue id '%s'", id)
+		}
+	}
+	if id := value("parent id"); id != "" {
+		t.ParentID, err = strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "invalid parent id '%s'", id)
+		}
+	}
+
+	if due := value("due date"); due != "" {
+		completeBy, err := jiraTime("due date", due)
+		if err != nil {
+			return nil, err
+		}
+		// due dates without a time are all-day
+		if completeBy.Equal(completeBy.Truncate(24 * time.Hour)) {
+			t.DueDate = completeBy.Format(tasks.DateLayout)
+		} else {
+			t.CompleteBy = *completeBy
+		}
+	}
+	if created := value("created"); created != "" {
+		t.CreatedAt, err = jiraTime("created", created)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resolved := value("resolved"); resolved != "" && t.Status == tasks.StatusDone {
+		t.CompletedAt, err = jiraTime("resolved", resolved)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if assignee := value("assignee"); isEmail(assignee) {
+		t.AssignedTo = assignee
+	}
+	for _, name := range values("labels") {
+		if name = label(name); name != "" {
+			t.Tags = append(t.Tags, name)
+		}
+	}
+
+	record := &Record{
+		Ref:  value("issue key"),
+		Task: t,
+	}
+	for _, c := range values("comment") {
+		record.Comments = append(record.Comments, jiraComment(c))
+	}
+
+	return record, nil
+}
+
+// jiraStatus maps the status of an issue to one of the default workflow. The status category is
+// used if it was exported, since the names of statuses are specific to each project
+func jiraStatus(category string, status string, resolved string) string {
+	for _, name := range []string{category, status} {
+		switch strings.ToLower(name) {
+		case "done", "closed", "resolved":
+			return tasks.StatusDone
+		case "in progress", "in review":
+			return tasks.StatusInProgress
+		case "to do", "open", "backlog", "reopened":
+			return tasks.StatusTodo
+		}
+	}
+
+	if resolved != "" {
+		return tasks.StatusDone
+	}
+	return ""
+}
+
+// jiraComment reads a comment, which is exported as "<date>;<author>;<body>"
+func jiraComment(value string) Comment {
+	parts := strings.SplitN(value, ";", 3)
+	if len(parts) < 3 {
+		return Comment{Body: value}
+	}
+
+	c := Comment{
+		Author: strings.TrimSpace(parts[1]),
+		Body:   parts[2],
+	}
+	c.CreatedAt, _ = jiraTime("comment", strings.TrimSpace(parts[0]))
+	if c.CreatedAt == nil {
+		// not a comment in the expected format, the semicolons are part of the body
+		return Comment{Body: value}
+	}
+	return c
+}
+
+func jiraTime(column string, value string) (*time.Time, error) {
+	for _, layout := range jiraTimeLayouts {
+		t, err := time.Parse(layout, value)
+		if err == nil {
+			return &t, nil
+		}
+	}
+	return nil, errors.Validationf("invalid %s '%s'", column, value)
+}
@@ -0,0 +1,108 @@
+package importers
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const todoTxtDate = "2006-01-02"
+
+var todoTxtPriority = regexp.MustCompile(`^\([A-Z]\)$`)
+
+var todoTxtPriorities = map[byte]string{
+	'A': tasks.PriorityUrgent,
+	'B': tasks.PriorityHigh,
+	'C': tasks.PriorityMedium,
+}
+
+// todoTxt reads a todo.txt file, a task per line. Projects (+project) and contexts (@context) are
+// kept as tags, and the due:YYYY-MM-DD extension as the date of an all-day task. Priorities (A)
+// to (C) are urgent, high and medium, the others are low
+type todoTxt struct{}
+
+func (todoTxt) Parse(r io.Reader) ([]Record, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	records := []Record{}
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row++
+		t, err := todoTxtTask(line)
+		records = append(records, Record{Row: row, Task: t, Err: err})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid todo.txt provided")
+	}
+
+	return records, nil
+}
+
+func todoTxtTask(line string) (*tasks.Task, error) {
+	t := new(tasks.Task)
+	words := strings.Fields(line)
+
+	if words[0] == "x" {
+		t.Status = tasks.StatusDone
+		words = words[1:]
+		if len(words) > 0 {
+			if completedAt, err := time.Parse(todoTxtDate, words[0]); err == nil {
+				t.CompletedAt = &completedAt
+				words = words[1:]
+			}
+		}
+	} else if todoTxtPriority.MatchString(words[0]) {
+		t.Priority = todoTxtPriorities[words[0][1]]
+		if t.Priority == "" {
+			t.Priority = tasks.PriorityLow
+		}
+		words = words[1:]
+	}
+
+	if len(words) > 0 {
+		if createdAt, err := time.Parse(todoTxtDate, words[0]); err == nil {
+			t.CreatedAt = &createdAt
+			words = words[1:]
+		}
+	}
+
+	text := make([]string, 0, len(words))
+	for _, word := range words {
+		switch {
+		case len(word) > 1 && (word[0] == '+' || word[0] == '@'):
+			if name := label(word[1:]); name != "" {
+				t.Tags = append(t.Tags, name)
+			}
+		case strings.HasPrefix(word, "due:"):
+			due, err := time.Parse(todoTxtDate, strings.TrimPrefix(word, "due:"))
+			if err != nil {
+				return nil, errors.ValidationErrf(err, "invalid due date '%s', expected YYYY-MM-DD", word)
+			}
+			t.DueDate = due.Format(tasks.DateLayout)
+		case strings.HasPrefix(word, "pri:") && t.Status == tasks.StatusDone:
+			// the priority of a completed task, which has no use once it is done
+		default:
+			text = append(text, word)
+		}
+	}
+
+	t.Detail = strings.Join(text, " ")
+	if t.Detail == "" {
+		return nil, errors.Validation("the task has no description")
+	}
+
+	return t, nil
+}
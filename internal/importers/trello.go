@@ -0,0 +1,133 @@
+package importers
+
+import (
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// trello reads the JSON export of a Trello board. Archived cards and cards of archived lists are
+// skipped, cards which are marked complete or are in a list named done are done
+type trello struct{}
+
+type trelloBoard struct {
+	Lists []struct {
+		ID     string `json:"id"`
+		Name   string `json:"name"`
+		Closed bool   `json:"closed"`
+	} `json:"lists"`
+	Cards []struct {
+		ID          string     `json:"id"`
+		Name        string     `json:"name"`
+		Desc        string     `json:"desc"`
+		Due         *time.Time `json:"due"`
+		DueComplete bool       `json:"dueComplete"`
+		Closed      bool       `json:"closed"`
+		IDList      string     `json:"idList"`
+		IDMembers   []string   `json:"idMembers"`
+		Labels      []struct {
+			Name  string `json:"name"`
+			Color string `json:"color"`
+		} `json:"labels"`
+	} `json:"cards"`
+	Members []struct {
+		ID       string `json:"id"`
+		FullName string `json:"fullName"`
+		Email    string `json:"email"`
+	} `json:"members"`
+	Actions []struct {
+		Type string    `json:"type"`
+		Date time.Time `json:"date"`
+		Data struct {
+			Text string `json:"text"`
+			Card struct {
+				ID string `json:"id"`
+			} `json:"card"`
+		} `json:"data"`
+		MemberCreator struct {
+			FullName string `json:"fullName"`
+		} `json:"memberCreator"`
+	} `json:"actions"`
+}
+
+func (trello) Parse(r io.Reader) ([]Record, error) {
+	board := new(trelloBoard)
+	err := json.NewDecoder(r).Decode(board)
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid Trello export provided")
+	}
+
+	closedLists := map[string]bool{}
+	doneLists := map[string]bool{}
+	for _, l := range board.Lists {
+		closedLists[l.ID] = l.Closed
+		doneLists[l.ID] = strings.EqualFold(strings.TrimSpace(l.Name), "done")
+	}
+
+	emails := map[string]string{}
+	for _, m := range board.Members {
+		if isEmail(m.Email) {
+			emails[m.ID] = m.Email
+		}
+	}
+
+	// actions are exported newest first
+	comments := map[string][]Comment{}
+	for idx := len(board.Actions) - 1; idx >= 0; idx-- {
+		action := board.Actions[idx]
+		if action.Type != "commentCard" {
+			continue
+		}
+		date := action.Date
+		comments[action.Data.Card.ID] = append(comments[action.Data.Card.ID], Comment{
+			Author:    action.MemberCreator.FullName,
+			Body:      action.Data.Text,
+			CreatedAt: &date,
+		})
+	}
+
+	records := []Record{}
+	for _, card := range board.Cards {
+		if card.Closed || closedLists[card.IDList] {
+			continue
+		}
+
+		t := &tasks.Task{
+			Detail: detail(card.Name, card.Desc),
+		}
+		if card.Due != nil {
+			t.CompleteBy = *card.Due
+		}
+		if card.DueComplete || doneLists[card.IDList] {
+			t.Status = tasks.StatusDone
+		}
+		for _, id := range card.IDMembers {
+			if email := emails[id]; email != "" {
+				t.Assignees = append(t.Assignees, email)
+			}
+		}
+		for _, l := range card.Labels {
+			name := l.Name
+			if strings.TrimSpace(name) == "" {
+				name = l.Color
+			}
+			if name = label(name); name != "" {
+				t.Tags = append(t.Tags, name)
+			}
+		}
+
+		records = append(records, Record{
+			Row:      len(records) + 1,
+			Ref:      card.ID,
+			Task:     t,
+			Comments: comments[card.ID],
+		})
+	}
+
+	return records, nil
+}
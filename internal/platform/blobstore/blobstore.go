@@ -0,0 +1,17 @@
+// Package blobstore stores binary objects (e.g. uploaded files) outside of the database
+package blobstore
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore stores blobs by key. Keys are slash separated paths, e.g. "tasks/12/3f9a..."
+type BlobStore interface {
+	// Put stores the content of r at key, replacing any existing blob
+	Put(ctx context.Context, key string, r io.Reader) error
+	// Get returns the content of the blob at key, it returns a NotFound error if it does not exist
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete deletes the blob at key, deleting a blob which does not exist is not an error
+	Delete(ctx context.Context, key string) error
+}
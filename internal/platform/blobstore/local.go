@@ -0,0 +1,105 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// Local is a BlobStore keeping blobs as files in a directory of the local filesystem
+type Local struct {
+	root string
+}
+
+// path returns the file path of a key, keys cannot point outside of the root directory
+func (l *Local) path(key string) (string, error) {
+	clean := filepath.Clean("/" + key)
+	if clean == "/" || strings.Contains(key, "..") {
+		return "", errors.Validationf("invalid blob key '%s'", key)
+	}
+	return filepath.Join(l.root, filepath.FromSlash(clean)), nil
+}
+
+// Put writes the blob to a temporary file first, so a failed upload never leaves a partial blob
+func (l *Local) Put(ctx context.Context, key string, r io.Reader) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o750)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = io.Copy(tmp, r)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+
+	err = tmp.Close()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	err = os.Rename(tmp.Name(), path)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (l *Local) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	path, err := l.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, errors.NotFound("blob not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return f, nil
+}
+
+func (l *Local) Delete(ctx context.Context, key string) error {
+	path, err := l.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if err != nil && !os.IsNotExist(err) {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// NewLocal returns a BlobStore keeping blobs in the root directory, which is created if needed
+func NewLocal(root string) (*Local, error) {
+	err := os.MkdirAll(root, 0o750)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return &Local{
+		root: root,
+	}, nil
+}
@@ -0,0 +1,57 @@
+package datastore
+
+import (
+	"context"
+
+	"github.com/jackc/pgconn"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type txKey struct{}
+
+// Querier is implemented by both *pgxpool.Pool and pgx.Tx, so stores can run the same
+// queries with or without a transaction
+type Querier interface {
+	Exec(ctx context.Context, sql string, arguments ...interface{}) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row
+	CopyFrom(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
+// Conn returns the transaction stored in ctx by WithTx, or the pool if there is none
+func Conn(ctx context.Context, pool *pgxpool.Pool) Querier {
+	tx, ok := ctx.Value(txKey{}).(pgx.Tx)
+	if ok {
+		return tx
+	}
+	return pool
+}
+
+// WithTx runs fn inside a transaction. The transaction is carried in the context passed to fn,
+// and is committed if fn returns nil. If ctx already carries a transaction, fn runs inside a
+// savepoint of it instead, so a failing fn only rolls back its own changes
+func WithTx(ctx context.Context, pool *pgxpool.Pool, fn func(ctx context.Context) error) error {
+	var (
+		tx  pgx.Tx
+		err error
+	)
+
+	parent, ok := ctx.Value(txKey{}).(pgx.Tx)
+	if ok {
+		tx, err = parent.Begin(ctx)
+	} else {
+		tx, err = pool.Begin(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	err = fn(context.WithValue(ctx, txKey{}, tx))
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,378 @@
+// Package jsonpatch applies JSON Patch (RFC 6902) and JSON Merge Patch (RFC 7396) documents to
+// JSON values decoded as interface{}, i.e. maps, slices, json.Number, strings, bools and nil
+package jsonpatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// MergePatchType is the media type of JSON Merge Patch documents
+	MergePatchType = "application/merge-patch+json"
+	// PatchType is the media type of JSON Patch documents
+	PatchType = "application/json-patch+json"
+)
+
+const (
+	OpAdd     = "add"
+	OpRemove  = "remove"
+	OpReplace = "replace"
+	OpMove    = "move"
+	OpCopy    = "copy"
+	OpTest    = "test"
+)
+
+// Operation is an operation of a JSON Patch. Value is nil if the operation has no value, and
+// "null" if its value is null
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Unmarshal decodes a JSON value, numbers are kept as json.Number so large integers like IDs
+// are not rounded
+func Unmarshal(b []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	if dec.More() {
+		return nil, errors.Validation("unexpected data after the JSON value")
+	}
+	return v, nil
+}
+
+// MergePatch applies a JSON Merge Patch to doc. Members of the patch which are null are removed
+// from doc, objects are merged and any other value replaces the one in doc
+func MergePatch(doc interface{}, patch interface{}) interface{} {
+	fields, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	target, ok := doc.(map[string]interface{})
+	if !ok {
+		target = map[string]interface{}{}
+	}
+	for key, value := range fields {
+		if value == nil {
+			delete(target, key)
+			continue
+		}
+		target[key] = MergePatch(target[key], value)
+	}
+	return target
+}
+
+// Apply applies the operations to doc in order. doc is not modified, and nothing is applied if
+// any of the operations fails
+func Apply(doc interface{}, ops []Operation) (interface{}, error) {
+	doc = deepCopy(doc)
+	for idx, op := range ops {
+		var err error
+		doc, err = apply(doc, op)
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "operation %d (%s %s)", idx, op.Op, op.Path)
+		}
+	}
+	return doc, nil
+}
+
+func apply(doc interface{}, op Operation) (interface{}, error) {
+	path, err := ParsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case OpAdd:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OpRemove:
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case OpReplace:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		if len(path) == 0 {
+			return value, nil
+		}
+		doc, _, err = remove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OpMove:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if len(from) < len(path) && reflect.DeepEqual(from, path[:len(from)]) {
+			return nil, errors.Validation("a value cannot be moved into itself")
+		}
+		doc, value, err := remove(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, value)
+	case OpCopy:
+		from, err := ParsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		value, err := get(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		return add(doc, path, deepCopy(value))
+	case OpTest:
+		value, err := op.value()
+		if err != nil {
+			return nil, err
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !equal(current, value) {
+			return nil, errors.Validation("test failed, the value is different")
+		}
+		return doc, nil
+	}
+
+	return nil, errors.Validationf("unknown operation '%s'", op.Op)
+}
+
+func (op *Operation) value() (interface{}, error) {
+	if op.Value == nil {
+		return nil, errors.Validation("value is required")
+	}
+	value, err := Unmarshal(op.Value)
+	if err != nil {
+		return nil, errors.Validationf("invalid value: %s", err.Error())
+	}
+	return value, nil
+}
+
+// ParsePointer returns the reference tokens of a JSON Pointer (RFC 6901), "" is the whole document
+func ParsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if pointer[0] != '/' {
+		return nil, errors.Validationf("invalid path '%s', it should start with /", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for idx, token := range tokens {
+		tokens[idx] = strings.NewReplacer("~1", "/", "~0", "~").Replace(token)
+	}
+	return tokens, nil
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		switch node := doc.(type) {
+		case map[string]interface{}:
+			value, ok := node[token]
+			if !ok {
+				return nil, errors.Validationf("'%s' does not exist", token)
+			}
+			doc = value
+		case []interface{}:
+			idx, err := index(token, len(node)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = node[idx]
+		default:
+			return nil, errors.Validationf("'%s' does not exist", token)
+		}
+	}
+	return doc, nil
+}
+
+// add adds value at path, it returns the document since adding at the root replaces it
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			node[token] = value
+			return node, nil
+		}
+		child, ok := node[token]
+		if !ok {
+			return nil, errors.Validationf("'%s' does not exist", token)
+		}
+		child, err := add(child, path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[token] = child
+		return node, nil
+	case []interface{}:
+		if len(path) == 1 {
+			idx := len(node)
+			if token != "-" {
+				var err error
+				idx, err = index(token, len(node))
+				if err != nil {
+					return nil, err
+				}
+			}
+			node = append(node, nil)
+			copy(node[idx+1:], node[idx:])
+			node[idx] = value
+			return node, nil
+		}
+		idx, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, err
+		}
+		child, err := add(node[idx], path[1:], value)
+		if err != nil {
+			return nil, err
+		}
+		node[idx] = child
+		return node, nil
+	}
+
+	return nil, errors.Validationf("'%s' does not exist", token)
+}
+
+// remove removes the value at path, it returns the document and the value removed
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.Validation("the whole document cannot be removed")
+	}
+
+	token := path[0]
+	switch node := doc.(type) {
+	case map[string]interface{}:
+		child, ok := node[token]
+		if !ok {
+			return nil, nil, errors.Validationf("'%s' does not exist", token)
+		}
+		if len(path) == 1 {
+			delete(node, token)
+			return node, child, nil
+		}
+		child, removed, err := remove(child, path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[token] = child
+		return node, removed, nil
+	case []interface{}:
+		idx, err := index(token, len(node)-1)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(path) == 1 {
+			removed := node[idx]
+			return append(node[:idx], node[idx+1:]...), removed, nil
+		}
+		child, removed, err := remove(node[idx], path[1:])
+		if err != nil {
+			return nil, nil, err
+		}
+		node[idx] = child
+		return node, removed, nil
+	}
+
+	return nil, nil, errors.Validationf("'%s' does not exist", token)
+}
+
+// index returns the array index of a token, which is at most max
+func index(token string, max int) (int, error) {
+	idx, err := strconv.Atoi(token)
+	if err != nil || idx < 0 || (len(token) > 1 && token[0] == '0') {
+		return 0, errors.Validationf("invalid array index '%s'", token)
+	}
+	if idx > max {
+		return 0, errors.Validationf("array index %d is out of bounds", idx)
+	}
+	return idx, nil
+}
+
+// equal compares JSON values, numbers are equal if they have the same value however they are written
+func equal(a interface{}, b interface{}) bool {
+	switch x := a.(type) {
+	case json.Number:
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		if x == y {
+			return true
+		}
+		fx, errx := x.Float64()
+		fy, erry := y.Float64()
+		return errx == nil && erry == nil && fx == fy
+	case map[string]interface{}:
+		y, ok := b.(map[string]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for key, value := range x {
+			other, ok := y[key]
+			if !ok || !equal(value, other) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for idx := range x {
+			if !equal(x[idx], y[idx]) {
+				return false
+			}
+		}
+		return true
+	}
+
+	return a == b
+}
+
+func deepCopy(v interface{}) interface{} {
+	switch node := v.(type) {
+	case map[string]interface{}:
+		c := make(map[string]interface{}, len(node))
+		for key, value := range node {
+			c[key] = deepCopy(value)
+		}
+		return c
+	case []interface{}:
+		c := make([]interface{}, len(node))
+		for idx, value := range node {
+			c[idx] = deepCopy(value)
+		}
+		return c
+	}
+	return v
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/logger"
+)
+
+// Job is a unit of background work, run periodically by Every
+type Job func(ctx context.Context) error
+
+// Every runs job once every interval until ctx is cancelled. Errors returned by job are logged,
+// and do not stop the worker
+func Every(ctx context.Context, l logger.Logger, name string, interval time.Duration, job Job) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	l.Info("background worker started", name, interval.String())
+	for {
+		err := job(ctx)
+		if err != nil {
+			l.Error(name, err.Error())
+		}
+
+		select {
+		case <-ctx.Done():
+			l.Info("background worker stopped", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
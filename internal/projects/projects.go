@@ -0,0 +1,396 @@
+package projects
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/reminders"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// RoleOwner can change the settings and the members of the project, and archive it
+	RoleOwner = "owner"
+	// RoleEditor can add tasks to the project and move them in or out of it
+	RoleEditor = "editor"
+	// RoleViewer can only see the project and its tasks
+	RoleViewer = "viewer"
+
+	// MaxNameLength is the maximum number of characters in the name of a project
+	MaxNameLength = 200
+)
+
+// roleRanks orders the roles, a role has all the permissions of the roles ranked below it
+var roleRanks = map[string]int{
+	RoleViewer: 1,
+	RoleEditor: 2,
+	RoleOwner:  3,
+}
+
+// Defaults are applied to the tasks of a project
+type Defaults struct {
+	// Assignee is the email address new tasks are assigned to if they are not assigned to anyone
+	Assignee string `json:"assignee,omitempty"`
+	// ReminderOffsets replace the default reminder offsets for the tasks of the project, unless a
+	// task overrides them. nil keeps the default ones, an empty list disables reminders
+	ReminderOffsets []reminders.Offset `json:"reminderOffsets"`
+}
+
+// Project groups tasks, and is shared with its members
+type Project struct {
+	ID          int64    `json:"id,omitempty"`
+	UID         int64    `json:"uid,omitempty"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Defaults    Defaults `json:"defaults"`
+	// Role is the role of the user who requested the project
+	Role       string     `json:"role,omitempty"`
+	ArchivedAt *time.Time `json:"archivedAt,omitempty"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (p *Project) Sanitize() {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Description = strings.TrimSpace(p.Description)
+	p.Defaults.Assignee = strings.ToLower(strings.TrimSpace(p.Defaults.Assignee))
+}
+
+func (p *Project) Validate() error {
+	if p.Name == "" {
+		return errors.Validation("name is required")
+	}
+
+	if len([]rune(p.Name)) > MaxNameLength {
+		return errors.Validationf("name cannot be longer than %d characters", MaxNameLength)
+	}
+
+	if p.Defaults.Assignee != "" && !strings.Contains(p.Defaults.Assignee, "@") {
+		return errors.Validation("the default assignee should be an email address")
+	}
+
+	o := reminders.Override{Offsets: p.Defaults.ReminderOffsets}
+	return o.Validate()
+}
+
+// Member is a registered user the project is shared with
+type Member struct {
+	PID       int64      `json:"pid,omitempty"`
+	UID       int64      `json:"uid"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type Projects struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+	users      *users.Users
+	pqdriver   *pgxpool.Pool
+}
+
+// Create creates a project owned by the user uid
+func (ps *Projects) Create(ctx context.Context, p *Project, uid int64) (*Project, error) {
+	p.Sanitize()
+	err := p.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.UID = uid
+	p.Role = RoleOwner
+	p.ArchivedAt = nil
+	p.CreatedAt = &now
+	p.UpdatedAt = &now
+
+	err = datastore.WithTx(ctx, ps.pqdriver, func(ctx context.Context) error {
+		p.ID, err = ps.store.Create(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		return ps.store.SaveMember(ctx, &Member{
+			PID:       p.ID,
+			UID:       uid,
+			Role:      RoleOwner,
+			CreatedAt: &now,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Edit changes the name, description and defaults of a project, only its owner can edit it
+func (ps *Projects) Edit(ctx context.Context, pid int64, uid int64, p *Project) (*Project, error) {
+	existing, err := ps.Authorize(ctx, pid, uid, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Sanitize()
+	err = p.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.ID = existing.ID
+	p.UID = existing.UID
+	p.Role = existing.Role
+	p.ArchivedAt = existing.ArchivedAt
+	p.CreatedAt = existing.CreatedAt
+	p.UpdatedAt = &now
+	err = ps.store.Edit(ctx, p)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Get returns a project if the user is a member of it
+func (ps *Projects) Get(ctx context.Context, pid int64, uid int64) (*Project, error) {
+	return ps.Authorize(ctx, pid, uid, RoleViewer)
+}
+
+// GetAll returns the projects the user is a member of, archived ones only if archived is true
+func (ps *Projects) GetAll(ctx context.Context, uid int64, archived bool) ([]Project, error) {
+	return ps.store.GetAll(ctx, uid, archived)
+}
+
+// Archive archives or unarchives a project. The tasks of archived projects are not listed with the
+// user's tasks, no reminders are sent for them, and tasks cannot be added to them
+func (ps *Projects) Archive(ctx context.Context, pid int64, uid int64, archive bool) (*Project, error) {
+	p, err := ps.Authorize(ctx, pid, uid, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p.ArchivedAt = nil
+	if archive {
+		p.ArchivedAt = &now
+	}
+	p.UpdatedAt = &now
+
+	err = ps.store.SetArchived(ctx, pid, p.ArchivedAt, now)
+	if err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// Members returns the members of a project, the owner first
+func (ps *Projects) Members(ctx context.Context, pid int64, uid int64) ([]Member, error) {
+	_, err := ps.Authorize(ctx, pid, uid, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.store.GetMembers(ctx, pid)
+}
+
+// AddMember shares the project with a registered user, or changes their role if they already are
+// a member. Only the owner can manage members, and there is only one owner
+func (ps *Projects) AddMember(ctx context.Context, pid int64, uid int64, email string, role string) (*Member, error) {
+	_, err := ps.Authorize(ctx, pid, uid, RoleOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	role = strings.ToLower(strings.TrimSpace(role))
+	if role != RoleEditor && role != RoleViewer {
+		return nil, errors.Validation("role should be either editor or viewer")
+	}
+
+	u, err := ps.users.GetUserByEmail(ctx, email)
+	if err != nil {
+		if errors.HasType(err, errors.TypeNotFound) {
+			return nil, errors.Validationf("'%s' is not registered", email)
+		}
+		return nil, err
+	}
+
+	if u.UID == uid {
+		return nil, errors.Validation("the role of the owner cannot be changed")
+	}
+
+	now := time.Now()
+	m := &Member{
+		PID:       pid,
+		UID:       u.UID,
+		Email:     u.Email,
+		Role:      role,
+		CreatedAt: &now,
+	}
+	err = ps.store.SaveMember(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// RemoveMember stops sharing the project with a user, the owner cannot be removed
+func (ps *Projects) RemoveMember(ctx context.Context, pid int64, uid int64, memberUID int64) error {
+	p, err := ps.Authorize(ctx, pid, uid, RoleOwner)
+	if err != nil {
+		return err
+	}
+
+	if memberUID == p.UID {
+		return errors.Validation("the owner of a project cannot be removed")
+	}
+
+	return ps.store.RemoveMember(ctx, pid, memberUID)
+}
+
+// Authorize returns the project if the user has at least the role in it. Projects the user is not
+// a member of are reported as not found, so their existence is not disclosed
+func (ps *Projects) Authorize(ctx context.Context, pid int64, uid int64, role string) (*Project, error) {
+	p, err := ps.store.Get(ctx, pid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if roleRanks[p.Role] < roleRanks[role] {
+		return nil, errors.Unauthorizedf("only a project %s can do this", role)
+	}
+
+	return p, nil
+}
+
+// writable returns the project if the user can add tasks to it
+func (ps *Projects) writable(ctx context.Context, pid int64, uid int64) (*Project, error) {
+	p, err := ps.Authorize(ctx, pid, uid, RoleEditor)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.ArchivedAt != nil {
+		return nil, errors.Validation("the project is archived")
+	}
+
+	return p, nil
+}
+
+// PrepareTask checks if the user can create the task in its project, and applies the project's
+// defaults to it. Tasks which are not part of a project are left as is
+func (ps *Projects) PrepareTask(ctx context.Context, uid int64, t *tasks.Task) error {
+	if t.ProjectID == 0 {
+		return nil
+	}
+
+	p, err := ps.writable(ctx, t.ProjectID, uid)
+	if err != nil {
+		return err
+	}
+
+	p.applyDefaults(t)
+	return nil
+}
+
+// PrepareTasks is PrepareTask for many tasks at once, each project is looked up only once. The
+// errors are at the same index as their task
+func (ps *Projects) PrepareTasks(ctx context.Context, uid int64, list []*tasks.Task) []error {
+	errs := make([]error, len(list))
+	projects := map[int64]*Project{}
+	projectErrs := map[int64]error{}
+	for idx, t := range list {
+		if t.ProjectID == 0 {
+			continue
+		}
+
+		p, seen := projects[t.ProjectID]
+		if !seen {
+			var err error
+			p, err = ps.writable(ctx, t.ProjectID, uid)
+			projects[t.ProjectID], projectErrs[t.ProjectID] = p, err
+		}
+
+		if err := projectErrs[t.ProjectID]; err != nil {
+			errs[idx] = err
+			continue
+		}
+		p.applyDefaults(t)
+	}
+
+	return errs
+}
+
+func (p *Project) applyDefaults(t *tasks.Task) {
+	if strings.TrimSpace(t.AssignedTo) == "" && len(t.Assignees) == 0 {
+		t.AssignedTo = p.Defaults.Assignee
+	}
+}
+
+// MoveTask moves a task, along with its subtasks, to another project. 0 moves it out of any
+// project. The user should be able to edit both projects
+func (ps *Projects) MoveTask(ctx context.Context, tid int64, pid int64, uid int64) (*tasks.Task, error) {
+	t, err := ps.tasks.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.ProjectID != 0 {
+		_, err = ps.Authorize(ctx, t.ProjectID, uid, RoleEditor)
+		if err != nil {
+			return nil, err
+		}
+	} else if t.UID != uid {
+		return nil, errors.Unauthorized("only the owner of a task can move it to a project")
+	}
+
+	if pid != 0 {
+		_, err = ps.writable(ctx, pid, uid)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ps.tasks.Move(ctx, tid, pid, uid)
+}
+
+// Tasks returns a page of the tasks of a project
+func (ps *Projects) Tasks(ctx context.Context, pid int64, uid int64, filter *tasks.Filter) (*tasks.Page, error) {
+	_, err := ps.Authorize(ctx, pid, uid, RoleViewer)
+	if err != nil {
+		return nil, err
+	}
+
+	filter.ProjectID = pid
+	return ps.tasks.GetAll(ctx, uid, filter)
+}
+
+func NewService(
+	l logger.Logger,
+	pqdriver *pgxpool.Pool,
+	ts *tasks.Tasks,
+	us *users.Users,
+) (*Projects, error) {
+	pstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Projects{
+		logHandler: l,
+		store:      pstore,
+		tasks:      ts,
+		users:      us,
+		pqdriver:   pqdriver,
+	}, nil
+}
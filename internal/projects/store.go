@@ -0,0 +1,330 @@
+package projects
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/reminders"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Create(ctx context.Context, p *Project) (int64, error)
+	Edit(ctx context.Context, p *Project) error
+	Get(ctx context.Context, pid int64, uid int64) (*Project, error)
+	GetAll(ctx context.Context, uid int64, archived bool) ([]Project, error)
+	SetArchived(ctx context.Context, pid int64, archivedAt *time.Time, updatedAt time.Time) error
+	SaveMember(ctx context.Context, m *Member) error
+	RemoveMember(ctx context.Context, pid int64, uid int64) error
+	GetMembers(ctx context.Context, pid int64) ([]Member, error)
+}
+
+type projectStore struct {
+	qbuilder     squirrel.StatementBuilderType
+	pqdriver     *pgxpool.Pool
+	tableName    string
+	membersTable string
+}
+
+// projectColumns are selected from projects p joined with the membership m of the requesting user
+var projectColumns = []string{
+	"p.id",
+	"p.uid",
+	"p.name",
+	"p.description",
+	"p.defaultAssignee",
+	"p.reminderOffsets",
+	"m.role",
+	"p.archivedAt",
+	"p.createdAt",
+	"p.updatedAt",
+}
+
+func (ps *projectStore) Create(ctx context.Context, p *Project) (int64, error) {
+	query, args, err := ps.qbuilder.Insert(ps.tableName).SetMap(map[string]interface{}{
+		"uid":             p.UID,
+		"name":            p.Name,
+		"description":     p.Description,
+		"defaultAssignee": p.Defaults.Assignee,
+		"reminderOffsets": offsetSeconds(p.Defaults.ReminderOffsets),
+		"createdAt":       p.CreatedAt,
+		"updatedAt":       p.UpdatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	var id int64
+	err = datastore.Conn(ctx, ps.pqdriver).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return id, nil
+}
+
+func (ps *projectStore) Edit(ctx context.Context, p *Project) error {
+	query, args, err := ps.qbuilder.Update(ps.tableName).SetMap(map[string]interface{}{
+		"name":            p.Name,
+		"description":     p.Description,
+		"defaultAssignee": p.Defaults.Assignee,
+		"reminderOffsets": offsetSeconds(p.Defaults.ReminderOffsets),
+		"updatedAt":       p.UpdatedAt,
+	}).Where(squirrel.Eq{
+		"id": p.ID,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := ps.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("project not found")
+	}
+
+	return nil
+}
+
+// Get returns the project along with the role of the user in it, projects the user is not a member
+// of are not found
+func (ps *projectStore) Get(ctx context.Context, pid int64, uid int64) (*Project, error) {
+	query, args, err := ps.selectProjects(uid).Where(
+		squirrel.Eq{
+			"p.id": pid,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	p, err := scanProject(datastore.Conn(ctx, ps.pqdriver).QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("project not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return p, nil
+}
+
+func (ps *projectStore) GetAll(ctx context.Context, uid int64, archived bool) ([]Project, error) {
+	builder := ps.selectProjects(uid)
+	if !archived {
+		builder = builder.Where(squirrel.Eq{"p.archivedAt": nil})
+	}
+
+	query, args, err := builder.OrderBy(
+		"p.name",
+		"p.id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := ps.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Project{}
+	for rows.Next() {
+		p, err := scanProject(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *p)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+func (ps *projectStore) SetArchived(ctx context.Context, pid int64, archivedAt *time.Time, updatedAt time.Time) error {
+	query, args, err := ps.qbuilder.Update(ps.tableName).SetMap(map[string]interface{}{
+		"archivedAt": archivedAt,
+		"updatedAt":  updatedAt,
+	}).Where(squirrel.Eq{
+		"id": pid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := ps.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("project not found")
+	}
+
+	return nil
+}
+
+// SaveMember adds a member or changes the role of an existing one
+func (ps *projectStore) SaveMember(ctx context.Context, m *Member) error {
+	query, args, err := ps.qbuilder.Insert(ps.membersTable).SetMap(map[string]interface{}{
+		"pid":       m.PID,
+		"uid":       m.UID,
+		"role":      m.Role,
+		"createdAt": m.CreatedAt,
+	}).Suffix(
+		"ON CONFLICT (pid, uid) DO UPDATE SET role = EXCLUDED.role",
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ps.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ps *projectStore) RemoveMember(ctx context.Context, pid int64, uid int64) error {
+	query, args, err := ps.qbuilder.Delete(ps.membersTable).Where(squirrel.Eq{
+		"pid": pid,
+		"uid": uid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := ps.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("member not found")
+	}
+
+	return nil
+}
+
+func (ps *projectStore) GetMembers(ctx context.Context, pid int64) ([]Member, error) {
+	query, args, err := ps.qbuilder.Select(
+		"m.pid",
+		"m.uid",
+		"u.email",
+		"m.role",
+		"m.createdAt",
+	).From(
+		ps.membersTable+" m",
+	).Join(
+		"users u ON u.id = m.uid",
+	).Where(
+		squirrel.Eq{
+			"m.pid": pid,
+		},
+	).OrderBy(
+		"m.role = '"+RoleOwner+"' DESC",
+		"m.createdAt",
+		"m.uid",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := ps.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Member{}
+	for rows.Next() {
+		m := Member{}
+		err = rows.Scan(&m.PID, &m.UID, &m.Email, &m.Role, &m.CreatedAt)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, m)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+// selectProjects selects the projects the user is a member of, with projectColumns
+func (ps *projectStore) selectProjects(uid int64) squirrel.SelectBuilder {
+	return ps.qbuilder.Select(
+		projectColumns...,
+	).From(
+		ps.tableName+" p",
+	).Join(
+		ps.membersTable+" m ON m.pid = p.id AND m.uid = ?", uid,
+	)
+}
+
+// scanProject scans a row selected with projectColumns
+func scanProject(row pgx.Row) (*Project, error) {
+	p := new(Project)
+	var seconds []int64
+	err := row.Scan(
+		&p.ID,
+		&p.UID,
+		&p.Name,
+		&p.Description,
+		&p.Defaults.Assignee,
+		&seconds,
+		&p.Role,
+		&p.ArchivedAt,
+		&p.CreatedAt,
+		&p.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if seconds != nil {
+		p.Defaults.ReminderOffsets = make([]reminders.Offset, 0, len(seconds))
+		for _, s := range seconds {
+			p.Defaults.ReminderOffsets = append(p.Defaults.ReminderOffsets, reminders.Offset(time.Duration(s)*time.Second))
+		}
+	}
+
+	return p, nil
+}
+
+// offsetSeconds converts reminder offsets to seconds, keeping nil as nil so it is stored as NULL
+func offsetSeconds(offsets []reminders.Offset) []int64 {
+	if offsets == nil {
+		return nil
+	}
+
+	seconds := make([]int64, 0, len(offsets))
+	for _, o := range offsets {
+		seconds = append(seconds, int64(time.Duration(o)/time.Second))
+	}
+	return seconds
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*projectStore, error) {
+	return &projectStore{
+		pqdriver:     pqdriver,
+		qbuilder:     squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName:    "projects",
+		membersTable: "project_members",
+	}, nil
+}
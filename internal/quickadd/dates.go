@@ -0,0 +1,273 @@
+package quickadd
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	weekdays = map[string]time.Weekday{
+		"sunday":    time.Sunday,
+		"monday":    time.Monday,
+		"tuesday":   time.Tuesday,
+		"wednesday": time.Wednesday,
+		"thursday":  time.Thursday,
+		"friday":    time.Friday,
+		"saturday":  time.Saturday,
+	}
+	// shortWeekdays are only read after words like "on", "sun" or "wed" are common words otherwise
+	shortWeekdays = map[string]time.Weekday{
+		"sun":   time.Sunday,
+		"mon":   time.Monday,
+		"tue":   time.Tuesday,
+		"tues":  time.Tuesday,
+		"wed":   time.Wednesday,
+		"thu":   time.Thursday,
+		"thur":  time.Thursday,
+		"thurs": time.Thursday,
+		"fri":   time.Friday,
+		"sat":   time.Saturday,
+	}
+	months = map[string]time.Month{
+		"january":   time.January,
+		"jan":       time.January,
+		"february":  time.February,
+		"feb":       time.February,
+		"march":     time.March,
+		"mar":       time.March,
+		"april":     time.April,
+		"apr":       time.April,
+		"may":       time.May,
+		"june":      time.June,
+		"jun":       time.June,
+		"july":      time.July,
+		"jul":       time.July,
+		"august":    time.August,
+		"aug":       time.August,
+		"september": time.September,
+		"sep":       time.September,
+		"sept":      time.September,
+		"october":   time.October,
+		"oct":       time.October,
+		"november":  time.November,
+		"nov":       time.November,
+		"december":  time.December,
+		"dec":       time.December,
+	}
+
+	dayOfMonth = regexp.MustCompile(`^([0-9]{1,2})(st|nd|rd|th)?$`)
+	clock12    = regexp.MustCompile(`^([0-9]{1,2})(?::([0-9]{2}))?(am|pm)$`)
+	clock24    = regexp.MustCompile(`^([0-9]{1,2}):([0-9]{2})$`)
+)
+
+// clock is a time of the day
+type clock struct {
+	hour   int
+	minute int
+}
+
+// on returns the time on the date
+func (c *clock) on(date time.Time) time.Time {
+	y, m, d := date.Date()
+	return time.Date(y, m, d, c.hour, c.minute, 0, 0, date.Location())
+}
+
+// relative reads "in <n> <unit>" at the word idx, like "in 2 hours" or "in 3 days". Minutes and
+// hours are a time, days, weeks and months are a date
+func (p *parser) relative(idx int) (*time.Time, *time.Time, int) {
+	if p.lower[idx] != "in" || idx+2 >= len(p.words) {
+		return nil, nil, 0
+	}
+
+	n, err := strconv.Atoi(p.lower[idx+1])
+	if p.lower[idx+1] == "a" || p.lower[idx+1] == "an" {
+		n, err = 1, nil
+	}
+	if err != nil || n <= 0 || n > 1000 {
+		return nil, nil, 0
+	}
+
+	unit := strings.TrimSuffix(p.lower[idx+2], "s")
+	switch unit {
+	case "minute", "min":
+		t := p.now.Add(time.Duration(n) * time.Minute).Truncate(time.Minute)
+		return &t, nil, 3
+	case "hour", "hr":
+		t := p.now.Add(time.Duration(n) * time.Hour).Truncate(time.Minute)
+		return &t, nil, 3
+	case "day":
+		d := p.today().AddDate(0, 0, n)
+		return nil, &d, 3
+	case "week":
+		d := p.today().AddDate(0, 0, 7*n)
+		return nil, &d, 3
+	case "month":
+		d := p.today().AddDate(0, n, 0)
+		return nil, &d, 3
+	}
+
+	return nil, nil, 0
+}
+
+// parseDate reads a date at the word idx: "today", "tomorrow", weekdays (the next one, or today),
+// "next <weekday>" (the next one after today), "next week" (its Monday), "next month" (its first
+// day), ISO dates and dates like "June 5", "5th June" or "June 5 2027". Dates without a year are
+// the next ones
+func (p *parser) parseDate(idx int, introduced bool) (*time.Time, int) {
+	today := p.today()
+	word := p.lower[idx]
+
+	switch word {
+	case "today":
+		return &today, 1
+	case "tomorrow":
+		d := today.AddDate(0, 0, 1)
+		return &d, 1
+	case "next", "this":
+		if idx+1 >= len(p.words) {
+			return nil, 0
+		}
+		next := p.lower[idx+1]
+		if word == "next" && next == "week" {
+			days := (int(time.Monday) - int(today.Weekday()) + 7) % 7
+			if days == 0 {
+				days = 7
+			}
+			d := today.AddDate(0, 0, days)
+			return &d, 2
+		}
+		if word == "next" && next == "month" {
+			d := time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, p.loc)
+			return &d, 2
+		}
+		if wd, ok := weekday(next, true); ok {
+			d := nextWeekday(today, wd, word == "next")
+			return &d, 2
+		}
+		return nil, 0
+	}
+
+	if wd, ok := weekday(word, introduced); ok {
+		d := nextWeekday(today, wd, false)
+		return &d, 1
+	}
+
+	if d, err := time.ParseInLocation("2006-01-02", word, p.loc); err == nil {
+		return &d, 1
+	}
+
+	// June 5, June 5th 2027
+	if month, ok := months[word]; ok && idx+1 < len(p.words) {
+		if day, ok := parseDay(p.lower[idx+1]); ok {
+			return p.monthDay(idx+2, month, day, 2)
+		}
+	}
+
+	// 5 June, 5th of June 2027
+	if day, ok := parseDay(word); ok && idx+1 < len(p.words) {
+		next := idx + 1
+		if p.lower[next] == "of" && next+1 < len(p.words) {
+			next++
+		}
+		if month, ok := months[p.lower[next]]; ok {
+			return p.monthDay(next+1, month, day, next+1-idx)
+		}
+	}
+
+	return nil, 0
+}
+
+// monthDay returns the date of a day of a month, read in n words. The year is read at the word
+// idx if there is one, otherwise the date is the next one
+func (p *parser) monthDay(idx int, month time.Month, day int, n int) (*time.Time, int) {
+	today := p.today()
+	if idx < len(p.words) {
+		if year, err := strconv.Atoi(p.lower[idx]); err == nil && year >= 1970 && year <= 9999 {
+			d := time.Date(year, month, day, 0, 0, 0, 0, p.loc)
+			if d.Day() != day {
+				return nil, 0
+			}
+			return &d, n + 1
+		}
+	}
+
+	d := time.Date(today.Year(), month, day, 0, 0, 0, 0, p.loc)
+	if d.Day() != day {
+		// like February 30, which only exists in no year
+		return nil, 0
+	}
+	if d.Before(today) {
+		d = d.AddDate(1, 0, 0)
+	}
+	return &d, n
+}
+
+// parseClock reads a time at the word idx, like "5pm", "5:30 pm", "17:00" or "noon"
+func (p *parser) parseClock(idx int) (*clock, int) {
+	word := p.lower[idx]
+	if word == "noon" {
+		return &clock{hour: 12}, 1
+	}
+
+	n := 1
+	// 5 pm
+	if idx+1 < len(p.words) && (p.lower[idx+1] == "am" || p.lower[idx+1] == "pm") {
+		word += p.lower[idx+1]
+		n = 2
+	}
+
+	if m := clock12.FindStringSubmatch(word); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour < 1 || hour > 12 || minute > 59 {
+			return nil, 0
+		}
+		hour = hour % 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+		return &clock{hour: hour, minute: minute}, n
+	}
+
+	if m := clock24.FindStringSubmatch(word); m != nil && n == 1 {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		if hour > 23 || minute > 59 {
+			return nil, 0
+		}
+		return &clock{hour: hour, minute: minute}, 1
+	}
+
+	return nil, 0
+}
+
+func weekday(word string, short bool) (time.Weekday, bool) {
+	if wd, ok := weekdays[word]; ok {
+		return wd, true
+	}
+	if short {
+		wd, ok := shortWeekdays[word]
+		return wd, ok
+	}
+	return 0, false
+}
+
+// nextWeekday returns the next date on the weekday, which is today unless excludeToday is true
+func nextWeekday(today time.Time, wd time.Weekday, excludeToday bool) time.Time {
+	days := (int(wd) - int(today.Weekday()) + 7) % 7
+	if days == 0 && excludeToday {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+func parseDay(word string) (int, bool) {
+	m := dayOfMonth.FindStringSubmatch(word)
+	if m == nil {
+		return 0, false
+	}
+	day, _ := strconv.Atoi(m[1])
+	return day, day >= 1 && day <= 31
+}
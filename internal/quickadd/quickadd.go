@@ -0,0 +1,224 @@
+// Package quickadd reads a task from a line of text, like "Send invoice to @bob@acme.com next
+// Friday 5pm !high #billing". Dates are read in the timezone of the user, and what was understood
+// is returned along with the task so it can be confirmed before it is saved
+package quickadd
+
+import (
+	"strings"
+	"time"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	KindDue      = "due"
+	KindAssignee = "assignee"
+	KindPriority = "priority"
+	KindTag      = "tag"
+)
+
+// MaxLength is the maximum number of characters of the text
+const MaxLength = 1000
+
+// Match is a part of the text which was read as a field of the task. Value is how it was
+// understood, e.g. the date of a due date
+type Match struct {
+	Text  string `json:"text"`
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Result is a task read from text, Matches are the parts of the text which are not in its detail
+type Result struct {
+	Task    *tasks.Task `json:"task"`
+	Matches []Match     `json:"matches"`
+}
+
+// Parse reads a task from the text. now is the current time in the timezone of the user, which
+// relative dates like "tomorrow" are based on
+func Parse(text string, now time.Time) (*Result, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, errors.Validation("text is required")
+	}
+	if len([]rune(text)) > MaxLength {
+		return nil, errors.Validationf("text cannot be longer than %d characters", MaxLength)
+	}
+
+	p := &parser{
+		words: strings.Fields(text),
+		now:   now,
+		loc:   now.Location(),
+	}
+	p.lower = make([]string, len(p.words))
+	for idx, word := range p.words {
+		p.lower[idx] = strings.ToLower(strings.TrimRight(word, ",.;"))
+	}
+
+	return p.parse()
+}
+
+type parser struct {
+	words []string
+	// lower are the words in lowercase, without trailing punctuation
+	lower []string
+	now   time.Time
+	loc   *time.Location
+
+	// the parts of the due date found so far
+	date    *time.Time
+	clock   *clock
+	instant *time.Time
+	dueText []string
+}
+
+func (p *parser) parse() (*Result, error) {
+	t := &tasks.Task{}
+	matches := []Match{}
+	detail := make([]string, 0, len(p.words))
+
+	for idx := 0; idx < len(p.words); {
+		word, lower := p.words[idx], p.lower[idx]
+
+		switch {
+		case len(lower) > 1 && lower[0] == '@' && isEmail(lower[1:]):
+			email := strings.TrimRight(word[1:], ",.;")
+			t.Assignees = append(t.Assignees, email)
+			matches = append(matches, Match{Text: word, Kind: KindAssignee, Value: email})
+			idx++
+			continue
+		case len(lower) > 1 && lower[0] == '!' && tasks.IsPriority(lower[1:]):
+			t.Priority = lower[1:]
+			matches = append(matches, Match{Text: word, Kind: KindPriority, Value: t.Priority})
+			idx++
+			continue
+		case len(lower) > 1 && lower[0] == '#':
+			tag := strings.TrimLeft(lower, "#")
+			if tag != "" {
+				t.Tags = append(t.Tags, tag)
+				matches = append(matches, Match{Text: word, Kind: KindTag, Value: tag})
+				idx++
+				continue
+			}
+		}
+
+		n := p.due(idx)
+		if n > 0 {
+			p.dueText = append(p.dueText, strings.Join(p.words[idx:idx+n], " "))
+			idx += n
+			continue
+		}
+
+		detail = append(detail, word)
+		idx++
+	}
+
+	t.Detail = strings.Join(detail, " ")
+	if t.Detail == "" {
+		return nil, errors.Validation("the task has no description")
+	}
+
+	if len(p.dueText) > 0 {
+		p.setDue(t)
+		value := t.DueDate
+		if !t.AllDay() {
+			value = t.CompleteBy.Format(time.RFC3339)
+		}
+		matches = append(matches, Match{Text: strings.Join(p.dueText, " "), Kind: KindDue, Value: value})
+	}
+
+	return &Result{Task: t, Matches: matches}, nil
+}
+
+// setDue sets the due date of the task from the parts found in the text. A date without a time
+// makes an all-day task, a time without a date is today, or tomorrow if it has passed
+func (p *parser) setDue(t *tasks.Task) {
+	t.DueTimezone = p.loc.String()
+
+	if p.instant != nil {
+		t.CompleteBy = *p.instant
+		return
+	}
+
+	if p.clock == nil {
+		t.DueDate = p.date.Format(tasks.DateLayout)
+		return
+	}
+
+	date := p.date
+	if date == nil {
+		today := p.today()
+		date = &today
+		if !p.clock.on(today).After(p.now) {
+			tomorrow := today.AddDate(0, 0, 1)
+			date = &tomorrow
+		}
+	}
+	t.CompleteBy = p.clock.on(*date)
+}
+
+// due reads a part of a due date at the word idx, it returns the number of words read. Words
+// like "on" or "at" are read along with the date or time following them
+func (p *parser) due(idx int) int {
+	if p.instant != nil {
+		return 0
+	}
+
+	switch p.lower[idx] {
+	case "on", "at", "by", "due":
+		if idx+1 < len(p.words) {
+			if n := p.dueAt(idx+1, true); n > 0 {
+				return n + 1
+			}
+		}
+		return 0
+	}
+
+	return p.dueAt(idx, false)
+}
+
+// dueAt reads a date, a time or a relative time at the word idx. introduced is true if the word
+// before it was like "on", abbreviated weekdays are only read then since they are common words
+func (p *parser) dueAt(idx int, introduced bool) int {
+	if p.date == nil && p.clock == nil {
+		if instant, date, n := p.relative(idx); n > 0 {
+			if instant != nil {
+				p.instant = instant
+			} else {
+				p.date = date
+			}
+			return n
+		}
+	}
+
+	if p.date == nil {
+		if date, n := p.parseDate(idx, introduced); n > 0 {
+			p.date = date
+			return n
+		}
+	}
+
+	if p.clock == nil {
+		if c, n := p.parseClock(idx); n > 0 {
+			p.clock = c
+			return n
+		}
+	}
+
+	return 0
+}
+
+// today returns the start of the current day
+func (p *parser) today() time.Time {
+	y, m, d := p.now.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, p.loc)
+}
+
+// isEmail is a loose check of an email address, like the checks of the rest of the app
+func isEmail(s string) bool {
+	s = strings.TrimRight(s, ",.;")
+	at := strings.Index(s, "@")
+	return at > 0 && at < len(s)-1 && !strings.Contains(s[at+1:], "@")
+}
@@ -0,0 +1,218 @@
+package recurrence
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+var (
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+
+	cronMonths = map[string]int{
+		"JAN": 1, "FEB": 2, "MAR": 3, "APR": 4, "MAY": 5, "JUN": 6,
+		"JUL": 7, "AUG": 8, "SEP": 9, "OCT": 10, "NOV": 11, "DEC": 12,
+	}
+
+	cronWeekdays = map[string]int{
+		"SUN": 0, "MON": 1, "TUE": 2, "WED": 3, "THU": 4, "FRI": 5, "SAT": 6,
+	}
+)
+
+// cronField is the set of values allowed for one field of a cron expression
+type cronField struct {
+	values [60]bool
+	star   bool
+}
+
+func (cf *cronField) has(v int) bool {
+	return cf.values[v]
+}
+
+type cron struct {
+	minute cronField
+	hour   cronField
+	dom    cronField
+	month  cronField
+	dow    cronField
+
+	start time.Time
+	loc   *time.Location
+}
+
+// Next implements Rule
+func (c *cron) Next(after time.Time) (time.Time, bool) {
+	if after.Before(c.start) {
+		after = c.start.Add(-time.Nanosecond)
+	}
+
+	y, m, d := after.In(c.loc).Date()
+	limit := after.Add(horizon)
+	for day := time.Date(y, m, d, 0, 0, 0, 0, c.loc); day.Before(limit); day = day.AddDate(0, 0, 1) {
+		if !c.matchDay(day) {
+			continue
+		}
+
+		candidates := []time.Time{}
+		for h := 0; h < 24; h++ {
+			if !c.hour.has(h) {
+				continue
+			}
+			for min := 0; min < 60; min++ {
+				if c.minute.has(min) {
+					candidates = append(candidates, wallClock(day.Year(), day.Month(), day.Day(), h, min, 0, c.loc))
+				}
+			}
+		}
+
+		for _, t := range sortUnique(candidates) {
+			if t.After(after) {
+				return t, true
+			}
+		}
+	}
+
+	return time.Time{}, false
+}
+
+// matchDay follows the cron convention: if both day of month and day of week are restricted,
+// a day matching either of them matches
+func (c *cron) matchDay(day time.Time) bool {
+	if !c.month.has(int(day.Month())) {
+		return false
+	}
+
+	domMatch := c.dom.has(day.Day())
+	dowMatch := c.dow.has(int(day.Weekday()))
+	if c.dom.star || c.dow.star {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func parseCron(expr string, loc *time.Location, start time.Time) (*cron, error) {
+	if descriptor, ok := cronDescriptors[strings.ToLower(expr)]; ok {
+		expr = descriptor
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, errors.Validation("cron expression must have 5 fields: minute hour day-of-month month day-of-week")
+	}
+
+	c := &cron{
+		start: start,
+		loc:   loc,
+	}
+
+	var err error
+	c.minute, err = parseCronField(fields[0], 0, 59, nil)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid cron minute")
+	}
+
+	c.hour, err = parseCronField(fields[1], 0, 23, nil)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid cron hour")
+	}
+
+	c.dom, err = parseCronField(fields[2], 1, 31, nil)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid cron day of month")
+	}
+
+	c.month, err = parseCronField(fields[3], 1, 12, cronMonths)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid cron month")
+	}
+
+	c.dow, err = parseCronField(fields[4], 0, 7, cronWeekdays)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid cron day of week")
+	}
+	// both 0 and 7 are Sunday
+	if c.dow.has(7) {
+		c.dow.values[0] = true
+	}
+
+	return c, nil
+}
+
+// parseCronField parses a comma separated list of '*', 'a', 'a-b', each optionally followed by
+// a step '/n'
+func parseCronField(field string, min int, max int, names map[string]int) (cronField, error) {
+	cf := cronField{}
+	if field == "*" || field == "?" {
+		cf.star = true
+	}
+
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if idx := strings.Index(item, "/"); idx >= 0 {
+			rangePart = item[:idx]
+			n, err := strconv.Atoi(item[idx+1:])
+			if err != nil || n < 1 {
+				return cf, errors.Newf("invalid step in '%s'", item)
+			}
+			step = n
+		}
+
+		lower, upper := min, max
+		switch {
+		case rangePart == "*" || rangePart == "?":
+		case strings.Contains(rangePart, "-"):
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			lower, err = parseCronValue(bounds[0], names)
+			if err != nil {
+				return cf, err
+			}
+			upper, err = parseCronValue(bounds[1], names)
+			if err != nil {
+				return cf, err
+			}
+		default:
+			v, err := parseCronValue(rangePart, names)
+			if err != nil {
+				return cf, err
+			}
+			lower = v
+			// 'a/n' means every n starting at a, a plain 'a' is just a
+			if step == 1 {
+				upper = v
+			}
+		}
+
+		if lower < min || upper > max || lower > upper {
+			return cf, errors.Newf("'%s' is out of range [%d, %d]", item, min, max)
+		}
+
+		for v := lower; v <= upper; v += step {
+			cf.values[v] = true
+		}
+	}
+
+	return cf, nil
+}
+
+func parseCronValue(value string, names map[string]int) (int, error) {
+	if v, ok := names[strings.ToUpper(value)]; ok {
+		return v, nil
+	}
+
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, errors.Newf("invalid value '%s'", value)
+	}
+	return v, nil
+}
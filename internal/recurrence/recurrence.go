@@ -0,0 +1,128 @@
+// Package recurrence parses the recurrence rules of tasks and computes their occurrences.
+// Rules can be written either as an iCalendar RRULE (RFC 5545), e.g. "FREQ=WEEKLY;BYDAY=MO",
+// or as a 5 field cron expression, e.g. "0 9 * * 1".
+//
+// Occurrences are always computed on the wall clock of the rule's timezone, so a task due
+// every day at 09:00 stays at 09:00 across DST changes. Wall clock times which do not exist
+// (skipped by a DST change) are moved forward by the length of the gap, and wall clock times
+// which happen twice occur only once, at the first instance.
+package recurrence
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// MaxPreview is the maximum number of occurrences returned by Preview
+	MaxPreview = 100
+)
+
+// Rule computes the occurrences of a recurrence
+type Rule interface {
+	// Next returns the first occurrence strictly after the given time. ok is false if the rule
+	// has no more occurrences
+	Next(after time.Time) (next time.Time, ok bool)
+}
+
+// LoadLocation returns the location for an IANA timezone name, defaulting to UTC when empty
+func LoadLocation(timezone string) (*time.Location, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		return time.UTC, nil
+	}
+
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, errors.ValidationErr(err, "invalid timezone provided")
+	}
+	return loc, nil
+}
+
+// Parse parses expr as an RRULE or a cron expression. Occurrences are computed in timezone, and
+// start is the first possible occurrence of the rule (DTSTART in RFC 5545 terms). RRULEs also
+// take their default day and time of day from start
+func Parse(expr string, timezone string, start time.Time) (Rule, error) {
+	loc, err := LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, errors.Validation("recurrence rule is empty")
+	}
+
+	start = start.In(loc)
+	if isRRule(expr) {
+		return parseRRule(expr, loc, start)
+	}
+
+	return parseCron(expr, loc, start)
+}
+
+// Preview returns up to n occurrences of rule after the given time
+func Preview(rule Rule, after time.Time, n int) []time.Time {
+	if n > MaxPreview {
+		n = MaxPreview
+	}
+
+	list := make([]time.Time, 0, n)
+	for len(list) < n {
+		next, ok := rule.Next(after)
+		if !ok {
+			break
+		}
+		list = append(list, next)
+		after = next
+	}
+
+	return list
+}
+
+func isRRule(expr string) bool {
+	expr = strings.ToUpper(expr)
+	return strings.HasPrefix(expr, "RRULE:") || strings.Contains(expr, "FREQ=")
+}
+
+// wallClock returns the instant at which the wall clock of loc shows the given date and time.
+// Times which happen twice resolve to the first instance, and times in a DST gap are moved
+// forward by the length of the gap
+func wallClock(year int, month time.Month, day, hour, min, sec int, loc *time.Location) time.Time {
+	t := time.Date(year, month, day, hour, min, sec, 0, loc)
+
+	// time.Date does not guarantee which side of a gap or of an overlap it picks, so the time is
+	// also resolved using the offset in effect the day before. That is the first instance of a
+	// time which happens twice, and it moves a time in a gap forward by the length of the gap
+	naive := time.Date(year, month, day, hour, min, sec, 0, time.UTC)
+	_, offset := naive.Add(-time.Hour * 24).In(loc).Zone()
+	before := naive.Add(-time.Duration(offset) * time.Second).In(loc)
+
+	if t.Hour() != hour || t.Minute() != min {
+		return before
+	}
+	if before.Hour() == hour && before.Minute() == min && before.Before(t) {
+		return before
+	}
+	return t
+}
+
+// sortUnique sorts the list and removes duplicates. Duplicates are possible when a time in a
+// DST gap is moved onto another candidate
+func sortUnique(list []time.Time) []time.Time {
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Before(list[j])
+	})
+
+	out := list[:0]
+	for idx, t := range list {
+		if idx > 0 && t.Equal(out[len(out)-1]) {
+			continue
+		}
+		out = append(out, t)
+	}
+	return out
+}
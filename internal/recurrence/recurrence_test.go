@@ -0,0 +1,259 @@
+package recurrence
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+func TestPreview(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		timezone string
+		// start is the start of the rule in its timezone, occurrences are previewed from just
+		// before it unless after is set
+		start string
+		after string
+		n     int
+		want  []string
+	}{
+		{
+			name:     "daily across the start of DST",
+			rule:     "FREQ=DAILY",
+			timezone: "Europe/Berlin",
+			start:    "2024-03-29T09:00:00",
+			n:        4,
+			want: []string{
+				"2024-03-29T09:00:00+01:00",
+				"2024-03-30T09:00:00+01:00",
+				"2024-03-31T09:00:00+02:00",
+				"2024-04-01T09:00:00+02:00",
+			},
+		},
+		{
+			name:     "daily in the DST gap",
+			rule:     "FREQ=DAILY",
+			timezone: "Europe/Berlin",
+			start:    "2024-03-30T02:30:00",
+			n:        3,
+			want: []string{
+				"2024-03-30T02:30:00+01:00",
+				"2024-03-31T03:30:00+02:00",
+				"2024-04-01T02:30:00+02:00",
+			},
+		},
+		{
+			name:     "daily in the DST overlap",
+			rule:     "FREQ=DAILY",
+			timezone: "Europe/Berlin",
+			start:    "2024-10-26T02:30:00",
+			n:        3,
+			want: []string{
+				"2024-10-26T02:30:00+02:00",
+				"2024-10-27T02:30:00+02:00",
+				"2024-10-28T02:30:00+01:00",
+			},
+		},
+		{
+			name:     "weekly with count",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3",
+			timezone: "UTC",
+			start:    "2024-06-03T09:00:00",
+			n:        5,
+			want: []string{
+				"2024-06-03T09:00:00Z",
+				"2024-06-05T09:00:00Z",
+				"2024-06-10T09:00:00Z",
+			},
+		},
+		{
+			name:     "count is counted from the start",
+			rule:     "FREQ=WEEKLY;BYDAY=MO,WE;COUNT=3",
+			timezone: "UTC",
+			start:    "2024-06-03T09:00:00",
+			after:    "2024-06-04T00:00:00",
+			n:        5,
+			want: []string{
+				"2024-06-05T09:00:00Z",
+				"2024-06-10T09:00:00Z",
+			},
+		},
+		{
+			name:     "until is inclusive",
+			rule:     "FREQ=DAILY;UNTIL=20240605T090000Z",
+			timezone: "UTC",
+			start:    "2024-06-03T09:00:00",
+			n:        5,
+			want: []string{
+				"2024-06-03T09:00:00Z",
+				"2024-06-04T09:00:00Z",
+				"2024-06-05T09:00:00Z",
+			},
+		},
+		{
+			name:     "until as a date in the timezone",
+			rule:     "FREQ=DAILY;UNTIL=20240604",
+			timezone: "America/New_York",
+			start:    "2024-06-03T21:00:00",
+			n:        5,
+			want: []string{
+				"2024-06-03T21:00:00-04:00",
+				"2024-06-04T21:00:00-04:00",
+			},
+		},
+		{
+			name:     "last day of the month",
+			rule:     "FREQ=MONTHLY;BYMONTHDAY=-1",
+			timezone: "UTC",
+			start:    "2024-01-31T09:00:00",
+			n:        3,
+			want: []string{
+				"2024-01-31T09:00:00Z",
+				"2024-02-29T09:00:00Z",
+				"2024-03-31T09:00:00Z",
+			},
+		},
+		{
+			name:     "monthly skips months without the day",
+			rule:     "FREQ=MONTHLY",
+			timezone: "UTC",
+			start:    "2024-01-31T09:00:00",
+			n:        3,
+			want: []string{
+				"2024-01-31T09:00:00Z",
+				"2024-03-31T09:00:00Z",
+				"2024-05-31T09:00:00Z",
+			},
+		},
+		{
+			name:     "cron across the start of DST",
+			rule:     "0 9 * * *",
+			timezone: "America/New_York",
+			start:    "2024-03-09T00:00:00",
+			n:        3,
+			want: []string{
+				"2024-03-09T09:00:00-05:00",
+				"2024-03-10T09:00:00-04:00",
+				"2024-03-11T09:00:00-04:00",
+			},
+		},
+		{
+			name:     "cron in the DST gap",
+			rule:     "30 2 * * *",
+			timezone: "America/New_York",
+			start:    "2024-03-09T00:00:00",
+			n:        3,
+			want: []string{
+				"2024-03-09T02:30:00-05:00",
+				"2024-03-10T03:30:00-04:00",
+				"2024-03-11T02:30:00-04:00",
+			},
+		},
+		{
+			name:     "cron in the DST overlap",
+			rule:     "30 1 * * *",
+			timezone: "America/New_York",
+			start:    "2024-11-02T00:00:00",
+			n:        3,
+			want: []string{
+				"2024-11-02T01:30:00-04:00",
+				"2024-11-03T01:30:00-04:00",
+				"2024-11-04T01:30:00-05:00",
+			},
+		},
+		{
+			name:     "cron day of month or day of week",
+			rule:     "0 9 1 * MON",
+			timezone: "UTC",
+			start:    "2024-06-01T00:00:00",
+			n:        3,
+			want: []string{
+				"2024-06-01T09:00:00Z",
+				"2024-06-03T09:00:00Z",
+				"2024-06-10T09:00:00Z",
+			},
+		},
+		{
+			name:     "cron descriptor",
+			rule:     "@weekly",
+			timezone: "UTC",
+			start:    "2024-06-01T00:00:00",
+			n:        2,
+			want: []string{
+				"2024-06-02T00:00:00Z",
+				"2024-06-09T00:00:00Z",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		loc, err := LoadLocation(tt.timezone)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		start, err := time.ParseInLocation("2006-01-02T15:04:05", tt.start, loc)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		after := start.Add(-time.Nanosecond)
+		if tt.after != "" {
+			after, err = time.ParseInLocation("2006-01-02T15:04:05", tt.after, loc)
+			if err != nil {
+				t.Fatalf("%s: %v", tt.name, err)
+			}
+		}
+
+		rule, err := Parse(tt.rule, tt.timezone, start)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+
+		got := []string{}
+		for _, next := range Preview(rule, after, tt.n) {
+			got = append(got, next.In(loc).Format(time.RFC3339))
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		rule     string
+		timezone string
+	}{
+		{name: "empty", rule: " "},
+		{name: "unknown timezone", rule: "FREQ=DAILY", timezone: "Mars/Olympus"},
+		{name: "no frequency", rule: "BYDAY=MO;COUNT=2"},
+		{name: "unsupported frequency", rule: "FREQ=SECONDLY"},
+		{name: "count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20240605T090000Z"},
+		{name: "zero count", rule: "FREQ=DAILY;COUNT=0"},
+		{name: "zero interval", rule: "FREQ=DAILY;INTERVAL=0"},
+		{name: "invalid until", rule: "FREQ=DAILY;UNTIL=tomorrow"},
+		{name: "invalid weekday", rule: "FREQ=WEEKLY;BYDAY=XX"},
+		{name: "month day out of range", rule: "FREQ=MONTHLY;BYMONTHDAY=32"},
+		{name: "unsupported part", rule: "FREQ=DAILY;BYSETPOS=1"},
+		{name: "part without value", rule: "FREQ=DAILY;COUNT"},
+		{name: "cron with 4 fields", rule: "0 9 * *"},
+		{name: "cron minute out of range", rule: "60 9 * * *"},
+		{name: "cron hour out of range", rule: "0 24 * * *"},
+		{name: "cron unknown weekday", rule: "0 9 * * FOO"},
+		{name: "cron zero step", rule: "*/0 * * * *"},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.rule, tt.timezone, time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC))
+		if err == nil {
+			t.Errorf("%s: %q is valid, want an error", tt.name, tt.rule)
+			continue
+		}
+		if !errors.HasType(err, errors.TypeValidation) {
+			t.Errorf("%s: got %v, want a validation error", tt.name, err)
+		}
+	}
+}
@@ -0,0 +1,537 @@
+package recurrence
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+type frequency int
+
+const (
+	freqHourly frequency = iota
+	freqDaily
+	freqWeekly
+	freqMonthly
+	freqYearly
+)
+
+var (
+	frequencies = map[string]frequency{
+		"HOURLY":  freqHourly,
+		"DAILY":   freqDaily,
+		"WEEKLY":  freqWeekly,
+		"MONTHLY": freqMonthly,
+		"YEARLY":  freqYearly,
+	}
+
+	weekdays = map[string]time.Weekday{
+		"SU": time.Sunday,
+		"MO": time.Monday,
+		"TU": time.Tuesday,
+		"WE": time.Wednesday,
+		"TH": time.Thursday,
+		"FR": time.Friday,
+		"SA": time.Saturday,
+	}
+
+	// horizon is how far past the requested time occurrences are searched for, before a rule
+	// is considered to have no more occurrences (e.g. FREQ=YEARLY;BYMONTH=2;BYMONTHDAY=30)
+	horizon = time.Hour * 24 * 366 * 10
+)
+
+// weekdayNum is a BYDAY entry, e.g. "MO", "2TU" or "-1FR"
+type weekdayNum struct {
+	n       int
+	weekday time.Weekday
+}
+
+type rrule struct {
+	freq     frequency
+	interval int
+	count    int
+	until    time.Time
+
+	byMonth    []int
+	byMonthDay []int
+	byDay      []weekdayNum
+	byHour     []int
+	byMinute   []int
+	wkst       time.Weekday
+
+	start time.Time
+	loc   *time.Location
+
+	// mu guards last, rules can be shared
+	mu sync.Mutex
+	// last is where the last occurrence of a rule with a COUNT was found
+	last *cursor
+}
+
+// cursor is the position of an occurrence, the following occurrences of rules with a COUNT are
+// counted from there instead of from the start
+type cursor struct {
+	period  int
+	emitted int
+	prev    time.Time
+}
+
+// Next implements Rule
+func (r *rrule) Next(after time.Time) (time.Time, bool) {
+	period := r.firstPeriod(after)
+	emitted := 0
+	prev := time.Time{}
+	// COUNT needs every occurrence since start to be counted, which is resumed from the last
+	// occurrence found if it is not after the requested time, like when occurrences are listed
+	if r.count > 0 {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		period = 0
+		if r.last != nil && !r.last.prev.After(after) {
+			period, emitted, prev = r.last.period, r.last.emitted, r.last.prev
+		}
+	}
+
+	for ; ; period++ {
+		periodStart, candidates := r.expand(period)
+		if periodStart.Sub(after) > horizon {
+			return time.Time{}, false
+		}
+
+		for _, c := range candidates {
+			if c.Before(r.start) || (!prev.IsZero() && !c.After(prev)) {
+				continue
+			}
+			if !r.until.IsZero() && c.After(r.until) {
+				return time.Time{}, false
+			}
+
+			emitted++
+			if r.count > 0 && emitted > r.count {
+				return time.Time{}, false
+			}
+
+			prev = c
+			if c.After(after) {
+				if r.count > 0 {
+					r.last = &cursor{period: period, emitted: emitted, prev: c}
+				}
+				return c, true
+			}
+		}
+	}
+}
+
+// firstPeriod returns the index of a period which starts no later than after
+func (r *rrule) firstPeriod(after time.Time) int {
+	if !after.After(r.start) {
+		return 0
+	}
+
+	after = after.In(r.loc)
+	elapsed := 0
+	switch r.freq {
+	case freqHourly:
+		elapsed = int(after.Sub(r.start) / time.Hour)
+	case freqDaily:
+		elapsed = int(after.Sub(r.start) / (time.Hour * 24))
+	case freqWeekly:
+		elapsed = int(after.Sub(r.start) / (time.Hour * 24 * 7))
+	case freqMonthly:
+		elapsed = (after.Year()-r.start.Year())*12 + int(after.Month()-r.start.Month())
+	case freqYearly:
+		elapsed = after.Year() - r.start.Year()
+	}
+
+	// one period of slack absorbs DST shifts and partial periods
+	period := elapsed/r.interval - 1
+	if period < 0 {
+		return 0
+	}
+	return period
+}
+
+// expand returns the start of the period at index, along with all candidate occurrences in it
+func (r *rrule) expand(period int) (time.Time, []time.Time) {
+	sy, sm, sd := r.start.Date()
+	step := period * r.interval
+
+	switch r.freq {
+	case freqHourly:
+		instant := r.start.Add(time.Duration(step) * time.Hour)
+		y, m, d := instant.Date()
+		if !r.matchDay(y, m, d) || !contains(r.byHour, instant.Hour(), true) {
+			return instant, nil
+		}
+		return instant, r.times(y, m, d, []int{instant.Hour()})
+
+	case freqDaily:
+		day := time.Date(sy, sm, sd+step, 0, 0, 0, 0, r.loc)
+		y, m, d := day.Date()
+		if !r.matchDay(y, m, d) {
+			return day, nil
+		}
+		return day, r.times(y, m, d, nil)
+
+	case freqWeekly:
+		offset := (int(r.start.Weekday()) - int(r.wkst) + 7) % 7
+		weekStart := time.Date(sy, sm, sd-offset+step*7, 0, 0, 0, 0, r.loc)
+		list := []time.Time{}
+		for i := 0; i < 7; i++ {
+			day := weekStart.AddDate(0, 0, i)
+			y, m, d := day.Date()
+			if !contains(r.byMonth, int(m), true) {
+				continue
+			}
+			if !r.matchWeekday(day.Weekday(), r.start.Weekday()) {
+				continue
+			}
+			list = append(list, r.times(y, m, d, nil)...)
+		}
+		return weekStart, sortUnique(list)
+
+	case freqMonthly:
+		month := time.Date(sy, sm+time.Month(step), 1, 0, 0, 0, 0, r.loc)
+		if !contains(r.byMonth, int(month.Month()), true) {
+			return month, nil
+		}
+		list := []time.Time{}
+		for _, d := range r.monthDays(month.Year(), month.Month()) {
+			list = append(list, r.times(month.Year(), month.Month(), d, nil)...)
+		}
+		return month, sortUnique(list)
+
+	default:
+		year := sy + step
+		yearStart := time.Date(year, time.January, 1, 0, 0, 0, 0, r.loc)
+		list := []time.Time{}
+
+		if len(r.byDay) > 0 && len(r.byMonth) == 0 && len(r.byMonthDay) == 0 {
+			yearEnd := time.Date(year, time.December, 31, 0, 0, 0, 0, r.loc)
+			for _, day := range weekdaysIn(yearStart, yearEnd, r.byDay) {
+				y, m, d := day.Date()
+				list = append(list, r.times(y, m, d, nil)...)
+			}
+			return yearStart, sortUnique(list)
+		}
+
+		months := r.byMonth
+		if len(months) == 0 {
+			months = []int{int(sm)}
+			if len(r.byMonthDay) > 0 || len(r.byDay) > 0 {
+				months = []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+			}
+		}
+		for _, m := range months {
+			for _, d := range r.monthDays(year, time.Month(m)) {
+				list = append(list, r.times(year, time.Month(m), d, nil)...)
+			}
+		}
+		return yearStart, sortUnique(list)
+	}
+}
+
+// monthDays returns the days of the month matching BYMONTHDAY and BYDAY, defaulting to the
+// day of the month of start
+func (r *rrule) monthDays(year int, month time.Month) []int {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, r.loc)
+	last := first.AddDate(0, 1, -1)
+	days := []int{}
+
+	switch {
+	case len(r.byMonthDay) > 0:
+		for _, md := range r.byMonthDay {
+			d := md
+			if md < 0 {
+				d = last.Day() + md + 1
+			}
+			if d < 1 || d > last.Day() {
+				continue
+			}
+			day := time.Date(year, month, d, 0, 0, 0, 0, r.loc)
+			if len(r.byDay) > 0 && !r.matchWeekday(day.Weekday(), day.Weekday()) {
+				continue
+			}
+			days = append(days, d)
+		}
+
+	case len(r.byDay) > 0:
+		for _, day := range weekdaysIn(first, last, r.byDay) {
+			days = append(days, day.Day())
+		}
+
+	default:
+		if r.start.Day() <= last.Day() {
+			days = append(days, r.start.Day())
+		}
+	}
+
+	return days
+}
+
+// times returns the occurrences on the given day, for each BYHOUR and BYMINUTE, defaulting to
+// the time of day of start
+func (r *rrule) times(year int, month time.Month, day int, hours []int) []time.Time {
+	if len(hours) == 0 {
+		hours = r.byHour
+	}
+	if len(hours) == 0 {
+		hours = []int{r.start.Hour()}
+	}
+
+	minutes := r.byMinute
+	if len(minutes) == 0 {
+		minutes = []int{r.start.Minute()}
+	}
+
+	list := make([]time.Time, 0, len(hours)*len(minutes))
+	for _, h := range hours {
+		for _, m := range minutes {
+			list = append(list, wallClock(year, month, day, h, m, r.start.Second(), r.loc))
+		}
+	}
+	return list
+}
+
+// matchDay reports whether the day satisfies BYMONTH, BYMONTHDAY and BYDAY. It is used for
+// HOURLY and DAILY rules, where these parts limit rather than expand the occurrences
+func (r *rrule) matchDay(year int, month time.Month, day int) bool {
+	if !contains(r.byMonth, int(month), true) {
+		return false
+	}
+
+	date := time.Date(year, month, day, 0, 0, 0, 0, r.loc)
+	if len(r.byMonthDay) > 0 {
+		last := time.Date(year, month+1, 0, 0, 0, 0, 0, r.loc).Day()
+		found := false
+		for _, md := range r.byMonthDay {
+			if md == day || last+md+1 == day {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(r.byDay) > 0 && !r.matchWeekday(date.Weekday(), date.Weekday()) {
+		return false
+	}
+
+	return true
+}
+
+// matchWeekday reports whether wd is one of the BYDAY weekdays, ignoring ordinals. If there
+// are no BYDAY entries, wd must be the same as fallback
+func (r *rrule) matchWeekday(wd time.Weekday, fallback time.Weekday) bool {
+	if len(r.byDay) == 0 {
+		return wd == fallback
+	}
+
+	for _, bd := range r.byDay {
+		if bd.weekday == wd {
+			return true
+		}
+	}
+	return false
+}
+
+// weekdaysIn returns the days between first and last (inclusive) matching the BYDAY entries.
+// Ordinals are relative to the range, e.g. "-1FR" is the last Friday in the range
+func weekdaysIn(first time.Time, last time.Time, byDay []weekdayNum) []time.Time {
+	list := []time.Time{}
+	for _, bd := range byDay {
+		matches := []time.Time{}
+		for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+			if day.Weekday() == bd.weekday {
+				matches = append(matches, day)
+			}
+		}
+
+		switch {
+		case bd.n == 0:
+			list = append(list, matches...)
+		case bd.n > 0 && bd.n <= len(matches):
+			list = append(list, matches[bd.n-1])
+		case bd.n < 0 && -bd.n <= len(matches):
+			list = append(list, matches[len(matches)+bd.n])
+		}
+	}
+	return list
+}
+
+// contains reports whether v is in list. An empty list contains everything if emptyMatches is true
+func contains(list []int, v int, emptyMatches bool) bool {
+	if len(list) == 0 {
+		return emptyMatches
+	}
+
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
+func parseRRule(expr string, loc *time.Location, start time.Time) (*rrule, error) {
+	expr = strings.TrimSpace(expr)
+	if strings.HasPrefix(strings.ToUpper(expr), "RRULE:") {
+		expr = expr[len("RRULE:"):]
+	}
+
+	r := &rrule{
+		interval: 1,
+		wkst:     time.Monday,
+		start:    start,
+		loc:      loc,
+	}
+	hasFreq := false
+
+	for _, part := range strings.Split(expr, ";") {
+		if strings.TrimSpace(part) == "" {
+			continue
+		}
+
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, errors.Validationf("invalid RRULE part '%s'", part)
+		}
+		key := strings.ToUpper(strings.TrimSpace(kv[0]))
+		value := strings.ToUpper(strings.TrimSpace(kv[1]))
+
+		var err error
+		switch key {
+		case "FREQ":
+			freq, ok := frequencies[value]
+			if !ok {
+				return nil, errors.Validationf("unsupported RRULE frequency '%s'", value)
+			}
+			r.freq = freq
+			hasFreq = true
+		case "INTERVAL":
+			r.interval, err = parseInt(value, 1, 1000)
+		case "COUNT":
+			r.count, err = parseInt(value, 1, 100000)
+		case "UNTIL":
+			r.until, err = parseUntil(value, loc)
+		case "BYMONTH":
+			r.byMonth, err = parseIntList(value, 1, 12, false)
+		case "BYMONTHDAY":
+			r.byMonthDay, err = parseIntList(value, 1, 31, true)
+		case "BYHOUR":
+			r.byHour, err = parseIntList(value, 0, 23, false)
+		case "BYMINUTE":
+			r.byMinute, err = parseIntList(value, 0, 59, false)
+		case "BYDAY":
+			r.byDay, err = parseByDay(value)
+		case "WKST":
+			wd, ok := weekdays[value]
+			if !ok {
+				return nil, errors.Validationf("invalid RRULE WKST '%s'", value)
+			}
+			r.wkst = wd
+		default:
+			return nil, errors.Validationf("unsupported RRULE part '%s'", key)
+		}
+
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "invalid RRULE %s '%s'", key, value)
+		}
+	}
+
+	if !hasFreq {
+		return nil, errors.Validation("RRULE must have a FREQ")
+	}
+
+	if r.count > 0 && !r.until.IsZero() {
+		return nil, errors.Validation("RRULE cannot have both COUNT and UNTIL")
+	}
+
+	return r, nil
+}
+
+func parseInt(value string, min int, max int) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, err
+	}
+
+	if n < min || n > max {
+		return 0, errors.Newf("%d is out of range [%d, %d]", n, min, max)
+	}
+
+	return n, nil
+}
+
+// parseIntList parses a comma separated list of integers within [min, max]. If negative is
+// true, values within [-max, -min] are also accepted
+func parseIntList(value string, min int, max int, negative bool) ([]int, error) {
+	list := []int{}
+	for _, item := range strings.Split(value, ",") {
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			return nil, err
+		}
+
+		inRange := n >= min && n <= max
+		if negative && n < 0 {
+			inRange = -n >= min && -n <= max
+		}
+		if !inRange {
+			return nil, errors.Newf("%d is out of range", n)
+		}
+
+		list = append(list, n)
+	}
+	return list, nil
+}
+
+func parseByDay(value string) ([]weekdayNum, error) {
+	list := []weekdayNum{}
+	for _, item := range strings.Split(value, ",") {
+		if len(item) < 2 {
+			return nil, errors.Newf("invalid weekday '%s'", item)
+		}
+
+		wd, ok := weekdays[item[len(item)-2:]]
+		if !ok {
+			return nil, errors.Newf("invalid weekday '%s'", item)
+		}
+
+		bd := weekdayNum{weekday: wd}
+		if ordinal := item[:len(item)-2]; ordinal != "" {
+			n, err := strconv.Atoi(ordinal)
+			if err != nil || n == 0 || n < -53 || n > 53 {
+				return nil, errors.Newf("invalid weekday ordinal '%s'", item)
+			}
+			bd.n = n
+		}
+
+		list = append(list, bd)
+	}
+	return list, nil
+}
+
+// parseUntil parses UNTIL as a UTC date-time, a local (floating) date-time or a date. A date
+// includes the whole day
+func parseUntil(value string, loc *time.Location) (time.Time, error) {
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, nil
+	}
+
+	t, err := time.ParseInLocation("20060102", value, loc)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return t.AddDate(0, 0, 1).Add(-time.Second), nil
+}
@@ -0,0 +1,360 @@
+package reminders
+
+import (
+	"context"
+	"encoding/json"
+	"html"
+	"sort"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	KindUpcoming = "upcoming"
+	KindOverdue  = "overdue"
+
+	// MaxOffset is the longest time before the due time at which a reminder can be sent
+	MaxOffset = time.Hour * 24 * 30
+	// overdueWindow is how long after the due time an overdue reminder is still sent, so old
+	// tasks do not all send reminders at once when the subsystem is first enabled
+	overdueWindow = time.Hour * 24 * 7
+	// scanLimit is the maximum number of tasks loaded at once, a run pages through all of them
+	scanLimit = 1000
+)
+
+// Config holds the configuration of the reminder worker
+type Config struct {
+	Interval time.Duration
+	// Offsets are how long before the due time reminders are sent, unless a task overrides them
+	Offsets []time.Duration
+}
+
+// Offset is a duration which is represented in JSON as a string, e.g. "24h" or "90m"
+type Offset time.Duration
+
+func (o Offset) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(o).String())
+}
+
+func (o *Offset) UnmarshalJSON(b []byte) error {
+	str := ""
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	d, err := time.ParseDuration(strings.TrimSpace(str))
+	if err != nil {
+		return errors.ValidationErrf(err, "invalid reminder offset '%s'", str)
+	}
+	*o = Offset(d)
+	return nil
+}
+
+// Override replaces the default reminder offsets for a single task. An override without any
+// offsets disables reminders for the task
+type Override struct {
+	TID     int64    `json:"tid,omitempty"`
+	Offsets []Offset `json:"offsets"`
+	// Default is true if the task has no override, and Offsets are the default ones
+	Default   bool       `json:"default"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (o *Override) Validate() error {
+	for _, offset := range o.Offsets {
+		if offset <= 0 || time.Duration(offset) > MaxOffset {
+			return errors.Validationf("reminder offsets should be between 0 and %s", MaxOffset)
+		}
+	}
+	return nil
+}
+
+func (o *Override) durations() []time.Duration {
+	list := make([]time.Duration, 0, len(o.Offsets))
+	for _, offset := range o.Offsets {
+		list = append(list, time.Duration(offset))
+	}
+	return list
+}
+
+type Reminders struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+	users      *users.Users
+	mailer     *emailService.Mailer
+	cfg        *Config
+}
+
+// Run sends all reminders which are due. It is meant to be run periodically, and every reminder is
+// recorded before it is sent, so a reminder is never sent twice even across restarts
+func (rs *Reminders) Run(ctx context.Context) error {
+	now := time.Now()
+
+	maxOffset, err := rs.store.MaxOverrideOffset(ctx)
+	if err != nil {
+		return err
+	}
+	for _, offset := range rs.cfg.Offsets {
+		if offset > maxOffset {
+			maxOffset = offset
+		}
+	}
+
+	// tasks whose reminders were already sent are still loaded, so the whole window is paged
+	// through rather than stopping at the first scanLimit tasks
+	from, afterTID, to := now.Add(-overdueWindow), int64(0), now.Add(maxOffset)
+	for {
+		due, err := rs.tasks.DueBetween(ctx, from, afterTID, to, scanLimit)
+		if err != nil {
+			return err
+		}
+
+		err = rs.remindAll(ctx, due, now)
+		if err != nil {
+			return err
+		}
+
+		if uint64(len(due)) < scanLimit {
+			return nil
+		}
+		last := due[len(due)-1]
+		from, afterTID = last.CompleteBy, last.TID
+	}
+}
+
+// remindAll sends the reminders of the tasks which are due
+func (rs *Reminders) remindAll(ctx context.Context, due []tasks.Task, now time.Time) error {
+	tids := make([]int64, 0, len(due))
+	pids := []int64{}
+	for _, t := range due {
+		tids = append(tids, t.TID)
+		if t.ProjectID != 0 {
+			pids = append(pids, t.ProjectID)
+		}
+	}
+
+	overrides, err := rs.store.GetOverrides(ctx, tids)
+	if err != nil {
+		return err
+	}
+
+	projectOffsets, err := rs.store.GetProjectOffsets(ctx, pids)
+	if err != nil {
+		return err
+	}
+
+	// a task's own override comes first, then the defaults of its project, then the global ones
+	for idx := range due {
+		offsets := rs.cfg.Offsets
+		if override, ok := overrides[due[idx].TID]; ok {
+			offsets = override.durations()
+		} else if po, ok := projectOffsets[due[idx].ProjectID]; ok {
+			offsets = po
+		}
+
+		err = rs.remind(ctx, &due[idx], offsets, now)
+		if err != nil {
+			rs.logHandler.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// remind sends a reminder for the task if one is due. If several offsets are due at once (e.g. a
+// task created an hour before its due time), only a single reminder is sent for all of them
+func (rs *Reminders) remind(ctx context.Context, t *tasks.Task, offsets []time.Duration, now time.Time) error {
+	if len(offsets) == 0 {
+		return nil
+	}
+
+	kind := KindUpcoming
+	pending := []time.Duration{}
+	if !now.Before(t.CompleteBy) {
+		kind = KindOverdue
+		pending = append(pending, 0)
+	} else {
+		for _, offset := range offsets {
+			if !now.Before(t.CompleteBy.Add(-offset)) {
+				pending = append(pending, offset)
+			}
+		}
+	}
+
+	claimed := []time.Duration{}
+	for _, offset := range pending {
+		ok, err := rs.store.Claim(ctx, t.TID, kind, offset, t.CompleteBy, now)
+		if err != nil {
+			return err
+		}
+		if ok {
+			claimed = append(claimed, offset)
+		}
+	}
+
+	if len(claimed) == 0 {
+		return nil
+	}
+
+	err := rs.send(ctx, t, kind, now)
+	if err != nil {
+		// the claims are released so the reminder is retried on the next run
+		for _, offset := range claimed {
+			rerr := rs.store.Release(ctx, t.TID, kind, offset, t.CompleteBy)
+			if rerr != nil {
+				rs.logHandler.Error(rerr)
+			}
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (rs *Reminders) send(ctx context.Context, t *tasks.Task, kind string, now time.Time) error {
+	to, loc, err := rs.recipient(ctx, t)
+	if err != nil {
+		return err
+	}
+
+	email := new(emailService.Email)
+	email.To = to
+	due := t.FormatDue(loc)
+	if kind == KindOverdue {
+		email.Subject = "Task overdue"
+		email.HtmlContent = `<p>Hello</p>
+		<p>The following task was due on ` + due + ` and is not complete yet.</p>
+		<p>` + html.EscapeString(t.Detail) + `</p>`
+	} else {
+		email.Subject = "Task due in " + humanize(t.CompleteBy.Sub(now))
+		email.HtmlContent = `<p>Hello</p>
+		<p>This is a reminder that the following task is due on ` + due + `.</p>
+		<p>` + html.EscapeString(t.Detail) + `</p>`
+	}
+
+	return rs.mailer.SendEmail(ctx, *email)
+}
+
+// recipient returns the email address of the task's assignee, or of its owner if it is not
+// assigned, and the timezone dates are shown in. Assignees who are not registered get the timezone
+// of the task
+func (rs *Reminders) recipient(ctx context.Context, t *tasks.Task) (string, *time.Location, error) {
+	if t.AssignedTo != "" {
+		u, err := rs.users.GetUserByEmail(ctx, t.AssignedTo)
+		if err != nil {
+			return t.AssignedTo, t.Location(), nil
+		}
+		return t.AssignedTo, u.Location(), nil
+	}
+
+	u, err := rs.users.GetUserByID(ctx, t.UID)
+	if err != nil {
+		return "", nil, err
+	}
+	return u.Email, u.Location(), nil
+}
+
+// Override returns the reminder offsets of a task, which are the default ones if it has no override
+func (rs *Reminders) Override(ctx context.Context, tid int64) (*Override, error) {
+	o, err := rs.store.GetOverride(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	if o == nil {
+		o = &Override{
+			TID:     tid,
+			Offsets: make([]Offset, 0, len(rs.cfg.Offsets)),
+			Default: true,
+		}
+		for _, offset := range rs.cfg.Offsets {
+			o.Offsets = append(o.Offsets, Offset(offset))
+		}
+	}
+
+	return o, nil
+}
+
+func (rs *Reminders) SaveOverride(ctx context.Context, tid int64, o *Override) (*Override, error) {
+	err := o.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(o.Offsets, func(i, j int) bool {
+		return o.Offsets[i] > o.Offsets[j]
+	})
+
+	now := time.Now()
+	o.TID = tid
+	o.Default = false
+	o.UpdatedAt = &now
+	err = rs.store.SaveOverride(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+func (rs *Reminders) DeleteOverride(ctx context.Context, tid int64) error {
+	return rs.store.DeleteOverride(ctx, tid)
+}
+
+// humanize formats a duration rounded to minutes, e.g. "23h59m"
+func humanize(d time.Duration) string {
+	str := d.Round(time.Minute).String()
+	return strings.TrimSuffix(str, "0s")
+}
+
+// ParseOffsets parses a comma separated list of durations, e.g. "24h,1h"
+func ParseOffsets(str string) ([]time.Duration, error) {
+	list := []time.Duration{}
+	for _, item := range strings.Split(str, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		d, err := time.ParseDuration(item)
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "invalid reminder offset '%s'", item)
+		}
+		list = append(list, d)
+	}
+	return list, nil
+}
+
+// NewService initializes the Reminders struct with all its dependencies and returns a new instance
+func NewService(
+	l logger.Logger,
+	pqdriver *pgxpool.Pool,
+	ts *tasks.Tasks,
+	us *users.Users,
+	es *emailService.Mailer,
+	cfg *Config,
+) (*Reminders, error) {
+	rstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Reminders{
+		logHandler: l,
+		store:      rstore,
+		tasks:      ts,
+		users:      us,
+		mailer:     es,
+		cfg:        cfg,
+	}, nil
+}
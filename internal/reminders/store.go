@@ -0,0 +1,247 @@
+package reminders
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Claim(ctx context.Context, tid int64, kind string, offset time.Duration, dueAt time.Time, sentAt time.Time) (bool, error)
+	Release(ctx context.Context, tid int64, kind string, offset time.Duration, dueAt time.Time) error
+	GetOverride(ctx context.Context, tid int64) (*Override, error)
+	GetOverrides(ctx context.Context, tids []int64) (map[int64]*Override, error)
+	SaveOverride(ctx context.Context, o *Override) error
+	DeleteOverride(ctx context.Context, tid int64) error
+	MaxOverrideOffset(ctx context.Context) (time.Duration, error)
+	GetProjectOffsets(ctx context.Context, pids []int64) (map[int64][]time.Duration, error)
+}
+
+type reminderStore struct {
+	qbuilder       squirrel.StatementBuilderType
+	pqdriver       *pgxpool.Pool
+	tableName      string
+	overridesTable string
+	projectsTable  string
+}
+
+// Claim records a reminder as sent. It returns false if the reminder was already recorded. The due
+// time is part of the key, so rescheduling a task makes its reminders due again
+func (rs *reminderStore) Claim(ctx context.Context, tid int64, kind string, offset time.Duration, dueAt time.Time, sentAt time.Time) (bool, error) {
+	query, args, err := rs.qbuilder.Insert(rs.tableName).SetMap(map[string]interface{}{
+		"tid":           tid,
+		"kind":          kind,
+		"offsetSeconds": int64(offset / time.Second),
+		"dueAt":         dueAt,
+		"sentAt":        sentAt,
+	}).Suffix("ON CONFLICT DO NOTHING").ToSql()
+	if err != nil {
+		return false, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := rs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return false, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (rs *reminderStore) Release(ctx context.Context, tid int64, kind string, offset time.Duration, dueAt time.Time) error {
+	query, args, err := rs.qbuilder.Delete(rs.tableName).Where(squirrel.Eq{
+		"tid":           tid,
+		"kind":          kind,
+		"offsetSeconds": int64(offset / time.Second),
+		"dueAt":         dueAt,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = rs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (rs *reminderStore) GetOverride(ctx context.Context, tid int64) (*Override, error) {
+	overrides, err := rs.GetOverrides(ctx, []int64{tid})
+	if err != nil {
+		return nil, err
+	}
+
+	return overrides[tid], nil
+}
+
+func (rs *reminderStore) GetOverrides(ctx context.Context, tids []int64) (map[int64]*Override, error) {
+	overrides := make(map[int64]*Override, len(tids))
+	if len(tids) == 0 {
+		return overrides, nil
+	}
+
+	query, args, err := rs.qbuilder.Select(
+		"tid",
+		"offsets",
+		"updatedAt",
+	).From(
+		rs.overridesTable,
+	).Where(
+		squirrel.Eq{
+			"tid": tids,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := rs.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		o := new(Override)
+		seconds := []int64{}
+		err = rows.Scan(&o.TID, &seconds, &o.UpdatedAt)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+
+		o.Offsets = make([]Offset, 0, len(seconds))
+		for _, s := range seconds {
+			o.Offsets = append(o.Offsets, Offset(time.Duration(s)*time.Second))
+		}
+		overrides[o.TID] = o
+	}
+
+	return overrides, nil
+}
+
+func (rs *reminderStore) SaveOverride(ctx context.Context, o *Override) error {
+	seconds := make([]int64, 0, len(o.Offsets))
+	for _, offset := range o.Offsets {
+		seconds = append(seconds, int64(time.Duration(offset)/time.Second))
+	}
+
+	query, args, err := rs.qbuilder.Insert(rs.overridesTable).SetMap(map[string]interface{}{
+		"tid":       o.TID,
+		"offsets":   seconds,
+		"updatedAt": o.UpdatedAt,
+	}).Suffix(
+		"ON CONFLICT (tid) DO UPDATE SET offsets = EXCLUDED.offsets, updatedAt = EXCLUDED.updatedAt",
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = rs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (rs *reminderStore) DeleteOverride(ctx context.Context, tid int64) error {
+	query, args, err := rs.qbuilder.Delete(rs.overridesTable).Where(squirrel.Eq{
+		"tid": tid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = rs.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// MaxOverrideOffset returns the longest offset of all overrides and project defaults, so the worker
+// knows how far ahead it should look for tasks
+func (rs *reminderStore) MaxOverrideOffset(ctx context.Context) (time.Duration, error) {
+	query := `SELECT COALESCE(MAX(o), 0) FROM (
+		SELECT unnest(offsets) AS o FROM ` + rs.overridesTable + `
+		UNION ALL
+		SELECT unnest(reminderOffsets) AS o FROM ` + rs.projectsTable + ` WHERE archivedAt IS NULL
+	) AS offsets`
+	seconds := int64(0)
+	err := rs.pqdriver.QueryRow(ctx, query).Scan(&seconds)
+	if err != nil && err != pgx.ErrNoRows {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return time.Duration(seconds) * time.Second, nil
+}
+
+// GetProjectOffsets returns the default reminder offsets of the projects which have them. Archived
+// projects have no offsets, so no reminders are sent for their tasks
+func (rs *reminderStore) GetProjectOffsets(ctx context.Context, pids []int64) (map[int64][]time.Duration, error) {
+	offsets := make(map[int64][]time.Duration, len(pids))
+	if len(pids) == 0 {
+		return offsets, nil
+	}
+
+	query, args, err := rs.qbuilder.Select(
+		"id",
+		"CASE WHEN archivedAt IS NULL THEN reminderOffsets ELSE '{}' END",
+	).From(
+		rs.projectsTable,
+	).Where(
+		squirrel.And{
+			squirrel.Eq{"id": pids},
+			squirrel.Or{
+				squirrel.NotEq{"reminderOffsets": nil},
+				squirrel.NotEq{"archivedAt": nil},
+			},
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := rs.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var pid int64
+		seconds := []int64{}
+		err = rows.Scan(&pid, &seconds)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+
+		list := make([]time.Duration, 0, len(seconds))
+		for _, s := range seconds {
+			list = append(list, time.Duration(s)*time.Second)
+		}
+		offsets[pid] = list
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return offsets, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*reminderStore, error) {
+	return &reminderStore{
+		pqdriver:       pqdriver,
+		qbuilder:       squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName:      "task_reminders",
+		overridesTable: "task_reminder_overrides",
+		projectsTable:  "projects",
+	}, nil
+}
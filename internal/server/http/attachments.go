@@ -0,0 +1,150 @@
+package http
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+// UploadAttachment streams the "file" part of a multipart request to the blob store, without
+// buffering the whole file in memory
+func (h *Handlers) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "multipart/form-data body expected"))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			errResponder(w, errors.InputBodyErr(err, "invalid multipart body"))
+			return
+		}
+
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+
+		a, err := h.api.UploadAttachment(r.Context(), tid, uid, part.FileName(), part)
+		part.Close()
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+
+		jsonResponder(w, http.StatusCreated, a)
+		return
+	}
+
+	errResponder(w, errors.InputBody("file is required"))
+}
+
+func (h *Handlers) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	aid, err := paramInt64(r, "aid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	a, content, err := h.api.DownloadAttachment(r.Context(), tid, aid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+	defer content.Close()
+
+	w.Header().Set("Content-Type", a.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if sum, err := hex.DecodeString(a.Checksum); err == nil {
+		w.Header().Set("Digest", "sha-256="+base64.StdEncoding.EncodeToString(sum))
+	}
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, content)
+}
+
+func (h *Handlers) GetAttachments(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetAttachments(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+func (h *Handlers) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	aid, err := paramInt64(r, "aid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.DeleteAttachment(r.Context(), tid, aid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
@@ -0,0 +1,78 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+type bulkResult struct {
+	Index  int         `json:"index"`
+	Op     string      `json:"op"`
+	TID    int64       `json:"tid,omitempty"`
+	Status int         `json:"status"`
+	Error  string      `json:"error,omitempty"`
+	Task   *tasks.Task `json:"task,omitempty"`
+}
+
+// BulkTasks applies a batch of create, edit and delete operations. The response is always 200 if
+// the request was well formed, with the status and error of each operation
+func (h *Handlers) BulkTasks(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		Mode       string            `json:"mode"`
+		Operations []tasks.Operation `json:"operations"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	results, committed, err := h.api.BulkTasks(r.Context(), payload.Mode, payload.Operations, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, map[string]interface{}{
+		"committed": committed,
+		"results":   bulkResults(results),
+	})
+}
+
+// bulkResults returns the response of each operation, with the status its error would have on its own
+func bulkResults(results []tasks.OperationResult) []bulkResult {
+	list := make([]bulkResult, 0, len(results))
+	for i, result := range results {
+		item := bulkResult{
+			Index:  i,
+			Op:     result.Op,
+			TID:    result.TID,
+			Status: http.StatusOK,
+			Task:   result.Task,
+		}
+		if result.Op == tasks.OpCreate {
+			item.Status = http.StatusCreated
+		}
+
+		if result.Err != nil {
+			item.Task = nil
+			item.Status, item.Error = errStatus(result.Err)
+			if result.Err == tasks.ErrRolledBack {
+				item.Status = http.StatusFailedDependency
+			}
+		}
+		list = append(list, item)
+	}
+
+	return list
+}
@@ -0,0 +1,48 @@
+package http
+
+import (
+	"net/http"
+	"testing"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+func TestBulkResults(t *testing.T) {
+	results := []tasks.OperationResult{
+		{Op: tasks.OpCreate, TID: 1, Task: &tasks.Task{TID: 1}},
+		{Op: tasks.OpEdit, TID: 2, Err: tasks.ErrVersionMismatch},
+		{Op: tasks.OpDelete, TID: 3, Err: tasks.ErrVersionMismatch},
+		{Op: tasks.OpEdit, TID: 4, Err: errors.NotFound("task not found")},
+		{Op: tasks.OpDelete, TID: 5, Err: tasks.ErrRolledBack},
+		{Op: tasks.OpEdit, TID: 6, Task: &tasks.Task{TID: 6}},
+	}
+	want := []int{
+		http.StatusCreated,
+		http.StatusPreconditionFailed,
+		http.StatusPreconditionFailed,
+		http.StatusNotFound,
+		http.StatusFailedDependency,
+		http.StatusOK,
+	}
+
+	list := bulkResults(results)
+	if len(list) != len(want) {
+		t.Fatalf("got %d results, want %d", len(list), len(want))
+	}
+	for i, item := range list {
+		if item.Index != i || item.TID != results[i].TID {
+			t.Errorf("result %d: got index %d and tid %d", i, item.Index, item.TID)
+		}
+		if item.Status != want[i] {
+			t.Errorf("result %d: got status %d, want %d", i, item.Status, want[i])
+		}
+		if results[i].Err != nil && (item.Error == "" || item.Task != nil) {
+			t.Errorf("result %d: got error %q and task %v", i, item.Error, item.Task)
+		}
+	}
+	if list[1].Error != tasks.ErrVersionMismatch.Error() {
+		t.Errorf("got error %q, want %q", list[1].Error, tasks.ErrVersionMismatch.Error())
+	}
+}
@@ -0,0 +1,578 @@
+package http
+
+import (
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"task-scheduler/internal/caldav"
+	"task-scheduler/internal/ical"
+	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/users"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+const (
+	davNS       = "DAV:"
+	caldavNS    = "urn:ietf:params:xml:ns:caldav"
+	calserverNS = "http://calendarserver.org/ns/"
+
+	// caldavRoot is where CalDAV clients are sent by /.well-known/caldav, every user has a single
+	// calendar with all their tasks
+	caldavRoot       = "/caldav/"
+	principalPath    = caldavRoot + "principal/"
+	calendarHomePath = caldavRoot + "calendars/"
+	collectionPath   = calendarHomePath + "tasks/"
+
+	davMethods        = "OPTIONS, GET, HEAD, PUT, DELETE, PROPFIND, REPORT"
+	maxDAVRequestSize = 1 << 20
+)
+
+var davPrefixes = map[string]string{
+	davNS:       "d",
+	caldavNS:    "c",
+	calserverNS: "cs",
+}
+
+// davProps are the properties of a resource, as XML, by their name
+type davProps map[xml.Name]string
+
+// davRequest is the body of a PROPFIND or a REPORT
+type davRequest struct {
+	// Root is the name of the root element, i.e. the name of the report
+	Root xml.Name
+	// Props are the requested properties, all of them are returned if AllProp is set
+	Props   []xml.Name
+	AllProp bool
+	// Hrefs are the resources requested by a calendar-multiget
+	Hrefs []string
+	// Components are the names in the comp-filters of a calendar-query
+	Components []string
+}
+
+// todosOnly returns false if a calendar-query filters by components other than VTODO, since
+// there are no other components in the calendar
+func (dr *davRequest) todosOnly() bool {
+	for _, name := range dr.Components {
+		name = strings.ToUpper(name)
+		if name != "VCALENDAR" && name != tasks.ComponentTodo {
+			return false
+		}
+	}
+	return true
+}
+
+func parseDAVRequest(r io.Reader) (*davRequest, error) {
+	dr := &davRequest{}
+	dec := xml.NewDecoder(io.LimitReader(r, maxDAVRequestSize))
+
+	depth, propDepth := 0, 0
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.InputBodyErr(err, "Invalid XML provided")
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			depth++
+			switch {
+			case depth == 1:
+				dr.Root = t.Name
+			case propDepth > 0:
+				if depth == propDepth+1 {
+					dr.Props = append(dr.Props, t.Name)
+				}
+			case t.Name.Space == davNS && t.Name.Local == "prop":
+				propDepth = depth
+			case t.Name.Space == davNS && (t.Name.Local == "allprop" || t.Name.Local == "propname"):
+				dr.AllProp = true
+			case t.Name.Space == davNS && t.Name.Local == "href":
+				href := ""
+				err = dec.DecodeElement(&href, &t)
+				if err != nil {
+					return nil, errors.InputBodyErr(err, "Invalid XML provided")
+				}
+				dr.Hrefs = append(dr.Hrefs, strings.TrimSpace(href))
+				depth--
+			case t.Name.Space == caldavNS && t.Name.Local == "comp-filter":
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						dr.Components = append(dr.Components, attr.Value)
+					}
+				}
+			}
+		case xml.EndElement:
+			if depth == propDepth {
+				propDepth = 0
+			}
+			depth--
+		}
+	}
+
+	// an empty PROPFIND asks for all the properties
+	if dr.Root.Local == "" || (len(dr.Props) == 0 && !dr.AllProp) {
+		dr.AllProp = true
+	}
+
+	return dr, nil
+}
+
+func xmlText(s string) string {
+	b := strings.Builder{}
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+func davHref(p string) string {
+	return xmlText((&url.URL{Path: p}).EscapedPath())
+}
+
+// davHrefName returns the name of the resource of the tasks collection the href points to
+func davHrefName(href string) (string, bool) {
+	u, err := url.Parse(href)
+	if err != nil || !strings.HasPrefix(u.Path, collectionPath) {
+		return "", false
+	}
+
+	name := strings.TrimPrefix(u.Path, collectionPath)
+	return name, name != "" && !strings.Contains(name, "/")
+}
+
+func writeDAVElement(b *strings.Builder, name xml.Name, inner string) {
+	tag, attr := name.Local, ""
+	if prefix, ok := davPrefixes[name.Space]; ok {
+		tag = prefix + ":" + name.Local
+	} else if name.Space != "" {
+		tag = "x:" + name.Local
+		attr = ` xmlns:x="` + xmlText(name.Space) + `"`
+	}
+
+	if inner == "" {
+		b.WriteString("<" + tag + attr + "/>")
+		return
+	}
+	b.WriteString("<" + tag + attr + ">" + inner + "</" + tag + ">")
+}
+
+// multistatus is a 207 response with the properties of one or more resources
+type multistatus struct {
+	b strings.Builder
+}
+
+func newMultistatus() *multistatus {
+	ms := &multistatus{}
+	ms.b.WriteString(`<?xml version="1.0" encoding="utf-8"?>`)
+	ms.b.WriteString(`<d:multistatus xmlns:d="DAV:" xmlns:c="` + caldavNS + `" xmlns:cs="` + calserverNS + `">`)
+	return ms
+}
+
+// response adds the requested properties of a resource, the ones it does not have are listed
+// as not found
+func (ms *multistatus) response(p string, props davProps, req *davRequest) {
+	names := req.Props
+	if req.AllProp {
+		names = make([]xml.Name, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Slice(names, func(i, j int) bool {
+			return names[i].Space+names[i].Local < names[j].Space+names[j].Local
+		})
+	}
+
+	found, missing := strings.Builder{}, strings.Builder{}
+	for _, name := range names {
+		value, ok := props[name]
+		if !ok {
+			writeDAVElement(&missing, name, "")
+			continue
+		}
+		writeDAVElement(&found, name, value)
+	}
+
+	ms.b.WriteString("<d:response><d:href>" + davHref(p) + "</d:href>")
+	if found.Len() > 0 {
+		ms.b.WriteString("<d:propstat><d:prop>" + found.String() + "</d:prop><d:status>HTTP/1.1 200 OK</d:status></d:propstat>")
+	}
+	if missing.Len() > 0 {
+		ms.b.WriteString("<d:propstat><d:prop>" + missing.String() + "</d:prop><d:status>HTTP/1.1 404 Not Found</d:status></d:propstat>")
+	}
+	ms.b.WriteString("</d:response>")
+}
+
+func (ms *multistatus) notFound(href string) {
+	ms.b.WriteString("<d:response><d:href>" + xmlText(href) + "</d:href><d:status>HTTP/1.1 404 Not Found</d:status></d:response>")
+}
+
+func (ms *multistatus) send(w http.ResponseWriter) {
+	ms.b.WriteString("</d:multistatus>")
+	w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+	w.WriteHeader(http.StatusMultiStatus)
+	w.Write([]byte(ms.b.String()))
+}
+
+// principalProps are the properties clients use to discover the calendars of the user
+func principalProps(u *users.User, resourceType string) davProps {
+	return davProps{
+		{Space: davNS, Local: "resourcetype"}:                 resourceType,
+		{Space: davNS, Local: "displayname"}:                  xmlText(u.Name),
+		{Space: davNS, Local: "current-user-principal"}:       "<d:href>" + principalPath + "</d:href>",
+		{Space: davNS, Local: "principal-URL"}:                "<d:href>" + principalPath + "</d:href>",
+		{Space: caldavNS, Local: "calendar-home-set"}:         "<d:href>" + calendarHomePath + "</d:href>",
+		{Space: caldavNS, Local: "calendar-user-address-set"}: "<d:href>mailto:" + xmlText(u.Email) + "</d:href>",
+	}
+}
+
+func collectionProps(list []caldav.Resource) davProps {
+	return davProps{
+		{Space: davNS, Local: "resourcetype"}:           "<d:collection/><c:calendar/>",
+		{Space: davNS, Local: "displayname"}:            "Tasks",
+		{Space: davNS, Local: "current-user-principal"}: "<d:href>" + principalPath + "</d:href>",
+		{Space: davNS, Local: "current-user-privilege-set"}: "<d:privilege><d:read/></d:privilege>" +
+			"<d:privilege><d:write/></d:privilege><d:privilege><d:write-content/></d:privilege>" +
+			"<d:privilege><d:bind/></d:privilege><d:privilege><d:unbind/></d:privilege>",
+		{Space: davNS, Local: "supported-report-set"}: "<d:supported-report><d:report><c:calendar-multiget/></d:report></d:supported-report>" +
+			"<d:supported-report><d:report><c:calendar-query/></d:report></d:supported-report>",
+		{Space: caldavNS, Local: "supported-calendar-component-set"}: `<c:comp name="` + tasks.ComponentTodo + `"/>`,
+		{Space: calserverNS, Local: "getctag"}:                       xmlText(caldav.CTag(list)),
+	}
+}
+
+// resourceProps are the properties of a task, the calendar data is only returned by reports
+func resourceProps(res *caldav.Resource, withData bool) (davProps, error) {
+	props := davProps{
+		{Space: davNS, Local: "resourcetype"}:   "",
+		{Space: davNS, Local: "getetag"}:        xmlText(res.ETag),
+		{Space: davNS, Local: "getcontenttype"}: "text/calendar; charset=utf-8; component=" + tasks.ComponentTodo,
+	}
+	if res.Task.UpdatedAt != nil {
+		props[xml.Name{Space: davNS, Local: "getlastmodified"}] = res.Task.UpdatedAt.UTC().Format(http.TimeFormat)
+	}
+
+	if withData {
+		data := strings.Builder{}
+		err := ical.NewEncoder(&data).Encode(res.Calendar())
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		props[xml.Name{Space: caldavNS, Local: "calendar-data"}] = xmlText(data.String())
+	}
+
+	return props, nil
+}
+
+// davUser returns the user with the basic authentication credentials of the request
+func (h *Handlers) davUser(r *http.Request) (*users.User, error) {
+	email, password, ok := r.BasicAuth()
+	if !ok {
+		return nil, errors.Unauthenticated("basic authentication is required")
+	}
+
+	u, err := h.api.Authenticate(r.Context(), email, password)
+	if err != nil {
+		if errors.HasType(err, errors.TypeUnauthorized) {
+			return nil, errors.UnauthenticatedErr(err, "Wrong username or password")
+		}
+		return nil, err
+	}
+
+	return u, nil
+}
+
+// CalDAV serves the tasks of the user as the to-dos of a CalDAV calendar, so calendar clients can
+// sync them both ways. Clients do not support bearer tokens, so it uses basic authentication
+func (h *Handlers) CalDAV(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("DAV", "1, 3, calendar-access")
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", davMethods)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	u, err := h.davUser(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="task-scheduler", charset="UTF-8"`)
+		errResponder(w, err)
+		return
+	}
+
+	p := r.URL.Path
+	switch p + "/" {
+	case caldavRoot, principalPath, calendarHomePath, collectionPath:
+		p += "/"
+	}
+
+	name := ""
+	if strings.HasPrefix(p, collectionPath) {
+		name = strings.TrimPrefix(p, collectionPath)
+	}
+
+	switch r.Method {
+	case "PROPFIND":
+		h.davPropfind(w, r, u, p, name)
+	case "REPORT":
+		h.davReport(w, r, u, p)
+	case http.MethodGet, http.MethodHead:
+		h.davGet(w, r, u, p, name)
+	case http.MethodPut:
+		h.davPut(w, r, u, name)
+	case http.MethodDelete:
+		h.davDelete(w, r, u, name)
+	default:
+		w.Header().Set("Allow", davMethods)
+		webgo.SendError(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// CalDAVDiscovery redirects clients to the root of the CalDAV server, as per RFC 6764
+func (h *Handlers) CalDAVDiscovery(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, caldavRoot, http.StatusMovedPermanently)
+}
+
+func (h *Handlers) davPropfind(w http.ResponseWriter, r *http.Request, u *users.User, p string, name string) {
+	req, err := parseDAVRequest(r.Body)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	// an infinite depth is not supported, it is treated as 1
+	deep := r.Header.Get("Depth") != "0"
+	ms := newMultistatus()
+	switch {
+	case p == caldavRoot:
+		ms.response(p, principalProps(u, "<d:collection/>"), req)
+	case p == principalPath:
+		ms.response(p, principalProps(u, "<d:collection/><d:principal/>"), req)
+	case p == calendarHomePath, p == collectionPath:
+		list, err := h.api.CalDAVResources(r.Context(), u.UID)
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+
+		if p == calendarHomePath {
+			ms.response(p, principalProps(u, "<d:collection/>"), req)
+			if deep {
+				ms.response(collectionPath, collectionProps(list), req)
+			}
+			break
+		}
+
+		ms.response(p, collectionProps(list), req)
+		if !deep {
+			break
+		}
+		for idx := range list {
+			props, err := resourceProps(&list[idx], false)
+			if err != nil {
+				errResponder(w, err)
+				return
+			}
+			ms.response(collectionPath+list[idx].Name, props, req)
+		}
+	case name != "":
+		res, err := h.api.CalDAVResource(r.Context(), u.UID, name)
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+
+		props, err := resourceProps(res, false)
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+		ms.response(p, props, req)
+	default:
+		errResponder(w, errors.NotFound("resource not found"))
+		return
+	}
+
+	ms.send(w)
+}
+
+// davReport supports calendar-multiget and calendar-query on the tasks collection. Queries are
+// only filtered by component, clients filter the time ranges themselves
+func (h *Handlers) davReport(w http.ResponseWriter, r *http.Request, u *users.User, p string) {
+	if p != collectionPath {
+		errResponder(w, errors.NotFound("resource not found"))
+		return
+	}
+
+	req, err := parseDAVRequest(r.Body)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	isMultiget := req.Root.Space == caldavNS && req.Root.Local == "calendar-multiget"
+	isQuery := req.Root.Space == caldavNS && req.Root.Local == "calendar-query"
+	if !isMultiget && !isQuery {
+		w.Header().Set("Content-Type", "application/xml; charset=utf-8")
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(`<?xml version="1.0" encoding="utf-8"?><d:error xmlns:d="DAV:"><d:supported-report/></d:error>`))
+		return
+	}
+
+	list, err := h.api.CalDAVResources(r.Context(), u.UID)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	ms := newMultistatus()
+	if isQuery {
+		if !req.todosOnly() {
+			list = nil
+		}
+
+		for idx := range list {
+			props, err := resourceProps(&list[idx], true)
+			if err != nil {
+				errResponder(w, err)
+				return
+			}
+			ms.response(collectionPath+list[idx].Name, props, req)
+		}
+		ms.send(w)
+		return
+	}
+
+	byName := make(map[string]*caldav.Resource, len(list))
+	for idx := range list {
+		byName[list[idx].Name] = &list[idx]
+	}
+
+	for _, href := range req.Hrefs {
+		name, ok := davHrefName(href)
+		if !ok {
+			ms.notFound(href)
+			continue
+		}
+
+		// completed tasks drop off the collection after a while, but can still be fetched
+		res := byName[name]
+		if res == nil {
+			res, err = h.api.CalDAVResource(r.Context(), u.UID, name)
+			if err != nil {
+				ms.notFound(href)
+				continue
+			}
+		}
+
+		props, err := resourceProps(res, true)
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+		ms.response(collectionPath+res.Name, props, req)
+	}
+
+	ms.send(w)
+}
+
+func (h *Handlers) davGet(w http.ResponseWriter, r *http.Request, u *users.User, p string, name string) {
+	if p == collectionPath {
+		list, err := h.api.CalDAVResources(r.Context(), u.UID)
+		if err != nil {
+			errResponder(w, err)
+			return
+		}
+
+		cal := tasks.NewCalendar("Tasks", nil, tasks.ComponentTodo)
+		for idx := range list {
+			cal.AddComponent(list[idx].Calendar().Components[0])
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.WriteHeader(http.StatusOK)
+		ical.NewEncoder(w).Encode(cal)
+		return
+	}
+
+	if name == "" {
+		errResponder(w, errors.NotFound("resource not found"))
+		return
+	}
+
+	res, err := h.api.CalDAVResource(r.Context(), u.UID, name)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", res.ETag)
+	if res.Task.UpdatedAt != nil {
+		w.Header().Set("Last-Modified", res.Task.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if r.Header.Get("If-None-Match") == res.ETag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	ical.NewEncoder(w).Encode(res.Calendar())
+}
+
+func (h *Handlers) davPut(w http.ResponseWriter, r *http.Request, u *users.User, name string) {
+	if name == "" {
+		w.Header().Set("Allow", davMethods)
+		webgo.SendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	res, created, err := h.api.PutCalDAVResource(
+		r.Context(),
+		u.UID,
+		name,
+		r.Body,
+		r.Header.Get("If-Match"),
+		r.Header.Get("If-None-Match"),
+	)
+	if err != nil {
+		if err == caldav.ErrPreconditionFailed {
+			webgo.SendError(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", res.ETag)
+	if created {
+		w.WriteHeader(http.StatusCreated)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *Handlers) davDelete(w http.ResponseWriter, r *http.Request, u *users.User, name string) {
+	if name == "" {
+		w.Header().Set("Allow", davMethods)
+		webgo.SendError(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	err := h.api.DeleteCalDAVResource(r.Context(), u.UID, name, r.Header.Get("If-Match"))
+	if err != nil {
+		if err == caldav.ErrPreconditionFailed {
+			webgo.SendError(w, err.Error(), http.StatusPreconditionFailed)
+			return
+		}
+		errResponder(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
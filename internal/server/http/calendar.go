@@ -0,0 +1,89 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"task-scheduler/internal/ical"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+// calendarLink is the token of a calendar feed along with its subscription URL
+type calendarLink struct {
+	Token string `json:"token"`
+	URL   string `json:"url"`
+}
+
+func newCalendarLink(r *http.Request, token string) *calendarLink {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+
+	return &calendarLink{
+		Token: token,
+		URL:   scheme + "://" + r.Host + "/api/calendar/feed/" + token,
+	}
+}
+
+func (h *Handlers) GetCalendarLink(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	token, err := h.api.CalendarToken(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, newCalendarLink(r, token))
+}
+
+func (h *Handlers) RegenerateCalendarLink(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	token, err := h.api.RegenerateCalendarToken(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, newCalendarLink(r, token))
+}
+
+// CalendarFeed serves the ICS feed of the user who owns the token in the URL, it does not require
+// a bearer token so calendar apps can subscribe to it. Tasks are rendered as to-dos, or as events
+// with component=vevent
+func (h *Handlers) CalendarFeed(w http.ResponseWriter, r *http.Request) {
+	component := strings.ToUpper(r.URL.Query().Get("component"))
+	if component == "" {
+		component = tasks.ComponentTodo
+	}
+
+	if component != tasks.ComponentTodo && component != tasks.ComponentEvent {
+		errResponder(w, errors.Validation("component should be either vtodo or vevent"))
+		return
+	}
+
+	cal, err := h.api.TaskCalendar(r.Context(), webgo.Context(r).Params()["token"], component)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="tasks.ics"`)
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.WriteHeader(http.StatusOK)
+	ical.NewEncoder(w).Encode(cal)
+}
@@ -0,0 +1,127 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-scheduler/internal/comments"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) AddComment(w http.ResponseWriter, r *http.Request) {
+	c := new(comments.Comment)
+	err := json.NewDecoder(r.Body).Decode(c)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	c.TID, err = paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	c.UID, err = claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	c, err = h.api.AddComment(r.Context(), c)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, c)
+}
+
+func (h *Handlers) EditComment(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		Body string `json:"body"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	cid, err := paramInt64(r, "cid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	c, err := h.api.EditComment(r.Context(), tid, cid, uid, payload.Body)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, c)
+}
+
+func (h *Handlers) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	cid, err := paramInt64(r, "cid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.DeleteComment(r.Context(), tid, cid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
+
+func (h *Handlers) GetComments(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetComments(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
@@ -0,0 +1,90 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"task-scheduler/internal/tasks"
+)
+
+// etags returns the entity tags of a header like If-Match, which is either "*" or a list
+func etags(header string) []string {
+	list := []string{}
+	for _, etag := range strings.Split(header, ",") {
+		etag = strings.TrimSpace(etag)
+		if etag != "" {
+			list = append(list, etag)
+		}
+	}
+	return list
+}
+
+// etagMatches returns whether the task matches any of the entity tags, "*" matches any task
+func etagMatches(t *tasks.Task, list []string) bool {
+	for _, etag := range list {
+		if etag == "*" {
+			return true
+		}
+		if version, ok := tasks.ParseETag(etag); ok && version == t.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// ifMatch returns the version of the task a change is based on, as sent in If-Match. It is 0 if
+// the change does not depend on the version, i.e. there is no If-Match or it is "*". When more
+// than one entity tag is sent, the current version is returned if it is one of them
+func (h *Handlers) ifMatch(r *http.Request, tid int64, uid int64) (int64, error) {
+	list := etags(r.Header.Get("If-Match"))
+	if len(list) == 0 || (len(list) == 1 && list[0] == "*") {
+		return 0, nil
+	}
+
+	if len(list) == 1 {
+		version, ok := tasks.ParseETag(list[0])
+		if !ok {
+			return 0, tasks.ErrVersionMismatch
+		}
+		return version, nil
+	}
+
+	t, err := h.api.GetTask(r.Context(), tid, uid)
+	if err != nil {
+		return 0, err
+	}
+	if !etagMatches(t, list) {
+		return 0, tasks.ErrVersionMismatch
+	}
+	return t.Version, nil
+}
+
+// GetTask returns a task, with its version as the ETag. If If-None-Match has the current version,
+// nothing is returned and the status is 304
+func (h *Handlers) GetTask(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err := h.api.GetTask(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", t.ETag())
+	if etagMatches(t, etags(r.Header.Get("If-None-Match"))) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, t)
+}
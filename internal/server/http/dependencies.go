@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) AddTaskDependency(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		BlockedBy int64 `json:"blockedBy"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	d, err := h.api.AddTaskDependency(r.Context(), tid, payload.BlockedBy, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, d)
+}
+
+func (h *Handlers) RemoveTaskDependency(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	blockedBy, err := paramInt64(r, "bid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.RemoveTaskDependency(r.Context(), tid, blockedBy, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
+
+func (h *Handlers) GetTaskDependencies(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	graph, err := h.api.GetTaskDependencies(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, graph)
+}
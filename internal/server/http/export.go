@@ -0,0 +1,200 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+)
+
+var exportContentTypes = map[string]string{
+	tasks.FormatCSV:    "text/csv; charset=utf-8",
+	tasks.FormatJSON:   "application/json",
+	tasks.FormatNDJSON: "application/x-ndjson",
+}
+
+// exportWriter writes tasks to the response as they are read, the response is only started with
+// the first task so errors before it are still reported with their status
+type exportWriter struct {
+	w       http.ResponseWriter
+	format  string
+	csv     *csv.Writer
+	count   int
+	started bool
+}
+
+func (ew *exportWriter) start() error {
+	ew.started = true
+	ew.w.Header().Set("Content-Type", exportContentTypes[ew.format])
+	ew.w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "tasks." + ew.format,
+	}))
+	ew.w.WriteHeader(http.StatusOK)
+
+	switch ew.format {
+	case tasks.FormatCSV:
+		ew.csv = csv.NewWriter(ew.w)
+		return ew.csv.Write(tasks.CSVHeader)
+	case tasks.FormatJSON:
+		_, err := ew.w.Write([]byte("["))
+		return err
+	}
+	return nil
+}
+
+func (ew *exportWriter) write(t *tasks.Task) error {
+	if !ew.started {
+		err := ew.start()
+		if err != nil {
+			return err
+		}
+	}
+	ew.count++
+
+	if ew.format == tasks.FormatCSV {
+		err := ew.csv.Write(t.CSVRecord())
+		if err != nil {
+			return err
+		}
+		ew.csv.Flush()
+		return ew.csv.Error()
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return err
+	}
+
+	if ew.format == tasks.FormatJSON && ew.count > 1 {
+		b = append([]byte(","), b...)
+	}
+	if ew.format == tasks.FormatNDJSON {
+		b = append(b, '\n')
+	}
+
+	_, err = ew.w.Write(b)
+	return err
+}
+
+func (ew *exportWriter) close() error {
+	if !ew.started {
+		err := ew.start()
+		if err != nil {
+			return err
+		}
+	}
+
+	switch ew.format {
+	case tasks.FormatCSV:
+		ew.csv.Flush()
+		return ew.csv.Error()
+	case tasks.FormatJSON:
+		_, err := ew.w.Write([]byte("]"))
+		return err
+	}
+	return nil
+}
+
+// ExportTasks streams the tasks of the user as a CSV (the default), JSON or NDJSON file depending
+// on format in the query string. It takes the filters and sort order of GetAllTasks, but is not
+// paginated. An error after the download has started leaves the file incomplete
+func (h *Handlers) ExportTasks(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	format := strings.ToLower(r.URL.Query().Get("format"))
+	if format == "" {
+		format = tasks.FormatCSV
+	}
+	if _, ok := exportContentTypes[format]; !ok {
+		errResponder(w, errors.Validationf("unsupported format '%s', it should be one of csv, json, ndjson", format))
+		return
+	}
+
+	ew := &exportWriter{
+		w:      w,
+		format: format,
+	}
+	err = h.api.ExportTasks(r.Context(), uid, filter, ew.write)
+	if err != nil {
+		if !ew.started {
+			errResponder(w, err)
+		}
+		return
+	}
+
+	ew.close()
+}
+
+type importError struct {
+	Row   int    `json:"row"`
+	Ref   string `json:"ref,omitempty"`
+	Error string `json:"error"`
+}
+
+// ImportTasks imports the file in the body, in the format of the query string (csv, json or
+// ndjson), in the format of ExportTasks. Nothing is imported if any row is invalid, the errors of
+// all the rows are reported instead. dryRun=true only validates the file
+func (h *Handlers) ImportTasks(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	dryRun := false
+	if v := query.Get("dryRun"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			errResponder(w, errors.ValidationErr(err, "invalid dryRun provided"))
+			return
+		}
+	}
+
+	result, err := h.api.ImportTasks(r.Context(), uid, r.Body, strings.ToLower(query.Get("format")), dryRun)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	errs := make([]importError, 0, len(result.Failed))
+	for _, row := range result.Failed {
+		_, msg, _ := errors.HTTPStatusCodeMessage(row.Err)
+		errs = append(errs, importError{
+			Row:   row.Row,
+			Error: msg,
+		})
+	}
+
+	status := http.StatusOK
+	if result.Imported > 0 {
+		status = http.StatusCreated
+	}
+	if len(errs) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	jsonResponder(w, status, map[string]interface{}{
+		"dryRun":   result.DryRun,
+		"total":    result.Total,
+		"valid":    result.Valid,
+		"imported": result.Imported,
+		"errors":   errs,
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"task-scheduler/internal/tasks"
 	"task-scheduler/internal/users"
@@ -27,14 +29,14 @@ func (h *Handlers) Register(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	tid, err := strconv.ParseInt(r.FormValue("tid"), 10, 64)
-	task, err := h.api.GetTask(r.Context(), tid)
+	task, err := h.api.GetTask(r.Context(), tid, createdUser.UID)
 	if err != nil {
 		errResponder(w, err)
 		return
 	}
 	if task.AssignedTo == u.Email {
 		task.UID = createdUser.UID
-		editedTask, err := h.api.EditTask(r.Context(), tid, task)
+		editedTask, err := h.api.EditTask(r.Context(), tid, task, createdUser.UID)
 		if err != nil {
 
 			println(err.Error())
@@ -77,6 +79,83 @@ func (h *Handlers) Login(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+// GetUser responds with the profile of the authenticated user
+func (h *Handlers) GetUser(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	u, err := h.api.User(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, u)
+}
+
+// SetTimezone changes the timezone of the authenticated user, with a body like
+// {"timezone": "Europe/Berlin"}
+func (h *Handlers) SetTimezone(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	payload := struct {
+		Timezone string `json:"timezone"`
+	}{}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	u, err := h.api.SetTimezone(r.Context(), uid, payload.Timezone)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, u)
+}
+
+// QuickAdd reads a task from a line of text, with a body like {"text": "Call Ann tomorrow 5pm
+// !high #work", "save": false}. It responds with the task and the parts of the text it understood,
+// the task is only created if save is true so the result can be confirmed first
+func (h *Handlers) QuickAdd(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	payload := struct {
+		Text string `json:"text"`
+		Save bool   `json:"save"`
+	}{}
+	err = json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	result, err := h.api.QuickAdd(r.Context(), uid, payload.Text, payload.Save)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if payload.Save {
+		status = http.StatusCreated
+	}
+	jsonResponder(w, status, result)
+}
+
 func (h *Handlers) AddTask(w http.ResponseWriter, r *http.Request) {
 	t := new(tasks.Task)
 	err := json.NewDecoder(r.Body).Decode(t)
@@ -105,7 +184,19 @@ func (h *Handlers) AddTask(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
 	wctx := webgo.Context(r)
 	tid, err := strconv.ParseInt(wctx.Params()["tid"], 10, 64)
-	err = h.api.DeleteTask(r.Context(), tid)
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	version, err := h.ifMatch(r, tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.DeleteTask(r.Context(), tid, r.URL.Query().Get("children"), version, uid)
 	if err != nil {
 		errResponder(w, err)
 		return
@@ -117,15 +208,31 @@ func (h *Handlers) DeleteTask(w http.ResponseWriter, r *http.Request) {
 func (h *Handlers) EditTask(w http.ResponseWriter, r *http.Request) {
 	t := new(tasks.Task)
 	err := json.NewDecoder(r.Body).Decode(t)
-	wctx := webgo.Context(r)
-	tid, err := strconv.ParseInt(wctx.Params()["tid"], 10, 64)
-
 	if err != nil {
 		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
 		return
 	}
 
-	modifiedTask, err := h.api.EditTask(r.Context(), tid, t)
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	// the version of the body is ignored, only If-Match makes the edit conditional
+	t.Version, err = h.ifMatch(r, tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	modifiedTask, err := h.api.EditTask(r.Context(), tid, t, uid)
 	if err != nil {
 		errResponder(w, err)
 		return
@@ -138,19 +245,26 @@ func (h *Handlers) EditTask(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", modifiedTask.ETag())
 	w.Write(b)
 }
 
 func (h *Handlers) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 	props, _ := r.Context().Value("props").(*users.Claims)
 	uid, err := strconv.ParseInt(props.Id, 10, 64)
-	tasks, err := h.api.GetAllTasks(r.Context(), uid)
+	filter, err := taskFilter(r)
 	if err != nil {
 		errResponder(w, err)
 		return
 	}
 
-	b, err := json.Marshal(tasks)
+	page, err := h.api.GetAllTasks(r.Context(), uid, filter)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	b, err := json.Marshal(page)
 	if err != nil {
 		errResponder(w, err)
 		return
@@ -160,6 +274,198 @@ func (h *Handlers) GetAllTasks(w http.ResponseWriter, r *http.Request) {
 	w.Write(b)
 }
 
+func (h *Handlers) GetTaskActivity(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	events, err := h.api.GetTaskActivity(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, events)
+}
+
+// GetActivityFeed returns the latest events on the caller's tasks, or made by the caller. Older
+// events are fetched by passing the ID of the last event received as ?before=
+func (h *Handlers) GetActivityFeed(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	var before int64
+	if v := query.Get("before"); v != "" {
+		before, err = strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			errResponder(w, errors.ValidationErr(err, "invalid before provided"))
+			return
+		}
+	}
+
+	var limit uint64
+	if v := query.Get("limit"); v != "" {
+		limit, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			errResponder(w, errors.ValidationErr(err, "invalid limit provided"))
+			return
+		}
+	}
+
+	events, err := h.api.GetActivityFeed(r.Context(), uid, before, limit)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, events)
+}
+
+// GetTrash lists the deleted tasks the caller can still restore, theirs and those of the projects
+// they own
+func (h *Handlers) GetTrash(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetTrash(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+func (h *Handlers) RestoreTask(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	task, err := h.api.RestoreTask(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, task)
+}
+
+// SearchTasks searches the tasks the caller can view
+func (h *Handlers) SearchTasks(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	var limit uint64
+	if v := r.URL.Query().Get("limit"); v != "" {
+		limit, err = strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			errResponder(w, errors.ValidationErr(err, "invalid limit provided"))
+			return
+		}
+	}
+
+	results, err := h.api.SearchTasks(r.Context(), uid, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, results)
+}
+
+// taskFilter reads the filters, sort order and pagination of a task listing from the query string
+func taskFilter(r *http.Request) (*tasks.Filter, error) {
+	query := r.URL.Query()
+	filter := &tasks.Filter{
+		AssignedTo: query.Get("assignedTo"),
+		Tag:        query.Get("tag"),
+		Priority:   query.Get("priority"),
+		Sort:       query.Get("sort"),
+		Cursor:     query.Get("cursor"),
+	}
+	if status := query.Get("status"); status != "" {
+		filter.Statuses = strings.Split(status, ",")
+	}
+
+	if limit := query.Get("limit"); limit != "" {
+		v, err := strconv.ParseUint(limit, 10, 64)
+		if err != nil {
+			return nil, errors.ValidationErr(err, "invalid limit provided")
+		}
+		filter.Limit = v
+	}
+
+	times := map[string]**time.Time{
+		"dueFrom":     &filter.DueFrom,
+		"dueTo":       &filter.DueTo,
+		"createdFrom": &filter.CreatedFrom,
+		"createdTo":   &filter.CreatedTo,
+		"updatedFrom": &filter.UpdatedFrom,
+		"updatedTo":   &filter.UpdatedTo,
+	}
+	for key, dest := range times {
+		v := query.Get(key)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return nil, errors.ValidationErrf(err, "invalid %s provided, expected an RFC3339 time", key)
+		}
+		*dest = &t
+	}
+
+	return filter, nil
+}
+
+func (h *Handlers) GetSubtree(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	node, err := h.api.GetSubtree(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, node)
+}
+
 func (h *Handlers) AssignTask(w http.ResponseWriter, r *http.Request) {
 	t := new(tasks.Task)
 	err := json.NewDecoder(r.Body).Decode(t)
@@ -168,7 +474,13 @@ func (h *Handlers) AssignTask(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	createdTask, err := h.api.AssignTask(r.Context(), t)
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	createdTask, err := h.api.AssignTask(r.Context(), t, uid)
 	if err != nil {
 		errResponder(w, err)
 		return
@@ -183,3 +495,157 @@ func (h *Handlers) AssignTask(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(b)
 }
+
+func (h *Handlers) PreviewRecurrence(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	rec := &tasks.Recurrence{
+		Rule:     query.Get("rule"),
+		Timezone: query.Get("timezone"),
+	}
+
+	count := 10
+	if query.Get("count") != "" {
+		n, err := strconv.Atoi(query.Get("count"))
+		if err != nil || n < 1 {
+			errResponder(w, errors.Validation("count should be a positive integer"))
+			return
+		}
+		count = n
+	}
+
+	after := time.Now()
+	if query.Get("start") != "" {
+		start, err := time.Parse(time.RFC3339, query.Get("start"))
+		if err != nil {
+			errResponder(w, errors.ValidationErr(err, "start should be an RFC3339 timestamp"))
+			return
+		}
+		rec.Start = &start
+		// the start itself is the first occurrence if it matches the rule
+		after = start.Add(-time.Nanosecond)
+	}
+
+	occurrences, err := h.api.PreviewRecurrence(r.Context(), rec, after, count)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	b, err := json.Marshal(map[string]interface{}{
+		"rule":        rec.Rule,
+		"timezone":    rec.Timezone,
+		"occurrences": occurrences,
+	})
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(b)
+}
+
+func (h *Handlers) TransitionTask(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		Status string `json:"status"`
+		Note   string `json:"note"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	task, err := h.api.TransitionTask(r.Context(), tid, uid, payload.Status, payload.Note)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, task)
+}
+
+func (h *Handlers) GetTaskTransitions(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	transitions, err := h.api.GetTaskTransitions(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, transitions)
+}
+
+func (h *Handlers) GetWorkflow(w http.ResponseWriter, r *http.Request) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	workflow, err := h.api.GetWorkflow(r.Context(), pid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, workflow)
+}
+
+func (h *Handlers) SaveWorkflow(w http.ResponseWriter, r *http.Request) {
+	workflow := new(tasks.Workflow)
+	err := json.NewDecoder(r.Body).Decode(workflow)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	workflow, err = h.api.SaveWorkflow(r.Context(), pid, uid, workflow)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, workflow)
+}
@@ -2,13 +2,16 @@ package http
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
 
 	"task-scheduler/internal/api"
+	"task-scheduler/internal/tasks"
 	"task-scheduler/internal/users"
 
 	"github.com/bnkamalesh/errors"
@@ -38,10 +41,55 @@ type Config struct {
 }
 
 func errResponder(w http.ResponseWriter, err error) {
-	status, msg, _ := errors.HTTPStatusCodeMessage(err)
+	status, msg := errStatus(err)
 	webgo.SendError(w, msg, status)
 }
 
+// errStatus returns the HTTP status and the message of an error
+func errStatus(err error) (int, string) {
+	if err == tasks.ErrVersionMismatch {
+		return http.StatusPreconditionFailed, err.Error()
+	}
+	status, msg, _ := errors.HTTPStatusCodeMessage(err)
+	return status, msg
+}
+
+func jsonResponder(w http.ResponseWriter, status int, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// claimsUID returns the ID of the user who made the request, as set in the JWT claims by authRoute
+func claimsUID(r *http.Request) (int64, error) {
+	props, ok := r.Context().Value("props").(*users.Claims)
+	if !ok {
+		return 0, errors.Unauthenticated("Authorization token is missing")
+	}
+
+	uid, err := strconv.ParseInt(props.Id, 10, 64)
+	if err != nil {
+		return 0, errors.UnauthenticatedErr(err, "Unauthorized")
+	}
+
+	return uid, nil
+}
+
+// paramInt64 returns the URI parameter key parsed as an integer
+func paramInt64(r *http.Request, key string) (int64, error) {
+	v, err := strconv.ParseInt(webgo.Context(r).Params()[key], 10, 64)
+	if err != nil {
+		return 0, errors.ValidationErrf(err, "invalid %s provided", key)
+	}
+	return v, nil
+}
+
 func authRoute(next http.Handler) http.HandlerFunc {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := strings.Split(r.Header.Get("Authorization"), "Bearer ")
@@ -122,12 +170,475 @@ func (h *Handlers) routes() []*webgo.Route {
 			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetAllTasks))},
 			TrailingSlash: true,
 		},
-		// this should be authorized with an admin token or whoever has access to assign tasks
+		&webgo.Route{
+			Name:          "search-tasks",
+			Pattern:       "/api/tasks/search",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.SearchTasks))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "bulk-tasks",
+			Pattern:       "/api/tasks/bulk",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.BulkTasks))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-trash",
+			Pattern:       "/api/tasks/trash",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTrash))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "restore-task",
+			Pattern:       "/api/tasks/:tid/restore",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RestoreTask))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-activity-feed",
+			Pattern:       "/api/activity",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetActivityFeed))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task-activity",
+			Pattern:       "/api/tasks/:tid/activity",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTaskActivity))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "preview-recurrence",
+			Pattern:       "/api/recurrence/preview",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.PreviewRecurrence))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-subtree",
+			Pattern:       "/api/tasks/:tid/subtree",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetSubtree))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "transition-task",
+			Pattern:       "/api/tasks/:tid/transitions",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.TransitionTask))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task-transitions",
+			Pattern:       "/api/tasks/:tid/transitions",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTaskTransitions))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-workflow",
+			Pattern:       "/api/projects/:pid/workflow",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetWorkflow))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "save-workflow",
+			Pattern:       "/api/projects/:pid/workflow",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.SaveWorkflow))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task-reminders",
+			Pattern:       "/api/tasks/:tid/reminders",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTaskReminders))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "set-task-reminders",
+			Pattern:       "/api/tasks/:tid/reminders",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.SetTaskReminders))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "reset-task-reminders",
+			Pattern:       "/api/tasks/:tid/reminders",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.ResetTaskReminders))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "add-task-dependency",
+			Pattern:       "/api/tasks/:tid/dependencies",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AddTaskDependency))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task-dependencies",
+			Pattern:       "/api/tasks/:tid/dependencies",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTaskDependencies))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "remove-task-dependency",
+			Pattern:       "/api/tasks/:tid/dependencies/:bid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RemoveTaskDependency))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "add-comment",
+			Pattern:       "/api/tasks/:tid/comments",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AddComment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-comments",
+			Pattern:       "/api/tasks/:tid/comments",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetComments))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "edit-comment",
+			Pattern:       "/api/tasks/:tid/comments/:cid",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.EditComment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "delete-comment",
+			Pattern:       "/api/tasks/:tid/comments/:cid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.DeleteComment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "upload-attachment",
+			Pattern:       "/api/tasks/:tid/attachments",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.UploadAttachment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-attachments",
+			Pattern:       "/api/tasks/:tid/attachments",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetAttachments))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "download-attachment",
+			Pattern:       "/api/tasks/:tid/attachments/:aid",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.DownloadAttachment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "delete-attachment",
+			Pattern:       "/api/tasks/:tid/attachments/:aid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.DeleteAttachment))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "create-template",
+			Pattern:       "/api/templates",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.CreateTemplate))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-templates",
+			Pattern:       "/api/templates",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTemplates))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-template",
+			Pattern:       "/api/templates/:id",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTemplate))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "edit-template",
+			Pattern:       "/api/templates/:id",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.EditTemplate))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "delete-template",
+			Pattern:       "/api/templates/:id",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.DeleteTemplate))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "instantiate-template",
+			Pattern:       "/api/templates/:id/instantiate",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.InstantiateTemplate))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "start-timer",
+			Pattern:       "/api/tasks/:tid/timer/start",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.StartTimer))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "stop-timer",
+			Pattern:       "/api/timer/stop",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.StopTimer))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-timer",
+			Pattern:       "/api/timer",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTimer))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "add-worklog",
+			Pattern:       "/api/tasks/:tid/worklogs",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AddWorklog))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-worklogs",
+			Pattern:       "/api/tasks/:tid/worklogs",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetWorklogs))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "edit-worklog",
+			Pattern:       "/api/tasks/:tid/worklogs/:wid",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.EditWorklog))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "delete-worklog",
+			Pattern:       "/api/tasks/:tid/worklogs/:wid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.DeleteWorklog))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "worklogs-report",
+			Pattern:       "/api/worklogs/report",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.WorklogsReport))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "add-task-member",
+			Pattern:       "/api/tasks/:tid/members",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AddTaskMember))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task-members",
+			Pattern:       "/api/tasks/:tid/members",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTaskMembers))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "remove-task-member",
+			Pattern:       "/api/tasks/:tid/members/:mid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RemoveTaskMember))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "create-project",
+			Pattern:       "/api/projects",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.CreateProject))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-projects",
+			Pattern:       "/api/projects",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetProjects))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-project",
+			Pattern:       "/api/projects/:pid",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetProject))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "edit-project",
+			Pattern:       "/api/projects/:pid",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.EditProject))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "archive-project",
+			Pattern:       "/api/projects/:pid/archive",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.ArchiveProject))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "unarchive-project",
+			Pattern:       "/api/projects/:pid/unarchive",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.UnarchiveProject))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-project-members",
+			Pattern:       "/api/projects/:pid/members",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetProjectMembers))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "add-project-member",
+			Pattern:       "/api/projects/:pid/members",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AddProjectMember))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "remove-project-member",
+			Pattern:       "/api/projects/:pid/members/:uid",
+			Method:        http.MethodDelete,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RemoveProjectMember))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-project-tasks",
+			Pattern:       "/api/projects/:pid/tasks",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetProjectTasks))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "move-task",
+			Pattern:       "/api/tasks/:tid/move",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.MoveTask))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-calendar-link",
+			Pattern:       "/api/calendar",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetCalendarLink))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "regenerate-calendar-link",
+			Pattern:       "/api/calendar/token",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RegenerateCalendarLink))},
+			TrailingSlash: true,
+		},
+		// the feed is authenticated with the secret token in its URL, as calendar apps cannot send
+		// a bearer token
+		&webgo.Route{
+			Name:          "calendar-feed",
+			Pattern:       "/api/calendar/feed/:token",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{http.HandlerFunc(h.CalendarFeed)},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "export-tasks",
+			Pattern:       "/api/tasks/export",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.ExportTasks))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "import-tasks",
+			Pattern:       "/api/tasks/import",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.ImportTasks))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "preview-import",
+			Pattern:       "/api/imports/:source/preview",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.PreviewImport))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "run-import",
+			Pattern:       "/api/imports/:source",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.RunImport))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-user",
+			Pattern:       "/api/users/me",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetUser))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "set-timezone",
+			Pattern:       "/api/users/me/timezone",
+			Method:        http.MethodPut,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.SetTimezone))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "quick-add",
+			Pattern:       "/api/tasks/quick",
+			Method:        http.MethodPost,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.QuickAdd))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "get-task",
+			Pattern:       "/api/tasks/:tid",
+			Method:        http.MethodGet,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.GetTask))},
+			TrailingSlash: true,
+		},
+		&webgo.Route{
+			Name:          "patch-task",
+			Pattern:       "/api/tasks/:tid",
+			Method:        http.MethodPatch,
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.PatchTask))},
+			TrailingSlash: true,
+		},
 		&webgo.Route{
 			Name:          "assign-tasks",
 			Pattern:       "/api/tasks/assign",
 			Method:        http.MethodPost,
-			Handlers:      []http.HandlerFunc{http.HandlerFunc(h.AssignTask)},
+			Handlers:      []http.HandlerFunc{authRoute(http.HandlerFunc(h.AssignTask))},
 			TrailingSlash: true,
 		},
 	}
@@ -166,8 +677,14 @@ func NewService(cfg *Config, a *api.API) (*HTTP, error) {
 	router.Use(accesslog.AccessLog)
 	tracer, _ := apm.NewTracer("task-scheduler", "v1.0.0")
 
+	// webgo does not support the methods of WebDAV, so CalDAV is served outside the router
+	mux := http.NewServeMux()
+	mux.HandleFunc(caldavRoot, h.CalDAV)
+	mux.HandleFunc("/.well-known/caldav", h.CalDAVDiscovery)
+	mux.Handle("/", router)
+
 	serverHandler := apmhttp.Wrap(
-		router,
+		mux,
 		apmhttp.WithRecovery(apmhttp.NewTraceRecovery(
 			tracer,
 		)),
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"net/http"
+
+	"task-scheduler/internal/importers"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+type importPreview struct {
+	Row      int                 `json:"row"`
+	Ref      string              `json:"ref,omitempty"`
+	Task     interface{}         `json:"task,omitempty"`
+	Comments []importers.Comment `json:"comments,omitempty"`
+	Error    string              `json:"error,omitempty"`
+}
+
+func importReport(report *importers.Report) map[string]interface{} {
+	errs := make([]importError, 0, len(report.Failed))
+	for _, record := range report.Failed {
+		_, msg, _ := errors.HTTPStatusCodeMessage(record.Err)
+		errs = append(errs, importError{
+			Row:   record.Row,
+			Ref:   record.Ref,
+			Error: msg,
+		})
+	}
+
+	return map[string]interface{}{
+		"source":   report.Source,
+		"dryRun":   report.DryRun,
+		"total":    report.Total,
+		"valid":    report.Valid,
+		"imported": report.Imported,
+		"comments": report.Comments,
+		"errors":   errs,
+	}
+}
+
+// PreviewImport reads the file in the body, exported from the source of the path (trello, jira or
+// todotxt), and responds with the tasks it would import and the report of a dry run
+func (h *Handlers) PreviewImport(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wctx := webgo.Context(r)
+	records, report, err := h.api.PreviewImport(r.Context(), uid, wctx.Params()["source"], r.Body)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	preview := make([]importPreview, 0, len(records))
+	for _, record := range records {
+		p := importPreview{
+			Row:      record.Row,
+			Ref:      record.Ref,
+			Comments: record.Comments,
+		}
+		if record.Task != nil {
+			p.Task = record.Task
+		}
+		if record.Err != nil {
+			_, p.Error, _ = errors.HTTPStatusCodeMessage(record.Err)
+		}
+		preview = append(preview, p)
+	}
+
+	response := importReport(report)
+	response["tasks"] = preview
+	webgo.R200(w, response)
+}
+
+// RunImport imports the file in the body, exported from the source of the path, and responds
+// with the import report
+func (h *Handlers) RunImport(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wctx := webgo.Context(r)
+	report, err := h.api.RunImport(r.Context(), uid, wctx.Params()["source"], r.Body)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if report.Imported > 0 {
+		status = http.StatusCreated
+	}
+	if len(report.Failed) > 0 {
+		status = http.StatusUnprocessableEntity
+	}
+
+	jsonResponder(w, status, importReport(report))
+}
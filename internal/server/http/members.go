@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) AddTaskMember(w http.ResponseWriter, r *http.Request) {
+	m := new(tasks.Member)
+	err := json.NewDecoder(r.Body).Decode(m)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	m, err = h.api.AddTaskMember(r.Context(), tid, m, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, m)
+}
+
+func (h *Handlers) GetTaskMembers(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	members, err := h.api.GetTaskMembers(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, members)
+}
+
+func (h *Handlers) RemoveTaskMember(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	mid, err := paramInt64(r, "mid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.RemoveTaskMember(r.Context(), tid, mid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
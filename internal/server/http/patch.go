@@ -0,0 +1,77 @@
+package http
+
+import (
+	"io"
+	"mime"
+	"net/http"
+
+	"task-scheduler/internal/platform/jsonpatch"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+// maxPatchSize is the maximum size of the body of a PATCH request
+const maxPatchSize = 1 << 20
+
+// acceptPatch are the media types of the patches of a task, JSON bodies are read as merge patches
+var acceptPatch = jsonpatch.MergePatchType + ", " + jsonpatch.PatchType
+
+// PatchTask updates the fields of a task in a JSON Merge Patch or a JSON Patch, depending on the
+// Content-Type. It honors If-Match like EditTask
+func (h *Handlers) PatchTask(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxPatchSize+1))
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "invalid body"))
+		return
+	}
+	if len(body) > maxPatchSize {
+		errResponder(w, errors.Validationf("the patch cannot be larger than %d bytes", maxPatchSize))
+		return
+	}
+
+	var patch *tasks.Patch
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case jsonpatch.PatchType:
+		patch, err = tasks.NewJSONPatch(body)
+	case jsonpatch.MergePatchType, "application/json", "":
+		patch, err = tasks.NewMergePatch(body)
+	default:
+		w.Header().Set("Accept-Patch", acceptPatch)
+		webgo.SendError(w, "unsupported patch format, expected one of "+acceptPatch, http.StatusUnsupportedMediaType)
+		return
+	}
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	version, err := h.ifMatch(r, tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err := h.api.PatchTask(r.Context(), tid, patch, version, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", t.ETag())
+	jsonResponder(w, http.StatusOK, t)
+}
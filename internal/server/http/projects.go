@@ -0,0 +1,275 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-scheduler/internal/projects"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) CreateProject(w http.ResponseWriter, r *http.Request) {
+	p := new(projects.Project)
+	err := json.NewDecoder(r.Body).Decode(p)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	p, err = h.api.CreateProject(r.Context(), p, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, p)
+}
+
+func (h *Handlers) EditProject(w http.ResponseWriter, r *http.Request) {
+	p := new(projects.Project)
+	err := json.NewDecoder(r.Body).Decode(p)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	p, err = h.api.EditProject(r.Context(), pid, uid, p)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, p)
+}
+
+func (h *Handlers) GetProject(w http.ResponseWriter, r *http.Request) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	p, err := h.api.GetProject(r.Context(), pid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, p)
+}
+
+// GetProjects lists the projects of the user, archived ones are included with archived=true
+func (h *Handlers) GetProjects(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetProjects(r.Context(), uid, r.URL.Query().Get("archived") == "true")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+func (h *Handlers) ArchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.archiveProject(w, r, true)
+}
+
+func (h *Handlers) UnarchiveProject(w http.ResponseWriter, r *http.Request) {
+	h.archiveProject(w, r, false)
+}
+
+func (h *Handlers) archiveProject(w http.ResponseWriter, r *http.Request, archive bool) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	p, err := h.api.ArchiveProject(r.Context(), pid, uid, archive)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, p)
+}
+
+func (h *Handlers) GetProjectMembers(w http.ResponseWriter, r *http.Request) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetProjectMembers(r.Context(), pid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+func (h *Handlers) AddProjectMember(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		Email string `json:"email"`
+		Role  string `json:"role"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	m, err := h.api.AddProjectMember(r.Context(), pid, uid, payload.Email, payload.Role)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, m)
+}
+
+func (h *Handlers) RemoveProjectMember(w http.ResponseWriter, r *http.Request) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	memberUID, err := paramInt64(r, "uid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.RemoveProjectMember(r.Context(), pid, uid, memberUID)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
+
+// GetProjectTasks lists the tasks of a project, with the same filters and pagination as GetAllTasks
+func (h *Handlers) GetProjectTasks(w http.ResponseWriter, r *http.Request) {
+	pid, err := paramInt64(r, "pid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	filter, err := taskFilter(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	page, err := h.api.GetProjectTasks(r.Context(), pid, uid, filter)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, page)
+}
+
+// MoveTask moves a task to the project in the payload, a projectId of 0 moves it out of any project
+func (h *Handlers) MoveTask(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		ProjectID int64 `json:"projectId"`
+	}{}
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err := h.api.MoveTask(r.Context(), tid, payload.ProjectID, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, t)
+}
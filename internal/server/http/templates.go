@@ -0,0 +1,152 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"task-scheduler/internal/templates"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) CreateTemplate(w http.ResponseWriter, r *http.Request) {
+	t := new(templates.Template)
+	err := json.NewDecoder(r.Body).Decode(t)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	t.UID, err = claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err = h.api.CreateTemplate(r.Context(), t)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, t)
+}
+
+func (h *Handlers) EditTemplate(w http.ResponseWriter, r *http.Request) {
+	t := new(templates.Template)
+	err := json.NewDecoder(r.Body).Decode(t)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	id, err := paramInt64(r, "id")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err = h.api.EditTemplate(r.Context(), id, uid, t)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, t)
+}
+
+func (h *Handlers) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := paramInt64(r, "id")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	t, err := h.api.GetTemplate(r.Context(), id, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, t)
+}
+
+func (h *Handlers) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetTemplates(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+func (h *Handlers) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := paramInt64(r, "id")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.DeleteTemplate(r.Context(), id, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
+
+func (h *Handlers) InstantiateTemplate(w http.ResponseWriter, r *http.Request) {
+	in := new(templates.Instantiation)
+	err := json.NewDecoder(r.Body).Decode(in)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	id, err := paramInt64(r, "id")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.InstantiateTemplate(r.Context(), id, uid, in)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, list)
+}
@@ -0,0 +1,241 @@
+package http
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"task-scheduler/internal/worklogs"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/bnkamalesh/webgo/v6"
+)
+
+func (h *Handlers) StartTimer(w http.ResponseWriter, r *http.Request) {
+	payload := struct {
+		Note string `json:"note"`
+	}{}
+	// the body is optional
+	err := json.NewDecoder(r.Body).Decode(&payload)
+	if err != nil && err != io.EOF {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl, err := h.api.StartTimer(r.Context(), tid, uid, payload.Note)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, wl)
+}
+
+func (h *Handlers) StopTimer(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl, err := h.api.StopTimer(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, wl)
+}
+
+func (h *Handlers) GetTimer(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl, err := h.api.GetTimer(r.Context(), uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, wl)
+}
+
+func (h *Handlers) AddWorklog(w http.ResponseWriter, r *http.Request) {
+	wl := new(worklogs.Worklog)
+	err := json.NewDecoder(r.Body).Decode(wl)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	wl.TID, err = paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl.UID, err = claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl, err = h.api.AddWorklog(r.Context(), wl)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusCreated, wl)
+}
+
+func (h *Handlers) EditWorklog(w http.ResponseWriter, r *http.Request) {
+	wl := new(worklogs.Worklog)
+	err := json.NewDecoder(r.Body).Decode(wl)
+	if err != nil {
+		errResponder(w, errors.InputBodyErr(err, "Invalid JSON provided"))
+		return
+	}
+
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wid, err := paramInt64(r, "wid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wl, err = h.api.EditWorklog(r.Context(), tid, wid, uid, wl)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, wl)
+}
+
+func (h *Handlers) DeleteWorklog(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	wid, err := paramInt64(r, "wid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	err = h.api.DeleteWorklog(r.Context(), tid, wid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	webgo.R200(w, nil)
+}
+
+func (h *Handlers) GetWorklogs(w http.ResponseWriter, r *http.Request) {
+	tid, err := paramInt64(r, "tid")
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	list, err := h.api.GetWorklogs(r.Context(), tid, uid)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	jsonResponder(w, http.StatusOK, list)
+}
+
+// WorklogsReport responds with the hours spent per task, user and day. The report is CSV if
+// format=csv is in the query string, JSON otherwise
+func (h *Handlers) WorklogsReport(w http.ResponseWriter, r *http.Request) {
+	uid, err := claimsUID(r)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &worklogs.ReportFilter{
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Timezone: query.Get("timezone"),
+	}
+
+	list, err := h.api.WorklogsReport(r.Context(), uid, filter)
+	if err != nil {
+		errResponder(w, err)
+		return
+	}
+
+	if query.Get("format") != "csv" {
+		jsonResponder(w, http.StatusOK, list)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{
+		"filename": "worklogs-" + filter.From + "-" + filter.To + ".csv",
+	}))
+	w.WriteHeader(http.StatusOK)
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"date", "tid", "detail", "uid", "email", "hours"})
+	for _, row := range list {
+		cw.Write([]string{
+			row.Date,
+			strconv.FormatInt(row.TID, 10),
+			row.Detail,
+			strconv.FormatInt(row.UID, 10),
+			row.Email,
+			strconv.FormatFloat(row.Hours, 'f', 2, 64),
+		})
+	}
+	cw.Flush()
+}
@@ -0,0 +1,150 @@
+package tasks
+
+import (
+	"context"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	OpCreate = "create"
+	OpEdit   = "edit"
+	OpDelete = "delete"
+
+	// BulkAtomic applies all the operations or none of them
+	BulkAtomic = "atomic"
+	// BulkPartial applies every operation which succeeds, independently of the others
+	BulkPartial = "partial"
+
+	// MaxBulkOperations is the maximum number of operations in a single bulk request
+	MaxBulkOperations = 500
+)
+
+// ErrRolledBack is the error of the operations which were not applied because another operation
+// of an atomic bulk request failed
+var ErrRolledBack = errors.New("not applied, another operation failed and the transaction was rolled back")
+
+// Operation is a single create, edit or delete of a bulk request
+type Operation struct {
+	Op string `json:"op"`
+	// TID is the task to edit or delete
+	TID  int64 `json:"tid,omitempty"`
+	Task *Task `json:"task,omitempty"`
+	// Children is how subtasks are handled on delete, see Delete
+	Children string `json:"children,omitempty"`
+	// Version, if set, is the version the task should still be at to be edited or deleted
+	Version int64 `json:"version,omitempty"`
+}
+
+// OperationResult is the outcome of an operation, at the same index as the operation
+type OperationResult struct {
+	Op   string
+	TID  int64
+	Task *Task
+	Err  error
+}
+
+// Bulk applies the operations on behalf of a user, in order. In BulkAtomic mode the first failure
+// rolls back everything, in BulkPartial mode each operation succeeds or fails on its own. Errors
+// of individual operations are reported in the results, the returned error is only for failures
+// of the request as a whole. authorize, if not nil, is called before each operation is applied
+// and fails it if it returns an error
+func (ts *Tasks) Bulk(ctx context.Context, mode string, ops []Operation, uid int64, authorize func(ctx context.Context, op *Operation) error) ([]OperationResult, bool, error) {
+	switch mode {
+	case "":
+		mode = BulkAtomic
+	case BulkAtomic, BulkPartial:
+	default:
+		return nil, false, errors.Validationf("mode should be either '%s' or '%s'", BulkAtomic, BulkPartial)
+	}
+
+	if len(ops) == 0 {
+		return nil, false, errors.Validation("at least one operation is required")
+	}
+	if len(ops) > MaxBulkOperations {
+		return nil, false, errors.Validationf("a bulk request cannot have more than %d operations", MaxBulkOperations)
+	}
+
+	results := make([]OperationResult, len(ops))
+	failed := -1
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		for i := range ops {
+			if mode == BulkAtomic {
+				results[i] = ts.apply(ctx, &ops[i], uid, authorize)
+				if results[i].Err != nil {
+					failed = i
+					return results[i].Err
+				}
+				continue
+			}
+
+			// each operation runs in its own savepoint, so a failure does not abort the others
+			err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+				results[i] = ts.apply(ctx, &ops[i], uid, authorize)
+				return results[i].Err
+			})
+			if err != nil && results[i].Err == nil {
+				// the savepoint itself failed, so the operation was not applied
+				results[i] = OperationResult{Op: ops[i].Op, TID: ops[i].TID, Err: err}
+			}
+		}
+		return nil
+	})
+
+	if failed >= 0 {
+		for i := range results {
+			if i != failed {
+				results[i] = OperationResult{Op: ops[i].Op, TID: ops[i].TID, Err: ErrRolledBack}
+			}
+		}
+		return results, false, nil
+	}
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	return results, true, nil
+}
+
+func (ts *Tasks) apply(ctx context.Context, op *Operation, uid int64, authorize func(ctx context.Context, op *Operation) error) OperationResult {
+	result := OperationResult{
+		Op:  op.Op,
+		TID: op.TID,
+	}
+
+	if op.Op != OpDelete && op.Task == nil {
+		result.Err = errors.Validationf("task is required for '%s'", op.Op)
+		return result
+	}
+
+	if authorize != nil {
+		result.Err = authorize(ctx, op)
+		if result.Err != nil {
+			return result
+		}
+	}
+
+	switch op.Op {
+	case OpCreate:
+		op.Task.UID = uid
+		result.Task, result.Err = ts.Create(ctx, op.Task)
+	case OpEdit:
+		if op.Version != 0 {
+			op.Task.Version = op.Version
+		}
+		result.Task, result.Err = ts.Edit(ctx, op.TID, op.Task, uid)
+	case OpDelete:
+		_, result.Err = ts.Delete(ctx, op.TID, op.Children, op.Version, uid)
+	default:
+		result.Err = errors.Validationf("op should be one of '%s', '%s' or '%s'", OpCreate, OpEdit, OpDelete)
+	}
+
+	if result.Task != nil && result.Task.TID != 0 {
+		result.TID = result.Task.TID
+	}
+
+	return result
+}
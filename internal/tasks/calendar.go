@@ -0,0 +1,162 @@
+package tasks
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"task-scheduler/internal/ical"
+)
+
+const (
+	// CalendarPast is how far back in time tasks are included in calendars
+	CalendarPast = time.Hour * 24 * 90
+	// MaxCalendarSize is the maximum number of tasks in a calendar
+	MaxCalendarSize = 2000
+
+	// ComponentTodo renders tasks as to-dos
+	ComponentTodo = "VTODO"
+	// ComponentEvent renders tasks as events, for calendar apps which do not support to-dos
+	ComponentEvent = "VEVENT"
+
+	calendarProdID = "-//task-scheduler//tasks//EN"
+)
+
+// Calendar returns the tasks of the user, or shared with them, which are due since CalendarPast
+func (ts *Tasks) Calendar(ctx context.Context, uid int64) ([]Task, error) {
+	return ts.store.Calendar(ctx, uid, time.Now().Add(-CalendarPast), MaxCalendarSize)
+}
+
+// Todos returns the tasks of the user, or shared with them, which are either open or were completed
+// since CalendarPast, whether they have a due date or not
+func (ts *Tasks) Todos(ctx context.Context, uid int64) ([]Task, error) {
+	return ts.store.Todos(ctx, uid, time.Now().Add(-CalendarPast), MaxCalendarSize)
+}
+
+// NewCalendar returns a VCALENDAR with the tasks rendered as component, either ComponentTodo or
+// ComponentEvent
+func NewCalendar(name string, list []Task, component string) *ical.Component {
+	cal := ical.NewComponent("VCALENDAR").
+		Add("VERSION", "2.0").
+		Add("PRODID", calendarProdID).
+		Add("CALSCALE", "GREGORIAN").
+		AddText("X-WR-CALNAME", name)
+
+	now := time.Now()
+	for idx := range list {
+		if component == ComponentEvent {
+			cal.AddComponent(list[idx].VEvent(now))
+		} else {
+			cal.AddComponent(list[idx].VTodo(now))
+		}
+	}
+
+	return cal
+}
+
+// CalendarUID is the unique identifier of the task in calendars, it never changes
+func (t *Task) CalendarUID() string {
+	return "task-" + strconv.FormatInt(t.TID, 10) + "@task-scheduler"
+}
+
+// VTodo renders the task as a VTODO, stamped at now
+func (t *Task) VTodo(now time.Time) *ical.Component {
+	c := t.calendarComponent(ComponentTodo, now)
+	if !t.CompleteBy.IsZero() {
+		t.addDue(c, "DUE")
+	}
+	if p := CalendarPriority(t.Priority); p != 0 {
+		c.Add("PRIORITY", strconv.Itoa(p))
+	}
+
+	switch {
+	case t.CompletedAt != nil:
+		c.Add("STATUS", "COMPLETED")
+		c.AddTime("COMPLETED", *t.CompletedAt)
+		c.Add("PERCENT-COMPLETE", "100")
+	case t.Status == StatusCancelled:
+		c.Add("STATUS", "CANCELLED")
+	case t.Status == StatusInProgress:
+		c.Add("STATUS", "IN-PROCESS")
+	default:
+		c.Add("STATUS", "NEEDS-ACTION")
+	}
+
+	return c
+}
+
+// VEvent renders the task as a VEVENT at its due time, stamped at now
+func (t *Task) VEvent(now time.Time) *ical.Component {
+	c := t.calendarComponent(ComponentEvent, now)
+	t.addDue(c, "DTSTART")
+	if t.Status == StatusCancelled {
+		c.Add("STATUS", "CANCELLED")
+	} else {
+		c.Add("STATUS", "CONFIRMED")
+	}
+	// events of tasks do not block time in the calendar
+	c.Add("TRANSP", "TRANSPARENT")
+
+	return c
+}
+
+// CalendarPriority returns the iCalendar PRIORITY of a priority, from 1 (the highest) to 9, or 0
+// for tasks without a priority
+func CalendarPriority(priority string) int {
+	switch priority {
+	case PriorityUrgent:
+		return 1
+	case PriorityHigh:
+		return 3
+	case PriorityMedium:
+		return 5
+	case PriorityLow:
+		return 9
+	}
+	return 0
+}
+
+// PriorityOfCalendar returns the priority of an iCalendar PRIORITY, 1 to 4 being high and 6 to 9 low
+func PriorityOfCalendar(p int) string {
+	switch {
+	case p == 1:
+		return PriorityUrgent
+	case p >= 2 && p <= 4:
+		return PriorityHigh
+	case p == 5:
+		return PriorityMedium
+	case p >= 6 && p <= 9:
+		return PriorityLow
+	}
+	return ""
+}
+
+// addDue adds the due date as a date for all-day tasks, so it is the same day in every calendar
+func (t *Task) addDue(c *ical.Component, name string) {
+	date, err := time.Parse(DateLayout, t.DueDate)
+	if err == nil {
+		c.AddDate(name, date)
+		return
+	}
+	c.AddTime(name, t.CompleteBy)
+}
+
+// calendarComponent returns a component with the properties common to to-dos and events
+func (t *Task) calendarComponent(name string, now time.Time) *ical.Component {
+	c := ical.NewComponent(name).
+		Add("UID", t.CalendarUID()).
+		AddTime("DTSTAMP", now).
+		AddText("SUMMARY", t.Detail)
+
+	if t.CreatedAt != nil {
+		c.AddTime("CREATED", *t.CreatedAt)
+	}
+	if t.UpdatedAt != nil {
+		c.AddTime("LAST-MODIFIED", *t.UpdatedAt)
+	}
+	if t.ParentID != 0 {
+		c.Add("RELATED-TO", (&Task{TID: t.ParentID}).CalendarUID())
+	}
+
+	return c
+}
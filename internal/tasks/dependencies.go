@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// Dependency is an edge of the dependency graph: task TID cannot start until BlockedBy is complete
+type Dependency struct {
+	TID       int64      `json:"tid"`
+	BlockedBy int64      `json:"blockedBy"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// DependencyGraph is the part of the dependency graph reachable from a task
+type DependencyGraph struct {
+	TID int64 `json:"tid"`
+	// Upstream are the edges to the tasks blocking TID, directly or transitively
+	Upstream []Dependency `json:"upstream"`
+	// Downstream are the edges to the tasks blocked by TID, directly or transitively
+	Downstream []Dependency `json:"downstream"`
+	// Tasks are all the tasks which are part of the graph, including TID
+	Tasks []Task `json:"tasks"`
+}
+
+// AddDependency makes tid blocked by blockedBy. Dependencies which would create a cycle are rejected
+func (ts *Tasks) AddDependency(ctx context.Context, tid int64, blockedBy int64) (*Dependency, error) {
+	if tid == blockedBy {
+		return nil, errors.Validation("a task cannot be blocked by itself")
+	}
+
+	// both tasks should exist
+	_, err := ts.store.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	_, err = ts.store.Get(ctx, blockedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	d := &Dependency{
+		TID:       tid,
+		BlockedBy: blockedBy,
+		CreatedAt: &now,
+	}
+
+	err = ts.store.AddDependency(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (ts *Tasks) RemoveDependency(ctx context.Context, tid int64, blockedBy int64) error {
+	return ts.store.RemoveDependency(ctx, tid, blockedBy)
+}
+
+// Dependencies returns the upstream and downstream dependency graph of a task
+func (ts *Tasks) Dependencies(ctx context.Context, tid int64) (*DependencyGraph, error) {
+	upstream, err := ts.store.Upstream(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	downstream, err := ts.store.Downstream(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := []int64{tid}
+	seen := map[int64]bool{tid: true}
+	for _, list := range [][]Dependency{upstream, downstream} {
+		for _, d := range list {
+			for _, id := range []int64{d.TID, d.BlockedBy} {
+				if !seen[id] {
+					seen[id] = true
+					ids = append(ids, id)
+				}
+			}
+		}
+	}
+
+	tasks, err := ts.store.GetMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if !containsTask(tasks, tid) {
+		return nil, errors.NotFound("task not found")
+	}
+
+	// edges to tasks in the trash are left out, along with those tasks
+	present := make(map[int64]bool, len(tasks))
+	for _, t := range tasks {
+		present[t.TID] = true
+	}
+
+	return &DependencyGraph{
+		TID:        tid,
+		Upstream:   presentEdges(upstream, present),
+		Downstream: presentEdges(downstream, present),
+		Tasks:      tasks,
+	}, nil
+}
+
+// Redact replaces the tasks of the graph which are not visible by their ID and whether they are
+// blocked, so the graph stays complete without disclosing them
+func (g *DependencyGraph) Redact(visible map[int64]bool) {
+	for i, t := range g.Tasks {
+		if !visible[t.TID] {
+			g.Tasks[i] = Task{
+				TID:     t.TID,
+				Blocked: t.Blocked,
+			}
+		}
+	}
+}
+
+func presentEdges(edges []Dependency, present map[int64]bool) []Dependency {
+	list := make([]Dependency, 0, len(edges))
+	for _, d := range edges {
+		if present[d.TID] && present[d.BlockedBy] {
+			list = append(list, d)
+		}
+	}
+	return list
+}
+
+func containsTask(tasks []Task, tid int64) bool {
+	for _, t := range tasks {
+		if t.TID == tid {
+			return true
+		}
+	}
+	return false
+}
+
+// Unblocked returns the tasks blocked by tid which no longer have any incomplete blocker. It is
+// meant to be called right after tid is completed
+func (ts *Tasks) Unblocked(ctx context.Context, tid int64) ([]Task, error) {
+	return ts.store.Unblocked(ctx, tid)
+}
+
+// checkBlocked prevents a blocked task from being started or completed. It can still move back to
+// the initial status of its workflow, or be marked as blocked or cancelled
+func checkBlocked(t *Task, w *Workflow, to string) error {
+	if !t.Blocked {
+		return nil
+	}
+
+	if to == w.Initial || to == StatusBlocked || to == StatusCancelled {
+		return nil
+	}
+
+	return errors.Validation("task is blocked by other tasks which are not complete yet")
+}
@@ -0,0 +1,168 @@
+package tasks
+
+import (
+	"context"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	dependenciesTable = "task_dependencies"
+
+	// blockedExpr is true for tasks which have at least one incomplete blocker
+	blockedExpr = `EXISTS (
+		SELECT 1 FROM task_dependencies d JOIN tasks b ON b.id = d.blockedBy
+		WHERE d.tid = tasks.id AND b.completedAt IS NULL AND b.deletedAt IS NULL
+	)`
+)
+
+// AddDependency adds an edge to the dependency graph, unless it would create a cycle. The table
+// is locked for the check, so two concurrent inserts cannot create a cycle together
+func (ts *taskStore) AddDependency(ctx context.Context, d *Dependency) error {
+	return datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		conn := datastore.Conn(ctx, ts.pqdriver)
+		_, err := conn.Exec(ctx, "LOCK TABLE "+dependenciesTable+" IN SHARE ROW EXCLUSIVE MODE")
+		if err != nil {
+			return errors.InternalErr(err, errors.DefaultMessage)
+		}
+
+		// a cycle is created if the blocking task is already (transitively) blocked by tid
+		upstream, err := ts.Upstream(ctx, d.BlockedBy)
+		if err != nil {
+			return err
+		}
+		for _, edge := range upstream {
+			if edge.BlockedBy == d.TID {
+				return errors.Validation("dependency would create a cycle")
+			}
+		}
+
+		query, args, err := ts.qbuilder.Insert(dependenciesTable).SetMap(map[string]interface{}{
+			"tid":       d.TID,
+			"blockedBy": d.BlockedBy,
+			"createdAt": d.CreatedAt,
+		}).ToSql()
+		if err != nil {
+			return errors.InternalErr(err, errors.DefaultMessage)
+		}
+
+		_, err = conn.Exec(ctx, query, args...)
+		if err != nil {
+			return errors.DuplicateErr(err, "dependency already exists")
+		}
+
+		return nil
+	})
+}
+
+func (ts *taskStore) RemoveDependency(ctx context.Context, tid int64, blockedBy int64) error {
+	query, args, err := ts.qbuilder.Delete(dependenciesTable).Where(squirrel.Eq{
+		"tid":       tid,
+		"blockedBy": blockedBy,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("dependency not found")
+	}
+
+	return nil
+}
+
+// Upstream returns all the edges leading from tid to the tasks blocking it, recursively
+func (ts *taskStore) Upstream(ctx context.Context, tid int64) ([]Dependency, error) {
+	query := `WITH RECURSIVE upstream(tid, blockedBy, createdAt) AS (
+		SELECT tid, blockedBy, createdAt FROM task_dependencies WHERE tid = $1
+		UNION
+		SELECT d.tid, d.blockedBy, d.createdAt FROM task_dependencies d JOIN upstream u ON d.tid = u.blockedBy
+	)
+	SELECT tid, blockedBy, createdAt FROM upstream`
+
+	return ts.dependencies(ctx, query, tid)
+}
+
+// Downstream returns all the edges leading from tid to the tasks it blocks, recursively
+func (ts *taskStore) Downstream(ctx context.Context, tid int64) ([]Dependency, error) {
+	query := `WITH RECURSIVE downstream(tid, blockedBy, createdAt) AS (
+		SELECT tid, blockedBy, createdAt FROM task_dependencies WHERE blockedBy = $1
+		UNION
+		SELECT d.tid, d.blockedBy, d.createdAt FROM task_dependencies d JOIN downstream u ON d.blockedBy = u.tid
+	)
+	SELECT tid, blockedBy, createdAt FROM downstream`
+
+	return ts.dependencies(ctx, query, tid)
+}
+
+func (ts *taskStore) dependencies(ctx context.Context, query string, args ...interface{}) ([]Dependency, error) {
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Dependency{}
+	for rows.Next() {
+		d := Dependency{}
+		err = rows.Scan(&d.TID, &d.BlockedBy, &d.CreatedAt)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, d)
+	}
+
+	return list, nil
+}
+
+// Unblocked returns the incomplete tasks directly blocked by tid, which have no incomplete blockers
+func (ts *taskStore) Unblocked(ctx context.Context, tid int64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Expr("id IN (SELECT tid FROM "+dependenciesTable+" WHERE blockedBy = ?)", tid),
+			squirrel.Eq{"completedAt": nil},
+			notTrashed,
+			squirrel.Expr("NOT " + blockedExpr),
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// GetMany returns the tasks with the given IDs, ignoring IDs which do not exist
+func (ts *taskStore) GetMany(ctx context.Context, tids []int64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Eq{
+				"id": tids,
+			},
+			notTrashed,
+		},
+	).OrderBy(
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
@@ -0,0 +1,46 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDependencyGraphRedact(t *testing.T) {
+	due := time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC)
+	graph := &DependencyGraph{
+		TID: 1,
+		Upstream: []Dependency{
+			{TID: 1, BlockedBy: 2},
+			{TID: 2, BlockedBy: 3},
+		},
+		Downstream: []Dependency{
+			{TID: 4, BlockedBy: 1},
+		},
+		Tasks: []Task{
+			{TID: 1, UID: 10, Detail: "release", Blocked: true},
+			{TID: 2, UID: 10, Detail: "review", Status: StatusInProgress, Blocked: true},
+			{TID: 3, UID: 20, Detail: "someone else's task", AssignedTo: "bob@example.com", CompleteBy: due, Status: StatusInProgress, Tags: []string{"secret"}},
+			{TID: 4, UID: 20, Detail: "another foreign task", Blocked: true},
+		},
+	}
+	upstream := append([]Dependency{}, graph.Upstream...)
+	downstream := append([]Dependency{}, graph.Downstream...)
+
+	graph.Redact(map[int64]bool{1: true, 2: true})
+
+	want := []Task{
+		{TID: 1, UID: 10, Detail: "release", Blocked: true},
+		{TID: 2, UID: 10, Detail: "review", Status: StatusInProgress, Blocked: true},
+		{TID: 3},
+		{TID: 4, Blocked: true},
+	}
+	if !reflect.DeepEqual(graph.Tasks, want) {
+		t.Errorf("got tasks %+v, want %+v", graph.Tasks, want)
+	}
+
+	// the edges to the foreign tasks are kept
+	if !reflect.DeepEqual(graph.Upstream, upstream) || !reflect.DeepEqual(graph.Downstream, downstream) {
+		t.Errorf("edges changed: got %+v and %+v", graph.Upstream, graph.Downstream)
+	}
+}
@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"strings"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// DateLayout is the layout of the due date of all-day tasks
+	DateLayout = "2006-01-02"
+	// displayTimeLayout and displayDateLayout are the layouts of due dates shown to people, like in emails
+	displayTimeLayout = "Mon, 02 Jan 2006 15:04 MST"
+	displayDateLayout = "Mon, 02 Jan 2006"
+)
+
+// AllDay returns true if the task is due on a date rather than at a time
+func (t *Task) AllDay() bool {
+	return t.DueDate != ""
+}
+
+// Location returns the timezone of the due date, UTC if the task has none
+func (t *Task) Location() *time.Location {
+	loc, err := time.LoadLocation(t.DueTimezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// initDue validates the timezone of the due date. All-day tasks are due by the end of their date
+// in that timezone, so CompleteBy is set to it and reminders are sent in time
+func (t *Task) initDue() error {
+	t.DueTimezone = strings.TrimSpace(t.DueTimezone)
+	t.DueDate = strings.TrimSpace(t.DueDate)
+
+	loc, err := time.LoadLocation(t.DueTimezone)
+	if err != nil {
+		return errors.ValidationErrf(err, "invalid dueTimezone '%s', expected an IANA name like Europe/Berlin", t.DueTimezone)
+	}
+
+	if t.AllDay() {
+		date, err := time.ParseInLocation(DateLayout, t.DueDate, loc)
+		if err != nil {
+			return errors.ValidationErrf(err, "invalid dueDate '%s', expected YYYY-MM-DD", t.DueDate)
+		}
+		t.CompleteBy = date.AddDate(0, 0, 1)
+	}
+
+	if !t.CompleteBy.IsZero() {
+		t.CompleteBy = t.CompleteBy.In(loc)
+	}
+
+	return nil
+}
+
+// localizeDue shows the due date of a task read from the database in its timezone
+func (t *Task) localizeDue(allDay bool) {
+	if t.CompleteBy.IsZero() {
+		return
+	}
+
+	t.CompleteBy = t.CompleteBy.In(t.Location())
+	if allDay {
+		t.DueDate = t.CompleteBy.Add(-time.Nanosecond).Format(DateLayout)
+	}
+}
+
+// FormatDue returns the due date of the task as shown in the timezone, all-day tasks show their
+// date wherever they are viewed from
+func (t *Task) FormatDue(loc *time.Location) string {
+	if t.AllDay() {
+		date, err := time.Parse(DateLayout, t.DueDate)
+		if err == nil {
+			return date.Format(displayDateLayout)
+		}
+	}
+	return t.CompleteBy.In(loc).Format(displayTimeLayout)
+}
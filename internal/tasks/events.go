@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	EventCreate = "create"
+	EventEdit   = "edit"
+	EventDelete = "delete"
+	EventAssign = "assign"
+	EventImport = "import"
+
+	// MaxFeedSize is the maximum number of events returned by one page of the activity feed
+	MaxFeedSize = 100
+)
+
+// auditedFields are the JSON fields of a task which are compared to build the diff of an event
+var auditedFields = []string{
+	"uid",
+	"detail",
+	"completeBy",
+	"dueDate",
+	"dueTimezone",
+	"assignedTo",
+	"tags",
+	"priority",
+	"recurrence",
+	"projectId",
+	"parentId",
+}
+
+// Change is the value of a field before and after an event, nil if the field was empty
+type Change struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// Event is an entry of the audit trail of a task. It outlives the task, so deleted tasks keep
+// their history
+type Event struct {
+	ID  int64 `json:"id,omitempty"`
+	TID int64 `json:"tid,omitempty"`
+	// OwnerUID is the owner of the task when the event happened
+	OwnerUID int64 `json:"ownerUid,omitempty"`
+	// ActorUID is the user who made the change, 0 for changes made by the system (e.g. recurring
+	// tasks being created)
+	ActorUID  int64             `json:"actorUid"`
+	Action    string            `json:"action,omitempty"`
+	Diff      map[string]Change `json:"diff"`
+	CreatedAt *time.Time        `json:"createdAt,omitempty"`
+}
+
+// diff returns the audited fields which differ between two versions of a task. A nil version
+// stands for a task which does not exist, e.g. before it is created
+func diff(before *Task, after *Task) (map[string]Change, error) {
+	from, err := auditedValues(before)
+	if err != nil {
+		return nil, err
+	}
+
+	to, err := auditedValues(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]Change{}
+	for _, field := range auditedFields {
+		if !reflect.DeepEqual(from[field], to[field]) {
+			changes[field] = Change{
+				From: from[field],
+				To:   to[field],
+			}
+		}
+	}
+
+	return changes, nil
+}
+
+// auditedValues returns the JSON representation of the audited fields of a task
+func auditedValues(t *Task) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if t == nil {
+		return values, nil
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	err = json.Unmarshal(b, &values)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	// a zero time is as good as no due date
+	if values["completeBy"] == (time.Time{}).Format(time.RFC3339Nano) {
+		delete(values, "completeBy")
+	}
+
+	return values, nil
+}
+
+// recordEvent records an action on a task, with the diff between its two versions. Edits which do
+// not change any audited field are not recorded
+func (ts *Tasks) recordEvent(ctx context.Context, action string, actorUID int64, before *Task, after *Task) error {
+	changes, err := diff(before, after)
+	if err != nil {
+		return err
+	}
+
+	if action == EventEdit && len(changes) == 0 {
+		return nil
+	}
+
+	current := after
+	if current == nil {
+		current = before
+	}
+
+	now := time.Now()
+	return ts.store.CreateEvent(ctx, &Event{
+		TID:       current.TID,
+		OwnerUID:  current.UID,
+		ActorUID:  actorUID,
+		Action:    action,
+		Diff:      changes,
+		CreatedAt: &now,
+	})
+}
+
+// Activity returns the audit trail of a task, oldest first
+func (ts *Tasks) Activity(ctx context.Context, tid int64) ([]Event, error) {
+	return ts.store.GetEvents(ctx, tid)
+}
+
+// Feed returns the events on the tasks owned by the user, or made by the user, newest first.
+// before is the ID of the last event of the previous page, 0 for the first page
+func (ts *Tasks) Feed(ctx context.Context, uid int64, before int64, limit uint64) ([]Event, error) {
+	if limit == 0 || limit > MaxFeedSize {
+		limit = MaxFeedSize
+	}
+
+	return ts.store.GetFeed(ctx, uid, before, limit)
+}
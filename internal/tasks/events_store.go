@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+)
+
+const eventsTable = "task_events"
+
+var eventColumns = []string{
+	"id",
+	"tid",
+	"ownerUid",
+	"actorUid",
+	"action",
+	"diff",
+	"createdAt",
+}
+
+func (ts *taskStore) CreateEvent(ctx context.Context, e *Event) error {
+	diff, err := json.Marshal(e.Diff)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	query, args, err := ts.qbuilder.Insert(eventsTable).SetMap(map[string]interface{}{
+		"tid":       e.TID,
+		"ownerUid":  e.OwnerUID,
+		"actorUid":  e.ActorUID,
+		"action":    e.Action,
+		"diff":      string(diff),
+		"createdAt": e.CreatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	err = datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...).Scan(&e.ID)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ts *taskStore) GetEvents(ctx context.Context, tid int64) ([]Event, error) {
+	query, args, err := ts.qbuilder.Select(
+		eventColumns...,
+	).From(
+		eventsTable,
+	).Where(
+		squirrel.Eq{
+			"tid": tid,
+		},
+	).OrderBy(
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.events(ctx, query, args...)
+}
+
+func (ts *taskStore) GetFeed(ctx context.Context, uid int64, before int64, limit uint64) ([]Event, error) {
+	where := squirrel.And{
+		squirrel.Or{
+			squirrel.Eq{"ownerUid": uid},
+			squirrel.Eq{"actorUid": uid},
+		},
+	}
+	if before > 0 {
+		where = append(where, squirrel.Lt{"id": before})
+	}
+
+	query, args, err := ts.qbuilder.Select(
+		eventColumns...,
+	).From(
+		eventsTable,
+	).Where(
+		where,
+	).OrderBy(
+		"id DESC",
+	).Limit(
+		limit,
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.events(ctx, query, args...)
+}
+
+func (ts *taskStore) events(ctx context.Context, query string, args ...interface{}) ([]Event, error) {
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Event{}
+	for rows.Next() {
+		e := Event{}
+		diff := []byte{}
+		err = rows.Scan(&e.ID, &e.TID, &e.OwnerUID, &e.ActorUID, &e.Action, &diff, &e.CreatedAt)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+
+		err = json.Unmarshal(diff, &e.Diff)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, e)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
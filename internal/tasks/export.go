@@ -0,0 +1,95 @@
+package tasks
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	FormatCSV    = "csv"
+	FormatJSON   = "json"
+	FormatNDJSON = "ndjson"
+)
+
+// CSVHeader are the columns of exported CSV files, imported CSV files use the same ones
+var CSVHeader = []string{
+	"tid",
+	"detail",
+	"completeBy",
+	"dueDate",
+	"dueTimezone",
+	"assignedTo",
+	"status",
+	"projectId",
+	"parentId",
+	"tags",
+	"priority",
+	"completedAt",
+	"recurrenceRule",
+	"recurrenceTimezone",
+	"recurrenceStart",
+	"createdAt",
+	"updatedAt",
+}
+
+// Export calls each with every task of the user matching the filter, as they are read from the
+// database, so exports of any size can be streamed. The limit and cursor of the filter are ignored
+func (ts *Tasks) Export(ctx context.Context, uid int64, filter *Filter, each func(t *Task) error) error {
+	if filter == nil {
+		filter = new(Filter)
+	}
+
+	filter.Sanitize()
+	filter.Cursor = ""
+	err := filter.Validate()
+	if err != nil {
+		return err
+	}
+
+	return ts.store.Export(ctx, uid, filter, each)
+}
+
+// CSVRecord returns the task as a row of a CSV file with the CSVHeader columns
+func (t *Task) CSVRecord() []string {
+	rule, timezone, start := "", "", ""
+	if t.Recurrence != nil {
+		rule, timezone = t.Recurrence.Rule, t.Recurrence.Timezone
+		start = csvTime(t.Recurrence.Start)
+	}
+
+	return []string{
+		strconv.FormatInt(t.TID, 10),
+		t.Detail,
+		csvTime(&t.CompleteBy),
+		t.DueDate,
+		t.DueTimezone,
+		t.AssignedTo,
+		t.Status,
+		csvInt(t.ProjectID),
+		csvInt(t.ParentID),
+		strings.Join(t.Tags, ","),
+		t.Priority,
+		csvTime(t.CompletedAt),
+		rule,
+		timezone,
+		start,
+		csvTime(t.CreatedAt),
+		csvTime(t.UpdatedAt),
+	}
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func csvInt(v int64) string {
+	if v == 0 {
+		return ""
+	}
+	return strconv.FormatInt(v, 10)
+}
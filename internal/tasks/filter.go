@@ -0,0 +1,199 @@
+package tasks
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// DefaultPageSize is the number of tasks returned by GetAll when no limit is provided
+	DefaultPageSize = 50
+	// MaxPageSize is the maximum number of tasks returned by GetAll, regardless of the limit provided
+	MaxPageSize = 200
+)
+
+// sortColumns maps the fields tasks can be sorted by to their column
+var sortColumns = map[string]string{
+	"id":         "id",
+	"completeBy": "completeBy",
+	"createdAt":  "createdAt",
+	"updatedAt":  "updatedAt",
+}
+
+// Filter narrows down the tasks returned by GetAll. All the ranges include From and exclude To
+type Filter struct {
+	Statuses   []string
+	AssignedTo string
+	// Tag lists the tasks with this tag
+	Tag string
+	// Priority lists the tasks with this priority
+	Priority    string
+	DueFrom     *time.Time
+	DueTo       *time.Time
+	CreatedFrom *time.Time
+	CreatedTo   *time.Time
+	UpdatedFrom *time.Time
+	UpdatedTo   *time.Time
+	// Sort is one of the keys of sortColumns, prefixed with '-' for descending order
+	Sort string
+	// Cursor is the NextCursor of the previous page
+	Cursor string
+	Limit  uint64
+	// ProjectID lists the tasks of a project rather than the tasks of the user
+	ProjectID int64
+
+	// after is the decoded cursor, the page starts right after this position
+	after *cursor
+}
+
+// Page is one page of tasks, NextCursor is empty on the last page
+type Page struct {
+	Tasks      []Task `json:"tasks"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// cursor is the position of the last task of a page, in the sort order of the page. Null is set
+// when the task has no value in the sort column, e.g. no due date
+type cursor struct {
+	Sort  string     `json:"s"`
+	Value *time.Time `json:"v,omitempty"`
+	Null  bool       `json:"n,omitempty"`
+	TID   int64      `json:"id"`
+}
+
+func (f *Filter) Sanitize() {
+	f.AssignedTo = strings.TrimSpace(f.AssignedTo)
+	f.Tag = strings.ToLower(strings.TrimSpace(f.Tag))
+	f.Priority = strings.ToLower(strings.TrimSpace(f.Priority))
+	f.Sort = strings.TrimSpace(f.Sort)
+	if f.Sort == "" {
+		f.Sort = "id"
+	}
+
+	if f.Limit == 0 {
+		f.Limit = DefaultPageSize
+	}
+	if f.Limit > MaxPageSize {
+		f.Limit = MaxPageSize
+	}
+}
+
+func (f *Filter) Validate() error {
+	if _, ok := sortColumns[strings.TrimPrefix(f.Sort, "-")]; !ok {
+		return errors.Validationf("tasks cannot be sorted by '%s'", f.Sort)
+	}
+
+	if f.Priority != "" && !IsPriority(f.Priority) {
+		return errors.Validationf("'%s' is not a priority", f.Priority)
+	}
+
+	ranges := [][2]*time.Time{
+		{f.DueFrom, f.DueTo},
+		{f.CreatedFrom, f.CreatedTo},
+		{f.UpdatedFrom, f.UpdatedTo},
+	}
+	for _, r := range ranges {
+		if r[0] != nil && r[1] != nil && !r[0].Before(*r[1]) {
+			return errors.Validation("the start of a range should be before its end")
+		}
+	}
+
+	if f.Cursor == "" {
+		return nil
+	}
+
+	b, err := base64.RawURLEncoding.DecodeString(f.Cursor)
+	if err != nil {
+		return errors.ValidationErr(err, "invalid cursor")
+	}
+
+	c := new(cursor)
+	err = json.Unmarshal(b, c)
+	if err != nil {
+		return errors.ValidationErr(err, "invalid cursor")
+	}
+
+	// a cursor only makes sense with the sort order it was created for
+	if c.Sort != f.Sort {
+		return errors.Validation("cursor does not match the sort order")
+	}
+
+	f.after = c
+	return nil
+}
+
+// sortColumn returns the column to sort by and whether the order is descending
+func (f *Filter) sortColumn() (string, bool) {
+	return sortColumns[strings.TrimPrefix(f.Sort, "-")], strings.HasPrefix(f.Sort, "-")
+}
+
+// orderBy returns the order of the tasks. Tasks without a value in the sort column, e.g. without a
+// due date, come last in both directions
+func (f *Filter) orderBy() []string {
+	column, desc := f.sortColumn()
+	order := "ASC"
+	if desc {
+		order = "DESC"
+	}
+
+	orderBy := []string{"id " + order}
+	if column != "id" {
+		orderBy = append([]string{column + " " + order + " NULLS LAST"}, orderBy...)
+	}
+	return orderBy
+}
+
+// afterCursor returns the condition of the tasks which come after the cursor in the order of
+// orderBy, nil if there is no cursor
+func (f *Filter) afterCursor() squirrel.Sqlizer {
+	c := f.after
+	if c == nil {
+		return nil
+	}
+
+	column, desc := f.sortColumn()
+	operator := ">"
+	if desc {
+		operator = "<"
+	}
+
+	switch {
+	case column == "id":
+		return squirrel.Expr("id "+operator+" ?", c.TID)
+	case c.Null:
+		// only tasks without a value come after those without a value
+		return squirrel.Expr(column+" IS NULL AND id "+operator+" ?", c.TID)
+	}
+
+	// a NULL column never compares, so the tasks without a value are added explicitly
+	return squirrel.Expr("(("+column+", id) "+operator+" (?, ?) OR "+column+" IS NULL)", c.Value, c.TID)
+}
+
+// nextCursor returns the cursor pointing right after t
+func (f *Filter) nextCursor(t *Task) string {
+	c := cursor{
+		Sort: f.Sort,
+		TID:  t.TID,
+	}
+
+	column, _ := f.sortColumn()
+	switch column {
+	case "completeBy":
+		if !t.CompleteBy.IsZero() {
+			c.Value = &t.CompleteBy
+		}
+	case "createdAt":
+		c.Value = t.CreatedAt
+	case "updatedAt":
+		c.Value = t.UpdatedAt
+	}
+	c.Null = column != "id" && c.Value == nil
+
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,272 @@
+package tasks
+
+import (
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFilterAfterCursor(t *testing.T) {
+	due := time.Date(2024, 6, 5, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		sort     string
+		task     Task
+		wantSQL  string
+		wantArgs []interface{}
+	}{
+		{
+			name:     "id",
+			sort:     "id",
+			task:     Task{TID: 4},
+			wantSQL:  "id > ?",
+			wantArgs: []interface{}{int64(4)},
+		},
+		{
+			name:     "due date",
+			sort:     "completeBy",
+			task:     Task{TID: 4, CompleteBy: due},
+			wantSQL:  "((completeBy, id) > (?, ?) OR completeBy IS NULL)",
+			wantArgs: []interface{}{&due, int64(4)},
+		},
+		{
+			name:     "no due date",
+			sort:     "completeBy",
+			task:     Task{TID: 4},
+			wantSQL:  "completeBy IS NULL AND id > ?",
+			wantArgs: []interface{}{int64(4)},
+		},
+		{
+			name:     "no due date, descending",
+			sort:     "-completeBy",
+			task:     Task{TID: 4},
+			wantSQL:  "completeBy IS NULL AND id < ?",
+			wantArgs: []interface{}{int64(4)},
+		},
+	}
+
+	for _, tt := range tests {
+		f := &Filter{Sort: tt.sort}
+		f.Cursor = f.nextCursor(&tt.task)
+		err := f.Validate()
+		if err != nil {
+			t.Fatalf("%s: invalid cursor: %v", tt.name, err)
+		}
+
+		sql, args, err := f.afterCursor().ToSql()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.wantSQL {
+			t.Errorf("%s: got %q, want %q", tt.name, sql, tt.wantSQL)
+		}
+		if len(args) != len(tt.wantArgs) {
+			t.Fatalf("%s: got args %v, want %v", tt.name, args, tt.wantArgs)
+		}
+		for i := range args {
+			got, want := args[i], tt.wantArgs[i]
+			if tm, ok := want.(*time.Time); ok {
+				gtm, ok := got.(*time.Time)
+				if !ok || !gtm.Equal(*tm) {
+					t.Errorf("%s: got arg %d %v, want %v", tt.name, i, got, *tm)
+				}
+				continue
+			}
+			if got != want {
+				t.Errorf("%s: got arg %d %v, want %v", tt.name, i, got, want)
+			}
+		}
+	}
+}
+
+func TestFilterPaginationWithoutDueDates(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2024, 6, d, 0, 0, 0, 0, time.UTC)
+	}
+	list := []Task{
+		{TID: 1},
+		{TID: 2, CompleteBy: day(3)},
+		{TID: 3},
+		{TID: 4, CompleteBy: day(1)},
+		{TID: 5, CompleteBy: day(3)},
+		{TID: 6},
+		{TID: 7, CompleteBy: day(2)},
+	}
+
+	tests := []struct {
+		sort string
+		want []int64
+	}{
+		{sort: "completeBy", want: []int64{4, 7, 2, 5, 1, 3, 6}},
+		{sort: "-completeBy", want: []int64{5, 2, 7, 4, 6, 3, 1}},
+	}
+
+	for _, tt := range tests {
+		for _, limit := range []uint64{1, 2, 3} {
+			got := []int64{}
+			cur := ""
+			for pages := 0; ; pages++ {
+				if pages > len(list) {
+					t.Fatalf("%s, limit %d: pagination does not end, got %v", tt.sort, limit, got)
+				}
+
+				f := &Filter{Sort: tt.sort, Limit: limit, Cursor: cur}
+				f.Sanitize()
+				err := f.Validate()
+				if err != nil {
+					t.Fatalf("%s, limit %d: %v", tt.sort, limit, err)
+				}
+
+				page := queryPage(t, list, f)
+				more := uint64(len(page)) > f.Limit
+				if more {
+					page = page[:f.Limit]
+				}
+				for _, task := range page {
+					got = append(got, task.TID)
+				}
+				if !more {
+					break
+				}
+				cur = f.nextCursor(&page[len(page)-1])
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s, limit %d: got %v, want %v", tt.sort, limit, got, tt.want)
+			}
+		}
+	}
+}
+
+var (
+	afterID      = regexp.MustCompile(`^id ([<>]) \?$`)
+	afterNull    = regexp.MustCompile(`^completeBy IS NULL AND id ([<>]) \?$`)
+	afterNotNull = regexp.MustCompile(`^\(\(completeBy, id\) ([<>]) \(\?, \?\) OR completeBy IS NULL\)$`)
+)
+
+// queryPage evaluates the condition and the order of the filter on the tasks like Postgres would,
+// NULL being a task without a due date, and returns up to Limit+1 of them
+func queryPage(t *testing.T, list []Task, f *Filter) []Task {
+	matches := func(task Task) bool { return true }
+	if after := f.afterCursor(); after != nil {
+		sql, args, err := after.ToSql()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		compare := func(op string, a, b int64) bool {
+			if op == ">" {
+				return a > b
+			}
+			return a < b
+		}
+		compareTime := func(op string, a, b time.Time) bool {
+			if op == ">" {
+				return a.After(b)
+			}
+			return a.Before(b)
+		}
+
+		switch {
+		case afterID.MatchString(sql):
+			op := afterID.FindStringSubmatch(sql)[1]
+			matches = func(task Task) bool { return compare(op, task.TID, args[0].(int64)) }
+		case afterNull.MatchString(sql):
+			op := afterNull.FindStringSubmatch(sql)[1]
+			matches = func(task Task) bool {
+				return task.CompleteBy.IsZero() && compare(op, task.TID, args[0].(int64))
+			}
+		case afterNotNull.MatchString(sql):
+			op := afterNotNull.FindStringSubmatch(sql)[1]
+			value, tid := *args[0].(*time.Time), args[1].(int64)
+			matches = func(task Task) bool {
+				if task.CompleteBy.IsZero() {
+					return true
+				}
+				if task.CompleteBy.Equal(value) {
+					return compare(op, task.TID, tid)
+				}
+				return compareTime(op, task.CompleteBy, value)
+			}
+		default:
+			t.Fatalf("unexpected condition %q", sql)
+		}
+	}
+
+	page := []Task{}
+	for _, task := range list {
+		if matches(task) {
+			page = append(page, task)
+		}
+	}
+
+	orderBy := f.orderBy()
+	sort.SliceStable(page, func(i, j int) bool {
+		for _, clause := range orderBy {
+			desc := strings.Contains(clause, " DESC")
+			a, b := page[i], page[j]
+			switch strings.Fields(clause)[0] {
+			case "completeBy":
+				if !strings.HasSuffix(clause, "NULLS LAST") {
+					t.Fatalf("tasks without a due date should come last: %q", clause)
+				}
+				if a.CompleteBy.IsZero() != b.CompleteBy.IsZero() {
+					return b.CompleteBy.IsZero()
+				}
+				if a.CompleteBy.Equal(b.CompleteBy) {
+					continue
+				}
+				return a.CompleteBy.Before(b.CompleteBy) != desc
+			case "id":
+				if a.TID == b.TID {
+					continue
+				}
+				return (a.TID < b.TID) != desc
+			}
+		}
+		return false
+	})
+
+	if uint64(len(page)) > f.Limit+1 {
+		page = page[:f.Limit+1]
+	}
+	return page
+}
+
+func TestVisibleTo(t *testing.T) {
+	member := "id IN (SELECT m.tid FROM " + membersTable + " m JOIN users u ON lower(u.email) = m.email WHERE u.id = ?)"
+	tests := []struct {
+		name       string
+		projectIDs []int64
+		wantSQL    string
+		wantArgs   []interface{}
+	}{
+		{
+			name:     "no project",
+			wantSQL:  "(uid = ? OR " + member + ")",
+			wantArgs: []interface{}{int64(1), int64(1)},
+		},
+		{
+			name:       "projects",
+			projectIDs: []int64{3, 4},
+			wantSQL:    "(uid = ? OR " + member + " OR projectId IN (?,?))",
+			wantArgs:   []interface{}{int64(1), int64(1), int64(3), int64(4)},
+		},
+	}
+
+	for _, tt := range tests {
+		sql, args, err := visibleTo(1, tt.projectIDs).ToSql()
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if sql != tt.wantSQL {
+			t.Errorf("%s: got %q, want %q", tt.name, sql, tt.wantSQL)
+		}
+		if !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: got args %v, want %v", tt.name, args, tt.wantArgs)
+		}
+	}
+}
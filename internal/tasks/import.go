@@ -0,0 +1,453 @@
+package tasks
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// MaxImportRows is the maximum number of tasks in a single import
+const MaxImportRows = 20000
+
+// ImportRow is a task read from an import file. Row is the position of the task in the file,
+// starting at 1, the CSV header and blank NDJSON lines are not counted. Err is set if the row is
+// not valid
+type ImportRow struct {
+	Row  int
+	Task *Task
+	Err  error
+}
+
+// ImportResult is the outcome of an import
+type ImportResult struct {
+	DryRun   bool
+	Total    int
+	Valid    int
+	Imported int
+	// Failed are the rows which are not valid
+	Failed []ImportRow
+}
+
+// DecodeImport reads the tasks of a file in format, FormatCSV, FormatJSON (an array of tasks) or
+// FormatNDJSON (a task per line). Rows which cannot be read are returned with their error, the
+// returned error is for files which cannot be read at all
+func DecodeImport(r io.Reader, format string) ([]ImportRow, error) {
+	switch format {
+	case FormatCSV:
+		return decodeCSV(r)
+	case FormatJSON:
+		return decodeJSON(r)
+	case FormatNDJSON:
+		return decodeNDJSON(r)
+	}
+
+	return nil, errors.Validationf("unsupported format '%s', it should be one of csv, json, ndjson", format)
+}
+
+func decodeCSV(r io.Reader) ([]ImportRow, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, errors.Validation("the file is empty")
+	}
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid CSV provided")
+	}
+
+	known := make(map[string]string, len(CSVHeader))
+	for _, column := range CSVHeader {
+		known[strings.ToLower(column)] = column
+	}
+
+	columns := make([]string, 0, len(header))
+	seen := map[string]bool{}
+	for idx, name := range header {
+		if idx == 0 {
+			name = strings.TrimPrefix(name, "\ufeff")
+		}
+
+		column, ok := known[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, errors.Validationf("unknown column '%s'", name)
+		}
+		if seen[column] {
+			return nil, errors.Validationf("column '%s' is repeated", name)
+		}
+		seen[column] = true
+		columns = append(columns, column)
+	}
+
+	rows := []ImportRow{}
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if row > MaxImportRows {
+			return nil, errors.Validationf("an import cannot have more than %d tasks", MaxImportRows)
+		}
+
+		if err != nil {
+			perr, ok := err.(*csv.ParseError)
+			if !ok || perr.Err != csv.ErrFieldCount {
+				return nil, errors.InputBodyErr(err, "Invalid CSV provided")
+			}
+			rows = append(rows, ImportRow{
+				Row: row,
+				Err: errors.Validationf("expected %d fields, found %d", len(columns), len(record)),
+			})
+			continue
+		}
+
+		t, err := parseCSVRecord(columns, record)
+		rows = append(rows, ImportRow{Row: row, Task: t, Err: err})
+	}
+
+	return rows, nil
+}
+
+// parseCSVRecord reads a task from a CSV record, columns are the names of its fields
+func parseCSVRecord(columns []string, record []string) (*Task, error) {
+	t := new(Task)
+	r := new(Recurrence)
+	for idx, column := range columns {
+		value := strings.TrimSpace(record[idx])
+		if value == "" {
+			continue
+		}
+
+		var err error
+		switch column {
+		case "tid":
+			t.TID, err = parseCSVInt(column, value)
+		case "detail":
+			t.Detail = value
+		case "completeBy":
+			var completeBy *time.Time
+			completeBy, err = parseCSVTime(column, value)
+			if completeBy != nil {
+				t.CompleteBy = *completeBy
+			}
+		case "dueDate":
+			t.DueDate = value
+		case "dueTimezone":
+			t.DueTimezone = value
+		case "assignedTo":
+			t.AssignedTo = value
+		case "status":
+			t.Status = value
+		case "projectId":
+			t.ProjectID, err = parseCSVInt(column, value)
+		case "parentId":
+			t.ParentID, err = parseCSVInt(column, value)
+		case "tags":
+			t.Tags = strings.Split(value, ",")
+		case "priority":
+			t.Priority = value
+		case "completedAt":
+			t.CompletedAt, err = parseCSVTime(column, value)
+		case "recurrenceRule":
+			r.Rule = value
+		case "recurrenceTimezone":
+			r.Timezone = value
+		case "recurrenceStart":
+			r.Start, err = parseCSVTime(column, value)
+		case "createdAt":
+			t.CreatedAt, err = parseCSVTime(column, value)
+		case "updatedAt":
+			t.UpdatedAt, err = parseCSVTime(column, value)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if r.Rule != "" {
+		t.Recurrence = r
+	}
+
+	return t, nil
+}
+
+func parseCSVInt(column string, value string) (int64, error) {
+	v, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, errors.ValidationErrf(err, "invalid %s '%s'", column, value)
+	}
+	return v, nil
+}
+
+func parseCSVTime(column string, value string) (*time.Time, error) {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, errors.ValidationErrf(err, "invalid %s '%s', expected an RFC3339 time", column, value)
+	}
+	return &t, nil
+}
+
+func decodeJSON(r io.Reader) ([]ImportRow, error) {
+	dec := json.NewDecoder(r)
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid JSON provided")
+	}
+	if tok != json.Delim('[') {
+		return nil, errors.InputBody("Invalid JSON provided, expected an array of tasks")
+	}
+
+	rows := []ImportRow{}
+	for row := 1; dec.More(); row++ {
+		if row > MaxImportRows {
+			return nil, errors.Validationf("an import cannot have more than %d tasks", MaxImportRows)
+		}
+
+		t := new(Task)
+		err := dec.Decode(t)
+		if err != nil {
+			// a value of the wrong type is skipped by the decoder, the rest of the file can still be read
+			if _, ok := err.(*json.UnmarshalTypeError); !ok {
+				return nil, errors.InputBodyErrf(err, "Invalid JSON provided in task %d", row)
+			}
+			rows = append(rows, ImportRow{Row: row, Err: errors.ValidationErr(err, err.Error())})
+			continue
+		}
+		rows = append(rows, ImportRow{Row: row, Task: t})
+	}
+
+	_, err = dec.Token()
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid JSON provided")
+	}
+
+	return rows, nil
+}
+
+func decodeNDJSON(r io.Reader) ([]ImportRow, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+
+	rows := []ImportRow{}
+	row := 0
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		row++
+		if row > MaxImportRows {
+			return nil, errors.Validationf("an import cannot have more than %d tasks", MaxImportRows)
+		}
+
+		t := new(Task)
+		err := json.Unmarshal([]byte(line), t)
+		if err != nil {
+			rows = append(rows, ImportRow{Row: row, Err: errors.ValidationErr(err, err.Error())})
+			continue
+		}
+		rows = append(rows, ImportRow{Row: row, Task: t})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.InputBodyErr(err, "Invalid NDJSON provided")
+	}
+
+	return rows, nil
+}
+
+// Import creates the tasks of the rows on behalf of the user, who owns all of them. Tasks are only
+// imported if all the rows are valid, and not at all on a dry run. The IDs of the tasks in the
+// file are only used to keep subtasks under their parents, which should be part of the import
+// too. Tasks are written with COPY, so large imports do not need a round trip per task. imported,
+// if not nil, is called with the imported rows and the new IDs of their tasks, within the same
+// transaction
+func (ts *Tasks) Import(ctx context.Context, uid int64, rows []ImportRow, dryRun bool, imported func(ctx context.Context, rows []ImportRow) error) (*ImportResult, error) {
+	result := &ImportResult{
+		DryRun: dryRun,
+		Total:  len(rows),
+		Failed: []ImportRow{},
+	}
+
+	workflows := map[int64]*Workflow{}
+	byTID := map[int64]*Task{}
+	for idx := range rows {
+		row := &rows[idx]
+		if row.Err == nil {
+			row.Err = ts.prepareImport(ctx, uid, row.Task, workflows)
+			if row.Err != nil && errors.HasType(row.Err, errors.TypeInternal) {
+				return nil, row.Err
+			}
+		}
+
+		if row.Err == nil && row.Task.TID != 0 {
+			if byTID[row.Task.TID] != nil {
+				row.Err = errors.Validationf("tid %d is repeated", row.Task.TID)
+			} else {
+				byTID[row.Task.TID] = row.Task
+			}
+		}
+	}
+
+	valid := make([]Task, 0, len(rows))
+	validRows := make([]ImportRow, 0, len(rows))
+	for idx := range rows {
+		row := &rows[idx]
+		if row.Err == nil {
+			row.Err = validateImportParent(row.Task, byTID)
+		}
+
+		if row.Err != nil {
+			result.Failed = append(result.Failed, *row)
+			continue
+		}
+		valid = append(valid, *row.Task)
+		validRows = append(validRows, *row)
+	}
+
+	result.Valid = len(valid)
+	if dryRun || len(result.Failed) > 0 || len(valid) == 0 {
+		return result, nil
+	}
+
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		ids, err := ts.store.ReserveIDs(ctx, len(valid))
+		if err != nil {
+			return err
+		}
+
+		newIDs := make(map[int64]int64, len(valid))
+		for idx := range valid {
+			if valid[idx].TID != 0 {
+				newIDs[valid[idx].TID] = ids[idx]
+			}
+		}
+
+		now := time.Now()
+		events := make([]Event, 0, len(valid))
+		for idx := range valid {
+			t := &valid[idx]
+			t.TID = ids[idx]
+			t.ParentID = newIDs[t.ParentID]
+			validRows[idx].Task = t
+
+			changes, err := diff(nil, t)
+			if err != nil {
+				return err
+			}
+			events = append(events, Event{
+				TID:       t.TID,
+				OwnerUID:  uid,
+				ActorUID:  uid,
+				Action:    EventImport,
+				Diff:      changes,
+				CreatedAt: &now,
+			})
+		}
+
+		err = ts.store.CopyTasks(ctx, valid)
+		if err != nil {
+			return err
+		}
+
+		err = ts.store.CopyEvents(ctx, events)
+		if err != nil || imported == nil {
+			return err
+		}
+
+		return imported(ctx, validRows)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result.Imported = len(valid)
+	return result, nil
+}
+
+// prepareImport validates a task of an import and sets its defaults, like create does
+func (ts *Tasks) prepareImport(ctx context.Context, uid int64, t *Task, workflows map[int64]*Workflow) error {
+	t.UID = uid
+	t.SeriesID = 0
+	t.Progress = nil
+	t.Blocked = false
+	t.CommentCount = 0
+	t.TimeSpent = 0
+	t.DeletedAt = nil
+	t.init()
+
+	err := t.initDue()
+	if err != nil {
+		return err
+	}
+
+	err = t.initRecurrence()
+	if err != nil {
+		return err
+	}
+
+	w, ok := workflows[t.ProjectID]
+	if !ok {
+		w, err = ts.Workflow(ctx, t.ProjectID)
+		if err != nil {
+			return err
+		}
+		workflows[t.ProjectID] = w
+	}
+
+	err = w.initStatus(t)
+	if err != nil {
+		return err
+	}
+	if !w.IsCompleted(t.Status) {
+		t.CompletedAt = nil
+	}
+
+	err = t.initAssignees()
+	if err != nil {
+		return err
+	}
+
+	err = t.initTags()
+	if err != nil {
+		return err
+	}
+
+	return t.initPriority()
+}
+
+// validateImportParent checks if the parent of an imported task is part of the import, and the
+// hierarchy it is in is valid
+func validateImportParent(t *Task, byTID map[int64]*Task) error {
+	if t.ParentID == 0 {
+		return nil
+	}
+
+	depth := 1
+	seen := map[int64]bool{t.TID: true}
+	for parentID := t.ParentID; parentID != 0; parentID = byTID[parentID].ParentID {
+		if byTID[parentID] == nil {
+			return errors.Validationf("parent %d is not part of the import", parentID)
+		}
+		if seen[parentID] {
+			return errors.Validation("a task cannot be a subtask of its own subtask")
+		}
+		seen[parentID] = true
+
+		depth++
+		if depth > MaxDepth {
+			return errors.Validationf("tasks cannot be nested more than %d levels deep", MaxDepth)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgtype"
+	"github.com/jackc/pgx/v4"
+)
+
+// copyColumns are the columns of the tasks written by CopyTasks. COPY quotes them, so they are
+// in lowercase like the unquoted names in the schema
+var copyColumns = []string{
+	"id",
+	"uid",
+	"detail",
+	"assignedto",
+	"completeby",
+	"allday",
+	"duetimezone",
+	"recurrencerule",
+	"recurrencetimezone",
+	"recurrencestart",
+	"status",
+	"projectid",
+	"parentid",
+	"tags",
+	"priority",
+	"completedat",
+	"createdat",
+	"updatedat",
+}
+
+// ReserveIDs returns n new task IDs, so tasks can be copied along with their members and events
+func (ts *taskStore) ReserveIDs(ctx context.Context, n int) ([]int64, error) {
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(
+		ctx,
+		`SELECT nextval(pg_get_serial_sequence('tasks', 'id')) FROM generate_series(1, $1)`,
+		n,
+	)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	ids := make([]int64, 0, n)
+	for rows.Next() {
+		id := int64(0)
+		err := rows.Scan(&id)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ids, nil
+}
+
+// CopyTasks writes tasks with reserved IDs using COPY, along with their owners and assignees as
+// members
+func (ts *taskStore) CopyTasks(ctx context.Context, list []Task) error {
+	conn := datastore.Conn(ctx, ts.pqdriver)
+
+	ids := make([]int64, 0, len(list))
+	members := make([][]interface{}, 0, len(list))
+	_, err := conn.CopyFrom(ctx, pgx.Identifier{ts.tableName}, copyColumns, pgx.CopyFromSlice(len(list), func(idx int) ([]interface{}, error) {
+		t := &list[idx]
+		ids = append(ids, t.TID)
+		for _, email := range t.Assignees {
+			members = append(members, []interface{}{t.TID, email, RoleAssignee, t.CreatedAt})
+		}
+
+		rule, timezone, start := recurrenceColumns(t.Recurrence)
+		return []interface{}{
+			t.TID, t.UID, t.Detail, t.AssignedTo, nullTime(t.CompleteBy), t.AllDay(), nullString(t.DueTimezone), rule, timezone, start,
+			t.Status, nullInt64(t.ProjectID), nullInt64(t.ParentID), tagsColumn(t.Tags), nullString(t.Priority), t.CompletedAt, t.CreatedAt, t.UpdatedAt,
+		}, nil
+	}))
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = conn.CopyFrom(
+		ctx,
+		pgx.Identifier{membersTable},
+		[]string{"tid", "email", "role", "createdat"},
+		pgx.CopyFromRows(members),
+	)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	// the owner may also be an assignee, like AddOwner the owner role wins
+	query := `INSERT INTO ` + membersTable + ` (tid, email, role, createdAt)
+	SELECT t.id, lower(u.email), $1, t.createdAt FROM tasks t JOIN users u ON u.id = t.uid WHERE t.id = ANY($2)
+	ON CONFLICT (tid, email) DO UPDATE SET role = EXCLUDED.role`
+	_, err = conn.Exec(ctx, query, RoleOwner, ids)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// CopyEvents writes events using COPY
+func (ts *taskStore) CopyEvents(ctx context.Context, events []Event) error {
+	_, err := datastore.Conn(ctx, ts.pqdriver).CopyFrom(
+		ctx,
+		pgx.Identifier{eventsTable},
+		[]string{"tid", "owneruid", "actoruid", "action", "diff", "createdat"},
+		pgx.CopyFromSlice(len(events), func(idx int) ([]interface{}, error) {
+			e := &events[idx]
+			diff, err := json.Marshal(e.Diff)
+			if err != nil {
+				return nil, err
+			}
+
+			return []interface{}{
+				e.TID, e.OwnerUID, e.ActorUID, e.Action,
+				&pgtype.JSONB{Bytes: diff, Status: pgtype.Present},
+				e.CreatedAt,
+			}, nil
+		}),
+	)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,249 @@
+package tasks
+
+import (
+	"context"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// RoleOwner is the user who created the task
+	RoleOwner = "owner"
+	// RoleAssignee is someone responsible for completing the task
+	RoleAssignee = "assignee"
+	// RoleWatcher is someone notified of the changes to the task
+	RoleWatcher = "watcher"
+	// MaxAssignees is the maximum number of people a task can be assigned to at once
+	MaxAssignees = 50
+)
+
+// memberRoles are the roles someone can be given, the owner is always the user who created the task
+var memberRoles = map[string]bool{
+	RoleAssignee: true,
+	RoleWatcher:  true,
+}
+
+// Member is someone sharing a task. Members are identified by their email address, so people who
+// have not registered yet can be members too, UID is set once they register
+type Member struct {
+	ID        int64      `json:"id,omitempty"`
+	TID       int64      `json:"tid,omitempty"`
+	UID       int64      `json:"uid,omitempty"`
+	Email     string     `json:"email"`
+	Role      string     `json:"role"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+func (m *Member) Sanitize() {
+	m.Email = strings.ToLower(strings.TrimSpace(m.Email))
+	m.Role = strings.ToLower(strings.TrimSpace(m.Role))
+}
+
+func (m *Member) Validate() error {
+	err := validateEmail(m.Email)
+	if err != nil {
+		return err
+	}
+
+	if !memberRoles[m.Role] {
+		return errors.Validationf("invalid role '%s', it should be one of assignee, watcher", m.Role)
+	}
+
+	return nil
+}
+
+func validateEmail(email string) error {
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Address != email {
+		return errors.Validationf("invalid email address '%s'", email)
+	}
+
+	return nil
+}
+
+// reassigned returns the new assignee of the edited task t, or an empty string if its assignee did
+// not change. The new assignee is normalized in t
+func reassigned(before *Task, t *Task) (string, error) {
+	email := strings.ToLower(strings.TrimSpace(t.AssignedTo))
+	if email == "" || strings.EqualFold(email, strings.TrimSpace(before.AssignedTo)) {
+		return "", nil
+	}
+
+	err := validateEmail(email)
+	if err != nil {
+		return "", err
+	}
+
+	t.AssignedTo = email
+	return email, nil
+}
+
+// addAssignee makes the new assignee of a task a member of it, nothing is done if email is empty
+func (ts *Tasks) addAssignee(ctx context.Context, tid int64, email string, createdAt time.Time) error {
+	if email == "" {
+		return nil
+	}
+
+	return ts.store.AddMember(ctx, &Member{
+		TID:       tid,
+		Email:     email,
+		Role:      RoleAssignee,
+		CreatedAt: &createdAt,
+	})
+}
+
+// initAssignees makes Assignees and AssignedTo consistent, AssignedTo being the first assignee
+func (t *Task) initAssignees() error {
+	seen := map[string]bool{}
+	assignees := make([]string, 0, len(t.Assignees)+1)
+	for _, email := range append([]string{t.AssignedTo}, t.Assignees...) {
+		email = strings.ToLower(strings.TrimSpace(email))
+		if email == "" || seen[email] {
+			continue
+		}
+
+		err := validateEmail(email)
+		if err != nil {
+			return err
+		}
+
+		seen[email] = true
+		assignees = append(assignees, email)
+	}
+
+	if len(assignees) > MaxAssignees {
+		return errors.Validationf("a task cannot have more than %d assignees", MaxAssignees)
+	}
+
+	t.Assignees = assignees
+	t.AssignedTo = strings.TrimSpace(t.AssignedTo)
+	if t.AssignedTo == "" && len(assignees) > 0 {
+		t.AssignedTo = assignees[0]
+	}
+
+	return nil
+}
+
+// addMembers adds the owner and the assignees of a newly created task as its members
+func (ts *Tasks) addMembers(ctx context.Context, t *Task) error {
+	if t.UID != 0 {
+		err := ts.store.AddOwner(ctx, t.TID, t.UID, *t.CreatedAt)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, email := range t.Assignees {
+		err := ts.store.AddMember(ctx, &Member{
+			TID:       t.TID,
+			Email:     email,
+			Role:      RoleAssignee,
+			CreatedAt: t.CreatedAt,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// copyMembers makes the members of task from members of task to, with the same roles
+func (ts *Tasks) copyMembers(ctx context.Context, from int64, to int64, createdAt time.Time) error {
+	members, err := ts.store.GetMembers(ctx, from, "")
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		m.TID = to
+		m.CreatedAt = &createdAt
+		err = ts.store.AddMember(ctx, &m)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// AddMember adds someone to a task as an assignee or a watcher, or changes the role of an existing
+// member. The role of the owner cannot be changed
+func (ts *Tasks) AddMember(ctx context.Context, tid int64, m *Member) (*Member, error) {
+	m.Sanitize()
+	err := m.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ts.store.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	m.TID = tid
+	m.CreatedAt = &now
+	err = ts.store.AddMember(ctx, m)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.store.GetMember(ctx, tid, m.Email)
+}
+
+// RemoveMember removes someone from a task, the owner cannot be removed
+func (ts *Tasks) RemoveMember(ctx context.Context, tid int64, mid int64) error {
+	members, err := ts.Members(ctx, tid)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range members {
+		if m.ID != mid {
+			continue
+		}
+
+		if m.Role == RoleOwner {
+			return errors.Validation("the owner of a task cannot be removed")
+		}
+		return ts.store.RemoveMember(ctx, tid, mid)
+	}
+
+	return errors.NotFound("member not found")
+}
+
+// Members returns the members of a task, the owner first
+func (ts *Tasks) Members(ctx context.Context, tid int64) ([]Member, error) {
+	_, err := ts.store.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	return ts.store.GetMembers(ctx, tid, "")
+}
+
+// Roles returns the roles of a user on a task, whether or not the task is in the trash
+func (ts *Tasks) Roles(ctx context.Context, tid int64, uid int64) ([]string, error) {
+	members, err := ts.store.GetMembers(ctx, tid, "")
+	if err != nil {
+		return nil, err
+	}
+
+	roles := []string{}
+	for _, m := range members {
+		if m.UID != 0 && m.UID == uid {
+			roles = append(roles, m.Role)
+		}
+	}
+
+	return roles, nil
+}
+
+// Watchers returns the watchers of a task
+func (ts *Tasks) Watchers(ctx context.Context, tid int64) ([]Member, error) {
+	return ts.store.GetMembers(ctx, tid, RoleWatcher)
+}
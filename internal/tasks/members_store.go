@@ -0,0 +1,178 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+const membersTable = "task_members"
+
+var memberColumns = []string{
+	"m.id",
+	"m.tid",
+	"COALESCE(u.id, 0)",
+	"m.email",
+	"m.role",
+	"m.createdAt",
+}
+
+// memberOf matches the tasks which the user is a member of
+func memberOf(uid int64) squirrel.Sqlizer {
+	return squirrel.Expr(
+		"id IN (SELECT m.tid FROM "+membersTable+" m JOIN users u ON lower(u.email) = m.email WHERE u.id = ?)",
+		uid,
+	)
+}
+
+// AddMember adds a member, or changes the role of an existing one unless they are the owner
+func (ts *taskStore) AddMember(ctx context.Context, m *Member) error {
+	query, args, err := ts.qbuilder.Insert(membersTable).SetMap(map[string]interface{}{
+		"tid":       m.TID,
+		"email":     m.Email,
+		"role":      m.Role,
+		"createdAt": m.CreatedAt,
+	}).Suffix(
+		"ON CONFLICT (tid, email) DO UPDATE SET role = EXCLUDED.role WHERE "+membersTable+".role <> ?",
+		RoleOwner,
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// AddOwner adds the user uid as the owner of the task
+func (ts *taskStore) AddOwner(ctx context.Context, tid int64, uid int64, createdAt time.Time) error {
+	query := `INSERT INTO ` + membersTable + ` (tid, email, role, createdAt)
+	SELECT $1, lower(email), $2, $3 FROM users WHERE id = $4
+	ON CONFLICT (tid, email) DO UPDATE SET role = EXCLUDED.role`
+	args := []interface{}{tid, RoleOwner, createdAt, uid}
+
+	_, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ts *taskStore) RemoveMember(ctx context.Context, tid int64, mid int64) error {
+	query, args, err := ts.qbuilder.Delete(membersTable).Where(squirrel.Eq{
+		"id":  mid,
+		"tid": tid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("member not found")
+	}
+
+	return nil
+}
+
+func (ts *taskStore) GetMember(ctx context.Context, tid int64, email string) (*Member, error) {
+	query, args, err := ts.membersQuery().Where(squirrel.Eq{
+		"m.tid":   tid,
+		"m.email": email,
+	}).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	m, err := scanMember(datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("member not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return m, nil
+}
+
+// GetMembers returns the members of the task with the role, or all of them if role is empty
+func (ts *taskStore) GetMembers(ctx context.Context, tid int64, role string) ([]Member, error) {
+	where := squirrel.Eq{
+		"m.tid": tid,
+	}
+	if role != "" {
+		where["m.role"] = role
+	}
+
+	query, args, err := ts.membersQuery().Where(where).OrderBy(
+		"m.role = '"+RoleOwner+"' DESC",
+		"m.createdAt",
+		"m.id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Member{}
+	for rows.Next() {
+		m, err := scanMember(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *m)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+// membersQuery selects memberColumns, resolving the user ID of the members who have registered
+func (ts *taskStore) membersQuery() squirrel.SelectBuilder {
+	return ts.qbuilder.Select(
+		memberColumns...,
+	).From(
+		membersTable + " m",
+	).LeftJoin(
+		"users u ON lower(u.email) = m.email",
+	)
+}
+
+func scanMember(row pgx.Row) (*Member, error) {
+	m := new(Member)
+	err := row.Scan(
+		&m.ID,
+		&m.TID,
+		&m.UID,
+		&m.Email,
+		&m.Role,
+		&m.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"testing"
+
+	"github.com/bnkamalesh/errors"
+)
+
+func TestReassigned(t *testing.T) {
+	tests := []struct {
+		name       string
+		before     string
+		assignedTo string
+		want       string
+		wantErr    bool
+	}{
+		{name: "same assignee", before: "alice@example.com", assignedTo: "alice@example.com", want: ""},
+		{name: "same assignee, other case", before: "alice@example.com", assignedTo: " Alice@Example.com ", want: ""},
+		{name: "not assigned", before: "alice@example.com", assignedTo: "", want: ""},
+		{name: "first assignee", before: "", assignedTo: "bob@example.com", want: "bob@example.com"},
+		{name: "new assignee", before: "alice@example.com", assignedTo: " Bob@Example.com", want: "bob@example.com"},
+		{name: "invalid assignee", before: "alice@example.com", assignedTo: "bob", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		task := &Task{AssignedTo: tt.assignedTo}
+		got, err := reassigned(&Task{AssignedTo: tt.before}, task)
+		if tt.wantErr {
+			if !errors.HasType(err, errors.TypeValidation) {
+				t.Errorf("%s: got error %v, want a validation error", tt.name, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+		if got != "" && task.AssignedTo != got {
+			t.Errorf("%s: got assignedTo %q, want %q", tt.name, task.AssignedTo, got)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// Move moves a task along with its subtasks to another project, 0 moves them out of any project.
+// Tasks whose status is not part of the workflow of the new project are reset to its initial status
+func (ts *Tasks) Move(ctx context.Context, tid int64, projectID int64, actorUID int64) (*Task, error) {
+	var moved *Task
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		subtree, err := ts.store.Subtree(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		w, err := ts.Workflow(ctx, projectID)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for idx := range subtree {
+			before := subtree[idx]
+			if before.TID == tid && before.ParentID != 0 {
+				return errors.Validation("subtasks are moved along with their parent task")
+			}
+
+			if before.ProjectID == projectID {
+				continue
+			}
+
+			status, completedAt := before.Status, before.CompletedAt
+			if !w.HasStatus(status) {
+				status, completedAt = w.Initial, nil
+			}
+
+			err = ts.store.Move(ctx, before.TID, projectID, status, completedAt, now)
+			if err != nil {
+				return err
+			}
+
+			after, err := ts.store.Get(ctx, before.TID)
+			if err != nil {
+				return err
+			}
+
+			err = ts.recordEvent(ctx, EventEdit, actorUID, &before, after)
+			if err != nil {
+				return err
+			}
+		}
+
+		moved, err = ts.store.Get(ctx, tid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return moved, nil
+}
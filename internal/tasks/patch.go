@@ -0,0 +1,307 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/platform/jsonpatch"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// patchable are the fields of a task which can be patched, by their JSON name. The fields which
+// cannot be null are false
+var patchable = map[string]bool{
+	"uid":         false,
+	"detail":      false,
+	"assignedTo":  false,
+	"completeBy":  true,
+	"dueDate":     true,
+	"dueTimezone": true,
+	"recurrence":  true,
+	"parentId":    true,
+	"tags":        true,
+	"priority":    true,
+}
+
+// Patch is a partial update of a task, either a JSON Merge Patch (RFC 7396) or a JSON Patch
+// (RFC 6902). Only the fields it changes are updated, and null clears a field
+type Patch struct {
+	merge map[string]interface{}
+	ops   []jsonpatch.Operation
+}
+
+// NewMergePatch returns the patch of a JSON Merge Patch, an object of the fields to change
+func NewMergePatch(body []byte) (*Patch, error) {
+	doc, err := jsonpatch.Unmarshal(body)
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "invalid JSON provided")
+	}
+
+	merge, ok := doc.(map[string]interface{})
+	if !ok {
+		return nil, errors.Validation("a merge patch should be a JSON object")
+	}
+
+	return &Patch{merge: merge}, nil
+}
+
+// NewJSONPatch returns the patch of a JSON Patch, a list of operations
+func NewJSONPatch(body []byte) (*Patch, error) {
+	ops := []jsonpatch.Operation{}
+	err := json.Unmarshal(body, &ops)
+	if err != nil {
+		return nil, errors.InputBodyErr(err, "invalid JSON provided, a JSON patch should be a list of operations")
+	}
+
+	return &Patch{ops: ops}, nil
+}
+
+// fields returns the fields changed by the patch, it returns an error if any of them cannot be
+// patched
+func (p *Patch) fields() (map[string]bool, error) {
+	paths := []string{}
+	if p.ops == nil {
+		for field := range p.merge {
+			paths = append(paths, "/"+field)
+		}
+	}
+	for _, op := range p.ops {
+		switch op.Op {
+		case jsonpatch.OpTest:
+			continue
+		case jsonpatch.OpMove:
+			paths = append(paths, op.From)
+		}
+		paths = append(paths, op.Path)
+	}
+
+	fields := map[string]bool{}
+	for _, path := range paths {
+		tokens, err := jsonpatch.ParsePointer(path)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			return nil, errors.Validation("the whole task cannot be replaced, use PUT instead")
+		}
+
+		field := tokens[0]
+		if _, ok := patchable[field]; !ok {
+			return nil, errors.Validationf("%s cannot be patched", field)
+		}
+		fields[field] = true
+	}
+
+	return fields, nil
+}
+
+// patchDocument returns the fields of the task which can be patched as a JSON document, the
+// fields which are not set are null
+func (t *Task) patchDocument() (map[string]interface{}, error) {
+	b, err := json.Marshal(t)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	doc, err := jsonpatch.Unmarshal(b)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	all, _ := doc.(map[string]interface{})
+	fields := make(map[string]interface{}, len(patchable))
+	for field := range patchable {
+		fields[field] = all[field]
+	}
+	if t.CompleteBy.IsZero() {
+		fields["completeBy"] = nil
+	}
+
+	return fields, nil
+}
+
+// apply returns a copy of the task with the fields changed by the patch
+func (p *Patch) apply(t *Task, fields map[string]bool) (*Task, error) {
+	doc, err := t.patchDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	var patched interface{}
+	if p.ops != nil {
+		patched, err = jsonpatch.Apply(doc, p.ops)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		patched = jsonpatch.MergePatch(doc, p.merge)
+	}
+
+	result, _ := patched.(map[string]interface{})
+	for field := range fields {
+		if result[field] == nil && !patchable[field] {
+			return nil, errors.Validationf("%s cannot be null", field)
+		}
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	values := new(Task)
+	err = json.Unmarshal(b, values)
+	if err != nil {
+		return nil, errors.ValidationErrf(err, "invalid patch, %s", err.Error())
+	}
+
+	edited := *t
+	for field := range fields {
+		switch field {
+		case "uid":
+			edited.UID = values.UID
+		case "detail":
+			edited.Detail = values.Detail
+		case "assignedTo":
+			edited.AssignedTo = values.AssignedTo
+		case "completeBy":
+			edited.CompleteBy = values.CompleteBy
+		case "dueDate":
+			edited.DueDate = values.DueDate
+		case "dueTimezone":
+			edited.DueTimezone = values.DueTimezone
+		case "recurrence":
+			edited.Recurrence = values.Recurrence
+		case "parentId":
+			edited.ParentID = values.ParentID
+		case "tags":
+			edited.Tags = values.Tags
+		case "priority":
+			edited.Priority = values.Priority
+		}
+	}
+
+	// a due time makes an all-day task due at that time, unless its date is patched as well
+	if fields["completeBy"] && !fields["dueDate"] {
+		edited.DueDate = ""
+	}
+
+	return &edited, nil
+}
+
+// Patch updates the fields of a task changed by the patch, the other fields are left as they
+// are. If version is not 0, the task is only patched if it is still at that version, otherwise
+// ErrVersionMismatch is returned. authorize, if not nil, is called with the patched task before
+// it is saved and fails the patch if it returns an error
+func (ts *Tasks) Patch(ctx context.Context, tid int64, p *Patch, version int64, actorUID int64, authorize func(ctx context.Context, t *Task) error) (*Task, error) {
+	fields, err := p.fields()
+	if err != nil {
+		return nil, err
+	}
+
+	var after *Task
+	err = datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		// the task is locked even without a version, so it cannot change between being read and
+		// being patched
+		current, err := ts.store.LockVersion(ctx, tid)
+		if err != nil {
+			return err
+		}
+		if version != 0 && current != version {
+			return ErrVersionMismatch
+		}
+
+		before, err := ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		t, err := p.apply(before, fields)
+		if err != nil {
+			return err
+		}
+
+		if len(fields) == 0 {
+			// only tests, nothing to update
+			after = before
+			return nil
+		}
+
+		err = ts.initPatch(ctx, tid, t, fields)
+		if err != nil {
+			return err
+		}
+
+		if authorize != nil {
+			err = authorize(ctx, t)
+			if err != nil {
+				return err
+			}
+		}
+
+		assignee, err := reassigned(before, t)
+		if err != nil {
+			return err
+		}
+
+		err = ts.store.Patch(ctx, tid, t, fields)
+		if err != nil {
+			return err
+		}
+
+		err = ts.addAssignee(ctx, tid, assignee, *t.UpdatedAt)
+		if err != nil {
+			return err
+		}
+
+		after, err = ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		return ts.recordEvent(ctx, EventEdit, actorUID, before, after)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return after, nil
+}
+
+// initPatch validates the fields of a patched task, like Edit does for the whole task
+func (ts *Tasks) initPatch(ctx context.Context, tid int64, t *Task, fields map[string]bool) error {
+	now := time.Now()
+	t.UpdatedAt = &now
+	if t.Recurrence != nil {
+		t.Recurrence.Sanitize()
+	}
+
+	err := t.initDue()
+	if err != nil {
+		return err
+	}
+
+	err = t.initTags()
+	if err != nil {
+		return err
+	}
+
+	err = t.initPriority()
+	if err != nil {
+		return err
+	}
+
+	err = t.Validate()
+	if err != nil {
+		return err
+	}
+
+	if fields["parentId"] {
+		return ts.validateParent(ctx, tid, t.ParentID)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	PriorityLow    = "low"
+	PriorityMedium = "medium"
+	PriorityHigh   = "high"
+	PriorityUrgent = "urgent"
+)
+
+// Priorities are the priorities a task can have, from the lowest to the highest. Tasks without a
+// priority have none of them
+var Priorities = []string{
+	PriorityLow,
+	PriorityMedium,
+	PriorityHigh,
+	PriorityUrgent,
+}
+
+// IsPriority returns true if the name is one of Priorities
+func IsPriority(name string) bool {
+	for _, p := range Priorities {
+		if p == name {
+			return true
+		}
+	}
+	return false
+}
+
+// initPriority makes the priority of the task lowercase, and checks it is one of Priorities
+func (t *Task) initPriority() error {
+	t.Priority = strings.ToLower(strings.TrimSpace(t.Priority))
+	if t.Priority != "" && !IsPriority(t.Priority) {
+		return errors.Validationf("'%s' is not a priority, it should be one of %s", t.Priority, strings.Join(Priorities, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,208 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/recurrence"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// occurrenceBatchSize is the maximum number of occurrences created per run of MaterializeDue
+	occurrenceBatchSize = 100
+)
+
+// Recurrence makes a task repeat. Every occurrence is stored as a separate task, and the next
+// occurrence is created once the current one is due
+type Recurrence struct {
+	// Rule is an iCalendar RRULE (e.g. "FREQ=WEEKLY;BYDAY=MO") or a cron expression (e.g. "0 9 * * 1")
+	Rule string `json:"rule,omitempty"`
+	// Timezone is the IANA timezone in which the rule is evaluated, defaults to UTC
+	Timezone string `json:"timezone,omitempty"`
+	// Start is the first possible occurrence, defaults to CompleteBy of the first task of the series
+	Start *time.Time `json:"start,omitempty"`
+}
+
+// RecurrenceConfig holds the configuration of the background scheduler which creates occurrences
+type RecurrenceConfig struct {
+	Interval time.Duration
+}
+
+func (r *Recurrence) Sanitize() {
+	r.Rule = strings.TrimSpace(r.Rule)
+	r.Timezone = strings.TrimSpace(r.Timezone)
+}
+
+func (r *Recurrence) Validate() error {
+	_, err := r.parse(time.Now())
+	return err
+}
+
+// parse returns the parsed rule, using fallbackStart if the recurrence has no start
+func (r *Recurrence) parse(fallbackStart time.Time) (recurrence.Rule, error) {
+	start := fallbackStart
+	if r.Start != nil {
+		start = *r.Start
+	}
+
+	return recurrence.Parse(r.Rule, r.Timezone, start)
+}
+
+// PreviewRecurrence returns the next n occurrences of r after the given time
+func PreviewRecurrence(r *Recurrence, after time.Time, n int) ([]time.Time, error) {
+	r.Sanitize()
+	rule, err := r.parse(after)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, err := recurrence.LoadLocation(r.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	list := recurrence.Preview(rule, after, n)
+	for idx := range list {
+		list[idx] = list[idx].In(loc)
+	}
+
+	return list, nil
+}
+
+// initRecurrence anchors the recurrence of a new task, and sets CompleteBy to the first
+// occurrence if the task has no due date
+func (t *Task) initRecurrence() error {
+	if t.Recurrence == nil {
+		return nil
+	}
+
+	t.Recurrence.Sanitize()
+	if t.Recurrence.Start == nil {
+		start := t.CompleteBy
+		if start.IsZero() {
+			start = time.Now()
+		}
+		t.Recurrence.Start = &start
+	}
+
+	rule, err := t.Recurrence.parse(*t.Recurrence.Start)
+	if err != nil {
+		return err
+	}
+
+	if t.CompleteBy.IsZero() {
+		next, ok := rule.Next(t.Recurrence.Start.Add(-time.Nanosecond))
+		if !ok {
+			return errors.Validation("recurrence rule has no occurrences")
+		}
+		t.CompleteBy = next
+	}
+
+	return nil
+}
+
+// MaterializeDue creates the next occurrence of every recurring task which is due
+func (ts *Tasks) MaterializeDue(ctx context.Context) error {
+	now := time.Now()
+	due, err := ts.store.DueOccurrences(ctx, now, occurrenceBatchSize)
+	if err != nil {
+		return err
+	}
+
+	for idx := range due {
+		_, err = ts.materialize(ctx, &due[idx], now)
+		if err != nil {
+			ts.logHandler.Error(err)
+		}
+	}
+
+	return nil
+}
+
+// materialize creates the occurrence following t. It returns nil if the series has ended
+func (ts *Tasks) materialize(ctx context.Context, t *Task, now time.Time) (*Task, error) {
+	if t.Recurrence == nil {
+		return nil, nil
+	}
+
+	occurrence, err := t.nextOccurrence(now)
+	if err != nil {
+		return nil, err
+	}
+	if occurrence == nil {
+		return nil, ts.store.EndSeries(ctx, t.TID)
+	}
+
+	occurrence.init()
+	err = ts.initStatus(ctx, occurrence)
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		id, err := ts.store.CreateOccurrence(ctx, t.TID, occurrence)
+		if err != nil {
+			return err
+		}
+		occurrence.TID = id
+
+		err = ts.copyMembers(ctx, t.TID, occurrence.TID, *occurrence.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		return ts.recordEvent(ctx, EventCreate, 0, nil, occurrence)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return occurrence, nil
+}
+
+// nextOccurrence returns the occurrence following t, which is not stored yet. It returns nil if
+// the series has ended
+func (t *Task) nextOccurrence(now time.Time) (*Task, error) {
+	rule, err := t.Recurrence.parse(t.CompleteBy)
+	if err != nil {
+		return nil, err
+	}
+
+	// occurrences missed while the scheduler was not running are skipped
+	after := t.CompleteBy
+	if now.After(after) {
+		after = now
+	}
+
+	next, ok := rule.Next(after)
+	if !ok {
+		return nil, nil
+	}
+
+	seriesID := t.SeriesID
+	if seriesID == 0 {
+		seriesID = t.TID
+	}
+
+	occurrence := &Task{
+		UID:         t.UID,
+		Detail:      t.Detail,
+		AssignedTo:  t.AssignedTo,
+		CompleteBy:  next,
+		DueTimezone: t.DueTimezone,
+		Recurrence:  t.Recurrence,
+		SeriesID:    seriesID,
+		ProjectID:   t.ProjectID,
+		ParentID:    t.ParentID,
+		Tags:        append([]string(nil), t.Tags...),
+		Priority:    t.Priority,
+	}
+	// occurrences of all-day tasks are all-day too
+	occurrence.localizeDue(t.AllDay())
+
+	return occurrence, nil
+}
@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNextOccurrence(t *testing.T) {
+	due := time.Date(2024, 6, 3, 9, 0, 0, 0, time.UTC)
+	task := &Task{
+		TID:         7,
+		UID:         1,
+		Detail:      "Weekly review",
+		AssignedTo:  "jane@example.com",
+		CompleteBy:  due,
+		DueTimezone: "UTC",
+		Recurrence:  &Recurrence{Rule: "FREQ=WEEKLY;BYDAY=MO", Start: &due},
+		ProjectID:   3,
+		ParentID:    5,
+		Tags:        []string{"review", "weekly"},
+		Priority:    PriorityHigh,
+	}
+
+	occurrence, err := task.nextOccurrence(due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if occurrence == nil {
+		t.Fatal("got no occurrence, want the next one")
+	}
+
+	want := due.AddDate(0, 0, 7)
+	if !occurrence.CompleteBy.Equal(want) {
+		t.Errorf("got due %v, want %v", occurrence.CompleteBy, want)
+	}
+	if occurrence.SeriesID != task.TID {
+		t.Errorf("got series %d, want %d", occurrence.SeriesID, task.TID)
+	}
+	if occurrence.UID != task.UID || occurrence.Detail != task.Detail || occurrence.AssignedTo != task.AssignedTo {
+		t.Errorf("got %+v, want the owner, detail and assignee of %+v", occurrence, task)
+	}
+	if occurrence.ProjectID != task.ProjectID || occurrence.ParentID != task.ParentID {
+		t.Errorf("got project %d and parent %d, want %d and %d", occurrence.ProjectID, occurrence.ParentID, task.ProjectID, task.ParentID)
+	}
+	if !reflect.DeepEqual(occurrence.Tags, task.Tags) {
+		t.Errorf("got tags %v, want %v", occurrence.Tags, task.Tags)
+	}
+	if occurrence.Priority != task.Priority {
+		t.Errorf("got priority %q, want %q", occurrence.Priority, task.Priority)
+	}
+
+	occurrence.Tags[0] = "changed"
+	if task.Tags[0] != "review" {
+		t.Errorf("tags of the occurrence are shared with the previous one")
+	}
+
+	// the series ends with the last occurrence
+	task.Recurrence.Rule = "FREQ=WEEKLY;BYDAY=MO;COUNT=1"
+	occurrence, err = task.nextOccurrence(due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if occurrence != nil {
+		t.Errorf("got occurrence due %v, want none", occurrence.CompleteBy)
+	}
+}
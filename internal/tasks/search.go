@@ -0,0 +1,60 @@
+package tasks
+
+import (
+	"context"
+	"html"
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// highlightStart and highlightStop delimit the matches in the headline returned by the store.
+	// They are control characters so they cannot be confused with the text of a task
+	highlightStart = "\x02"
+	highlightStop  = "\x03"
+)
+
+// SearchResult is a task matching a search, Headline is an HTML snippet of its detail with the
+// matching words wrapped in <mark>
+type SearchResult struct {
+	Task
+	Rank     float32 `json:"rank"`
+	Headline string  `json:"headline"`
+}
+
+// Search returns up to limit tasks which match the query, best match first, out of the tasks the
+// user owns or is a member of and the tasks of projectIDs. The query supports the web search
+// syntax: quoted phrases, 'or' and '-'
+func (ts *Tasks) Search(ctx context.Context, uid int64, projectIDs []int64, q string, limit uint64) ([]SearchResult, error) {
+	q = strings.TrimSpace(q)
+	if q == "" {
+		return nil, errors.Validation("search query is required")
+	}
+
+	if limit == 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+
+	results, err := ts.store.Search(ctx, uid, projectIDs, q, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Headline = highlight(results[i].Headline)
+	}
+
+	return results, nil
+}
+
+// highlight escapes the headline and replaces the match delimiters with <mark> tags
+func highlight(headline string) string {
+	return strings.NewReplacer(
+		highlightStart, "<mark>",
+		highlightStop, "</mark>",
+	).Replace(html.EscapeString(headline))
+}
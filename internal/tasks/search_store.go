@@ -0,0 +1,67 @@
+package tasks
+
+import (
+	"context"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+)
+
+const headlineOptions = "StartSel=" + highlightStart + ", StopSel=" + highlightStop + ", MaxFragments=3, MaxWords=20, MinWords=5"
+
+func (ts *taskStore) Search(ctx context.Context, uid int64, projectIDs []int64, q string, limit uint64) ([]SearchResult, error) {
+	columns := append(
+		append([]string{}, taskColumns...),
+		"ts_rank(searchVector, q) AS rank",
+	)
+
+	query, args, err := ts.qbuilder.Select(
+		columns...,
+	).Column(
+		"ts_headline('english', coalesce(detail, ''), q, ?) AS headline", headlineOptions,
+	).From(
+		ts.tableName,
+	).JoinClause(
+		"CROSS JOIN websearch_to_tsquery('english', ?) q", q,
+	).Where(
+		squirrel.And{
+			squirrel.Expr("searchVector @@ q"),
+			notTrashed,
+			visibleTo(uid, projectIDs),
+			notArchived,
+		},
+	).OrderBy(
+		"rank DESC",
+		"id DESC",
+	).Limit(
+		limit,
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []SearchResult{}
+	for rows.Next() {
+		result := SearchResult{}
+		task, err := scanTask(rows, &result.Rank, &result.Headline)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		result.Task = *task
+		list = append(list, result)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
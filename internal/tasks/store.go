@@ -3,18 +3,105 @@ package tasks
 import (
 	"context"
 	"database/sql"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
+var (
+	// taskColumns are the columns read by scanTask, in order
+	taskColumns = []string{
+		"id",
+		"uid",
+		"detail",
+		"completeBy",
+		"allDay",
+		"dueTimezone",
+		"assignedTo",
+		"recurrenceRule",
+		"recurrenceTimezone",
+		"recurrenceStart",
+		"seriesId",
+		"status",
+		"projectId",
+		"completedAt",
+		"parentId",
+		"tags",
+		"priority",
+		"(SELECT COUNT(*) FROM tasks c WHERE c.parentId = tasks.id AND c.deletedAt IS NULL) AS subtasks",
+		"(SELECT COUNT(*) FROM tasks c WHERE c.parentId = tasks.id AND c.deletedAt IS NULL AND c.completedAt IS NOT NULL) AS completedSubtasks",
+		blockedExpr + " AS blocked",
+		"(SELECT COUNT(*) FROM task_comments cm WHERE cm.tid = tasks.id) AS comments",
+		"(SELECT COALESCE(SUM(EXTRACT(EPOCH FROM wl.endedAt - wl.startedAt)), 0)::BIGINT FROM worklogs wl WHERE wl.tid = tasks.id AND wl.endedAt IS NOT NULL) AS timeSpent",
+		"createdAt",
+		"updatedAt",
+		"deletedAt",
+		"version",
+	}
+
+	// nextVersion increments the version of a task, every update of a task should set it
+	nextVersion = squirrel.Expr("version + 1")
+
+	// notTrashed excludes the tasks in the trash
+	notTrashed = squirrel.Eq{"deletedAt": nil}
+
+	// notArchived excludes the tasks of archived projects
+	notArchived = squirrel.Expr("(projectId IS NULL OR projectId NOT IN (SELECT id FROM projects WHERE archivedAt IS NOT NULL))")
+)
+
 type store interface {
 	Create(ctx context.Context, t *Task) (int64, error)
-	Delete(ctx context.Context, tid int64) error
+	Delete(ctx context.Context, tid int64, deletedAt time.Time) error
 	Edit(ctx context.Context, tid int64, t *Task) error
+	Patch(ctx context.Context, tid int64, t *Task, fields map[string]bool) error
 	Get(ctx context.Context, tid int64) (*Task, error)
-	GetAll(ctx context.Context, uid int64) ([]Task, error)
+	GetAll(ctx context.Context, uid int64, filter *Filter) ([]Task, error)
+	Export(ctx context.Context, uid int64, filter *Filter, each func(t *Task) error) error
+	DueOccurrences(ctx context.Context, before time.Time, limit uint64) ([]Task, error)
+	CreateOccurrence(ctx context.Context, prevTID int64, t *Task) (int64, error)
+	DueBetween(ctx context.Context, from time.Time, afterTID int64, to time.Time, limit uint64) ([]Task, error)
+	EndSeries(ctx context.Context, tid int64) error
+	SetStatus(ctx context.Context, tid int64, from string, to string, completedAt *time.Time, updatedAt time.Time) error
+	CreateTransition(ctx context.Context, tr *Transition) error
+	GetTransitions(ctx context.Context, tid int64) ([]Transition, error)
+	GetWorkflow(ctx context.Context, projectID int64) (*Workflow, error)
+	SaveWorkflow(ctx context.Context, w *Workflow) error
+	Ancestors(ctx context.Context, tid int64, limit int) ([]int64, error)
+	Height(ctx context.Context, tid int64, limit int) (int, error)
+	Subtree(ctx context.Context, tid int64) ([]Task, error)
+	DeleteSubtree(ctx context.Context, tid int64, deletedAt time.Time) ([]int64, error)
+	Reparent(ctx context.Context, tid int64, parentID int64) error
+	GetMany(ctx context.Context, tids []int64) ([]Task, error)
+	AddDependency(ctx context.Context, d *Dependency) error
+	RemoveDependency(ctx context.Context, tid int64, blockedBy int64) error
+	Upstream(ctx context.Context, tid int64) ([]Dependency, error)
+	Downstream(ctx context.Context, tid int64) ([]Dependency, error)
+	Unblocked(ctx context.Context, tid int64) ([]Task, error)
+	Search(ctx context.Context, uid int64, projectIDs []int64, q string, limit uint64) ([]SearchResult, error)
+	CreateEvent(ctx context.Context, e *Event) error
+	GetEvents(ctx context.Context, tid int64) ([]Event, error)
+	GetFeed(ctx context.Context, uid int64, before int64, limit uint64) ([]Event, error)
+	GetTrashed(ctx context.Context, tid int64) (*Task, error)
+	Trash(ctx context.Context, uid int64, projectIDs []int64) ([]Task, error)
+	Restore(ctx context.Context, tid int64, deletedAt time.Time, parentID int64) ([]int64, error)
+	Purge(ctx context.Context, before time.Time, limit uint64) ([]int64, error)
+	AddMember(ctx context.Context, m *Member) error
+	AddOwner(ctx context.Context, tid int64, uid int64, createdAt time.Time) error
+	RemoveMember(ctx context.Context, tid int64, mid int64) error
+	GetMember(ctx context.Context, tid int64, email string) (*Member, error)
+	GetMembers(ctx context.Context, tid int64, role string) ([]Member, error)
+	Move(ctx context.Context, tid int64, projectID int64, status string, completedAt *time.Time, updatedAt time.Time) error
+	Calendar(ctx context.Context, uid int64, since time.Time, limit uint64) ([]Task, error)
+	Todos(ctx context.Context, uid int64, completedSince time.Time, limit uint64) ([]Task, error)
+	ReserveIDs(ctx context.Context, n int) ([]int64, error)
+	CopyTasks(ctx context.Context, list []Task) error
+	CopyEvents(ctx context.Context, events []Event) error
+	LockVersion(ctx context.Context, tid int64) (int64, error)
 }
 
 type taskStore struct {
@@ -24,11 +111,17 @@ type taskStore struct {
 }
 
 func (ts *taskStore) Create(ctx context.Context, t *Task) (int64, error) {
-	sqlStatement := `INSERT INTO tasks (uid, detail, assignedTo, completeBy, createdAt, updatedAt)
-	VALUES ($1, $2, $3, $4, $5, $6)
+	sqlStatement := `INSERT INTO tasks (uid, detail, assignedTo, completeBy, allDay, dueTimezone, recurrenceRule, recurrenceTimezone, recurrenceStart, seriesId, status, projectId, completedAt, parentId, tags, priority, createdAt, updatedAt)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
 	RETURNING id`
+	rule, timezone, start := recurrenceColumns(t.Recurrence)
 	id := int64(0)
-	err := ts.pqdriver.QueryRow(ctx, sqlStatement, t.UID, t.Detail, t.AssignedTo, t.CompleteBy, t.CreatedAt, t.UpdatedAt).Scan(&id)
+	err := datastore.Conn(ctx, ts.pqdriver).QueryRow(
+		ctx,
+		sqlStatement,
+		t.UID, t.Detail, t.AssignedTo, nullTime(t.CompleteBy), t.AllDay(), nullString(t.DueTimezone), rule, timezone, start, nullInt64(t.SeriesID),
+		t.Status, nullInt64(t.ProjectID), t.CompletedAt, nullInt64(t.ParentID), tagsColumn(t.Tags), nullString(t.Priority), t.CreatedAt, t.UpdatedAt,
+	).Scan(&id)
 	if err != nil {
 		println(err.Error())
 		return id, errors.InternalErr(err, errors.DefaultMessage)
@@ -36,30 +129,75 @@ func (ts *taskStore) Create(ctx context.Context, t *Task) (int64, error) {
 	return id, nil
 }
 
-func (ts *taskStore) Delete(ctx context.Context, tid int64) error {
-	query, args, err := ts.qbuilder.Delete(ts.tableName).Where(
-		squirrel.Eq{
-			"id": tid,
+// Delete moves a task to the trash
+func (ts *taskStore) Delete(ctx context.Context, tid int64, deletedAt time.Time) error {
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
+		"deletedAt": deletedAt,
+		"version":   nextVersion,
+	}).Where(
+		squirrel.And{
+			squirrel.Eq{
+				"id": tid,
+			},
+			notTrashed,
 		},
 	).ToSql()
 	if err != nil {
 		return errors.InternalErr(err, errors.DefaultMessage)
 	}
 
-	_, err = ts.pqdriver.Query(ctx, query, args...)
+	tag, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("task not found")
+	}
+
+	return nil
+}
+
+// Move moves a task to another project, 0 moves it out of any project
+func (ts *taskStore) Move(ctx context.Context, tid int64, projectID int64, status string, completedAt *time.Time, updatedAt time.Time) error {
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
+		"projectId":   nullInt64(projectID),
+		"status":      status,
+		"completedAt": completedAt,
+		"updatedAt":   updatedAt,
+		"version":     nextVersion,
+	}).Where(squirrel.Eq{
+		"id": tid,
+	}).ToSql()
 	if err != nil {
-		println(err.Error())
 		return errors.InternalErr(err, errors.DefaultMessage)
 	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
 	return nil
 }
 
 func (ts *taskStore) Edit(ctx context.Context, tid int64, t *Task) error {
+	rule, timezone, start := recurrenceColumns(t.Recurrence)
 	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
-		"uid":        t.UID,
-		"detail":     t.Detail,
-		"completeBy": t.CompleteBy,
-		"updatedAt":  t.UpdatedAt,
+		"uid":                t.UID,
+		"detail":             t.Detail,
+		"assignedTo":         t.AssignedTo,
+		"completeBy":         nullTime(t.CompleteBy),
+		"allDay":             t.AllDay(),
+		"dueTimezone":        nullString(t.DueTimezone),
+		"recurrenceRule":     rule,
+		"recurrenceTimezone": timezone,
+		"recurrenceStart":    start,
+		"parentId":           nullInt64(t.ParentID),
+		"tags":               tagsColumn(t.Tags),
+		"priority":           nullString(t.Priority),
+		"updatedAt":          t.UpdatedAt,
+		"version":            nextVersion,
 	}).Where(squirrel.Eq{
 		"id": tid,
 	}).ToSql()
@@ -67,7 +205,7 @@ func (ts *taskStore) Edit(ctx context.Context, tid int64, t *Task) error {
 		return errors.InternalErr(err, errors.DefaultMessage)
 	}
 
-	_, err = ts.pqdriver.Exec(ctx, query, args...)
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
 	if err != nil {
 		println(err.Error())
 		return errors.InternalErr(err, errors.DefaultMessage)
@@ -76,19 +214,61 @@ func (ts *taskStore) Edit(ctx context.Context, tid int64, t *Task) error {
 	return nil
 }
 
+// Patch updates the columns of the fields of the task, by their JSON name
+func (ts *taskStore) Patch(ctx context.Context, tid int64, t *Task, fields map[string]bool) error {
+	columns := map[string]interface{}{
+		"updatedAt": t.UpdatedAt,
+		"version":   nextVersion,
+	}
+	for field := range fields {
+		switch field {
+		case "uid":
+			columns["uid"] = t.UID
+		case "detail":
+			columns["detail"] = t.Detail
+		case "assignedTo":
+			columns["assignedTo"] = t.AssignedTo
+		case "completeBy", "dueDate", "dueTimezone":
+			columns["completeBy"] = nullTime(t.CompleteBy)
+			columns["allDay"] = t.AllDay()
+			columns["dueTimezone"] = nullString(t.DueTimezone)
+		case "recurrence":
+			columns["recurrenceRule"], columns["recurrenceTimezone"], columns["recurrenceStart"] = recurrenceColumns(t.Recurrence)
+		case "parentId":
+			columns["parentId"] = nullInt64(t.ParentID)
+		case "tags":
+			columns["tags"] = tagsColumn(t.Tags)
+		case "priority":
+			columns["priority"] = nullString(t.Priority)
+		}
+	}
+
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(columns).Where(squirrel.Eq{
+		"id": tid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
 func (ts *taskStore) Get(ctx context.Context, tid int64) (*Task, error) {
 	query, args, err := ts.qbuilder.Select(
-		"uid",
-		"detail",
-		"completeBy",
-		"assignedTo",
-		"createdAt",
-		"updatedAt",
+		taskColumns...,
 	).From(
 		ts.tableName,
 	).Where(
-		squirrel.Eq{
-			"id": tid,
+		squirrel.And{
+			squirrel.Eq{
+				"id": tid,
+			},
+			notTrashed,
 		},
 	).ToSql()
 
@@ -96,85 +276,501 @@ func (ts *taskStore) Get(ctx context.Context, tid int64) (*Task, error) {
 		return nil, errors.InternalErr(err, errors.DefaultMessage)
 	}
 
-	task := new(Task)
-	uid := new(sql.NullInt64)
-	detail := new(sql.NullString)
-	assignedTo := new(sql.NullString)
-	completeBy := new(sql.NullTime)
-
-	row := ts.pqdriver.QueryRow(ctx, query, args...)
-	err = row.Scan(
-		uid,
-		detail,
-		completeBy,
-		assignedTo,
-		&task.CreatedAt,
-		&task.UpdatedAt,
-	)
-
+	row := datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...)
+	task, err := scanTask(row)
 	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("task not found")
+		}
 		return nil, errors.InternalErr(err, err.Error())
 	}
 
-	task.TID = tid
-	task.UID = uid.Int64
-	task.Detail = detail.String
-	task.CompleteBy = completeBy.Time
-	task.AssignedTo = assignedTo.String
-
 	return task, nil
 }
 
-func (ts *taskStore) GetAll(ctx context.Context, uid int64) ([]Task, error) {
+// LockVersion returns the version of a task, locking it until the end of the transaction
+func (ts *taskStore) LockVersion(ctx context.Context, tid int64) (int64, error) {
 	query, args, err := ts.qbuilder.Select(
-		"id",
-		"detail",
-		"completeBy",
-		"createdAt",
-		"updatedAt",
+		"version",
 	).From(
 		ts.tableName,
 	).Where(
+		squirrel.And{
+			squirrel.Eq{
+				"id": tid,
+			},
+			notTrashed,
+		},
+	).Suffix(
+		"FOR UPDATE",
+	).ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	version := int64(0)
+	err = datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return 0, errors.NotFound("task not found")
+	}
+	if err != nil {
+		return 0, errors.InternalErr(err, err.Error())
+	}
+
+	return version, nil
+}
+
+// visibleTo is the condition of the tasks the user owns or is a member of, along with the tasks of
+// projectIDs, which are the projects the user can view
+func visibleTo(uid int64, projectIDs []int64) squirrel.Or {
+	visible := squirrel.Or{
 		squirrel.Eq{
 			"uid": uid,
 		},
+		memberOf(uid),
+	}
+	if len(projectIDs) > 0 {
+		visible = append(visible, squirrel.Eq{"projectId": projectIDs})
+	}
+	return visible
+}
+
+// filterWhere returns the conditions of a listing of the tasks of a user, or of a project if the
+// filter has one
+func filterWhere(uid int64, filter *Filter) squirrel.And {
+	where := squirrel.And{
+		notTrashed,
+	}
+	if filter.ProjectID != 0 {
+		where = append(where, squirrel.Eq{"projectId": filter.ProjectID})
+	} else {
+		where = append(where,
+			visibleTo(uid, nil),
+			notArchived,
+		)
+	}
+	if len(filter.Statuses) > 0 {
+		where = append(where, squirrel.Eq{"status": filter.Statuses})
+	}
+	if filter.AssignedTo != "" {
+		where = append(where, squirrel.Expr("lower(assignedTo) = lower(?)", filter.AssignedTo))
+	}
+	if filter.Tag != "" {
+		where = append(where, squirrel.Expr("tags @> ARRAY[?]::TEXT[]", filter.Tag))
+	}
+	if filter.Priority != "" {
+		where = append(where, squirrel.Eq{"priority": filter.Priority})
+	}
+
+	ranges := []struct {
+		column string
+		from   *time.Time
+		to     *time.Time
+	}{
+		{"completeBy", filter.DueFrom, filter.DueTo},
+		{"createdAt", filter.CreatedFrom, filter.CreatedTo},
+		{"updatedAt", filter.UpdatedFrom, filter.UpdatedTo},
+	}
+	for _, r := range ranges {
+		if r.from != nil {
+			where = append(where, squirrel.GtOrEq{r.column: r.from})
+		}
+		if r.to != nil {
+			where = append(where, squirrel.Lt{r.column: r.to})
+		}
+	}
+
+	return where
+}
+
+// GetAll returns the tasks of a user matching the filter, with keyset pagination. Up to
+// filter.Limit+1 tasks are returned, so the caller knows whether there is a next page
+func (ts *taskStore) GetAll(ctx context.Context, uid int64, filter *Filter) ([]Task, error) {
+	where := filterWhere(uid, filter)
+	if after := filter.afterCursor(); after != nil {
+		where = append(where, after)
+	}
+
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		where,
+	).OrderBy(
+		filter.orderBy()...,
+	).Limit(
+		filter.Limit + 1,
 	).ToSql()
 
 	if err != nil {
 		return nil, errors.InternalErr(err, errors.DefaultMessage)
 	}
 
-	tasks := []Task{}
-	id := new(sql.NullInt64)
-	detail := new(sql.NullString)
-	completeBy := new(sql.NullTime)
+	return ts.list(ctx, query, args...)
+}
 
-	rows, err := ts.pqdriver.Query(ctx, query, args...)
+// Export calls each with every task matching the filter, in the sort order of the filter, as they
+// are read from the database. Pagination is ignored
+func (ts *taskStore) Export(ctx context.Context, uid int64, filter *Filter, each func(t *Task) error) error {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		filterWhere(uid, filter),
+	).OrderBy(
+		filter.orderBy()...,
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
 	defer rows.Close()
+
 	for rows.Next() {
-		task := new(Task)
-		err = rows.Scan(
-			id,
-			detail,
-			completeBy,
-			&task.CreatedAt,
-			&task.UpdatedAt,
-		)
+		t, err := scanTask(rows)
+		if err != nil {
+			return errors.InternalErr(err, err.Error())
+		}
 
+		err = each(t)
 		if err != nil {
-			return nil, errors.InternalErr(err, err.Error())
+			return err
 		}
+	}
 
-		task.TID = id.Int64
-		task.UID = uid
-		task.Detail = detail.String
-		task.CompleteBy = completeBy.Time
+	if err := rows.Err(); err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// DueOccurrences returns recurring tasks due before the given time, for which the next
+// occurrence has not been created yet
+func (ts *taskStore) DueOccurrences(ctx context.Context, before time.Time, limit uint64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.NotEq{"recurrenceRule": nil},
+			squirrel.Eq{"nextOccurrenceId": nil},
+			squirrel.LtOrEq{"completeBy": before},
+			notTrashed,
+		},
+	).OrderBy(
+		"completeBy",
+	).Limit(
+		limit,
+	).ToSql()
+
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// DueBetween returns the tasks which are not complete yet, and are due within [from, to]. If
+// afterTID is set, only the tasks following it in the order of (completeBy, id) are returned, from
+// being its due time
+func (ts *taskStore) DueBetween(ctx context.Context, from time.Time, afterTID int64, to time.Time, limit uint64) ([]Task, error) {
+	var after squirrel.Sqlizer = squirrel.GtOrEq{"completeBy": from}
+	if afterTID != 0 {
+		after = squirrel.Expr("(completeBy, id) > (?, ?)", from, afterTID)
+	}
+
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Eq{"completedAt": nil},
+			after,
+			squirrel.LtOrEq{"completeBy": to},
+			notTrashed,
+		},
+	).OrderBy(
+		"completeBy", "id",
+	).Limit(
+		limit,
+	).ToSql()
+
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// Calendar returns the tasks of the user, or shared with them, which are due since the given time
+func (ts *taskStore) Calendar(ctx context.Context, uid int64, since time.Time, limit uint64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Or{
+				squirrel.Eq{
+					"uid": uid,
+				},
+				memberOf(uid),
+			},
+			squirrel.GtOrEq{"completeBy": since},
+			notTrashed,
+			notArchived,
+		},
+	).OrderBy(
+		"completeBy",
+		"id",
+	).Limit(
+		limit,
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// Todos returns the tasks of the user, or shared with them, which are open or were completed since
+// the given time
+func (ts *taskStore) Todos(ctx context.Context, uid int64, completedSince time.Time, limit uint64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Or{
+				squirrel.Eq{
+					"uid": uid,
+				},
+				memberOf(uid),
+			},
+			squirrel.Or{
+				squirrel.Eq{"completedAt": nil},
+				squirrel.GtOrEq{"completedAt": completedSince},
+			},
+			notTrashed,
+			notArchived,
+		},
+	).OrderBy(
+		"id",
+	).Limit(
+		limit,
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// CreateOccurrence creates t as the occurrence following prevTID. It fails with a duplicate
+// error if the next occurrence of prevTID was already created, e.g. by another instance
+func (ts *taskStore) CreateOccurrence(ctx context.Context, prevTID int64, t *Task) (int64, error) {
+	id := int64(0)
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		var err error
+		id, err = ts.Create(ctx, t)
+		if err != nil {
+			return err
+		}
+
+		query, args, err := ts.qbuilder.Update(ts.tableName).Set(
+			"nextOccurrenceId", id,
+		).Where(squirrel.Eq{
+			"id":               prevTID,
+			"nextOccurrenceId": nil,
+		}).ToSql()
+		if err != nil {
+			return errors.InternalErr(err, errors.DefaultMessage)
+		}
+
+		tag, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+		if err != nil {
+			return errors.InternalErr(err, errors.DefaultMessage)
+		}
+
+		if tag.RowsAffected() == 0 {
+			return errors.Duplicate("next occurrence already exists")
+		}
+
+		return nil
+	})
+
+	return id, err
+}
+
+// EndSeries marks a recurring task as the last occurrence of its series
+func (ts *taskStore) EndSeries(ctx context.Context, tid int64) error {
+	query, args, err := ts.qbuilder.Update(ts.tableName).Set(
+		"nextOccurrenceId", 0,
+	).Where(squirrel.Eq{
+		"id":               tid,
+		"nextOccurrenceId": nil,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ts *taskStore) list(ctx context.Context, query string, args ...interface{}) ([]Task, error) {
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	tasks := []Task{}
+	for rows.Next() {
+		task, err := scanTask(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
 		tasks = append(tasks, *task)
 	}
 
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
 	return tasks, nil
 }
 
+// scanTask scans a row selected with taskColumns, followed by the extra columns if any
+func scanTask(row pgx.Row, extra ...interface{}) (*Task, error) {
+	task := new(Task)
+	id := new(sql.NullInt64)
+	uid := new(sql.NullInt64)
+	detail := new(sql.NullString)
+	completeBy := new(sql.NullTime)
+	allDay := false
+	dueTimezone := new(sql.NullString)
+	assignedTo := new(sql.NullString)
+	rule := new(sql.NullString)
+	timezone := new(sql.NullString)
+	start := new(sql.NullTime)
+	seriesID := new(sql.NullInt64)
+	status := new(sql.NullString)
+	projectID := new(sql.NullInt64)
+	parentID := new(sql.NullInt64)
+	priority := new(sql.NullString)
+	subtasks := 0
+	completedSubtasks := 0
+
+	dest := append([]interface{}{
+		id,
+		uid,
+		detail,
+		completeBy,
+		&allDay,
+		dueTimezone,
+		assignedTo,
+		rule,
+		timezone,
+		start,
+		seriesID,
+		status,
+		projectID,
+		&task.CompletedAt,
+		parentID,
+		&task.Tags,
+		priority,
+		&subtasks,
+		&completedSubtasks,
+		&task.Blocked,
+		&task.CommentCount,
+		&task.TimeSpent,
+		&task.CreatedAt,
+		&task.UpdatedAt,
+		&task.DeletedAt,
+		&task.Version,
+	}, extra...)
+
+	err := row.Scan(dest...)
+	if err != nil {
+		return nil, err
+	}
+
+	task.TID = id.Int64
+	task.UID = uid.Int64
+	task.Detail = detail.String
+	task.CompleteBy = completeBy.Time
+	task.DueTimezone = dueTimezone.String
+	task.localizeDue(allDay)
+	task.AssignedTo = assignedTo.String
+	task.SeriesID = seriesID.Int64
+	task.Status = status.String
+	task.ProjectID = projectID.Int64
+	task.ParentID = parentID.Int64
+	task.Priority = priority.String
+	task.Progress = newProgress(subtasks, completedSubtasks)
+	if rule.Valid {
+		task.Recurrence = &Recurrence{
+			Rule:     rule.String,
+			Timezone: timezone.String,
+		}
+		if start.Valid {
+			task.Recurrence.Start = &start.Time
+		}
+	}
+
+	return task, nil
+}
+
+// recurrenceColumns returns the values of the recurrence columns, all of which are NULL for
+// tasks which do not repeat
+func recurrenceColumns(r *Recurrence) (rule sql.NullString, timezone sql.NullString, start sql.NullTime) {
+	if r == nil {
+		return
+	}
+
+	rule = sql.NullString{String: r.Rule, Valid: true}
+	timezone = sql.NullString{String: r.Timezone, Valid: true}
+	if r.Start != nil {
+		start = sql.NullTime{Time: *r.Start, Valid: true}
+	}
+	return
+}
+
+// tagsColumn returns the value of the tags column, which is never NULL
+func tagsColumn(tags []string) []string {
+	if tags == nil {
+		return []string{}
+	}
+	return tags
+}
+
+func nullInt64(v int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: v, Valid: v != 0}
+}
+
+func nullTime(v time.Time) sql.NullTime {
+	return sql.NullTime{Time: v, Valid: !v.IsZero()}
+}
+
+func nullString(v string) sql.NullString {
+	return sql.NullString{String: v, Valid: v != ""}
+}
+
 func newStore(pqdriver *pgxpool.Pool) (*taskStore, error) {
 	return &taskStore{
 		pqdriver:  pqdriver,
@@ -0,0 +1,188 @@
+package tasks
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// MaxDepth is the maximum number of levels in a task hierarchy, including the top level task
+	MaxDepth = 5
+
+	// ChildrenReparent moves the subtasks of a deleted task to its parent
+	ChildrenReparent = "reparent"
+	// ChildrenCascade deletes the subtasks of a deleted task along with it
+	ChildrenCascade = "cascade"
+)
+
+// Progress is the completion rollup of a task's subtasks
+type Progress struct {
+	Subtasks  int `json:"subtasks"`
+	Completed int `json:"completed"`
+	// Percent is the percentage of subtasks which are complete, rounded down
+	Percent int `json:"percent"`
+}
+
+func newProgress(subtasks int, completed int) *Progress {
+	if subtasks == 0 {
+		return nil
+	}
+
+	return &Progress{
+		Subtasks:  subtasks,
+		Completed: completed,
+		Percent:   completed * 100 / subtasks,
+	}
+}
+
+// Node is a task along with its subtasks
+type Node struct {
+	Task
+	Children []*Node `json:"children"`
+}
+
+// validateParent checks if the task tid can be made a subtask of parentID, without creating a
+// cycle or exceeding MaxDepth. tid is 0 for new tasks
+func (ts *Tasks) validateParent(ctx context.Context, tid int64, parentID int64) error {
+	if parentID == 0 {
+		return nil
+	}
+
+	if parentID == tid {
+		return errors.Validation("a task cannot be its own parent")
+	}
+
+	ancestors, err := ts.store.Ancestors(ctx, parentID, MaxDepth+1)
+	if err != nil {
+		return err
+	}
+
+	if len(ancestors) == 0 {
+		return errors.Validation("parent task does not exist")
+	}
+
+	for _, id := range ancestors {
+		if id == tid {
+			return errors.Validation("a task cannot be a subtask of its own subtask")
+		}
+	}
+
+	height := 1
+	if tid != 0 {
+		height, err = ts.store.Height(ctx, tid, MaxDepth+1)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(ancestors)+height > MaxDepth {
+		return errors.Validationf("tasks cannot be nested more than %d levels deep", MaxDepth)
+	}
+
+	return nil
+}
+
+// Subtree returns a task along with all of its subtasks, recursively
+func (ts *Tasks) Subtree(ctx context.Context, tid int64) (*Node, error) {
+	list, err := ts.store.Subtree(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].TID < list[j].TID
+	})
+
+	nodes := make(map[int64]*Node, len(list))
+	for idx := range list {
+		nodes[list[idx].TID] = &Node{Task: list[idx], Children: []*Node{}}
+	}
+
+	root, ok := nodes[tid]
+	if !ok {
+		return nil, errors.NotFound("task not found")
+	}
+
+	for idx := range list {
+		node := nodes[list[idx].TID]
+		if node == root {
+			continue
+		}
+
+		parent, ok := nodes[node.ParentID]
+		if ok {
+			parent.Children = append(parent.Children, node)
+		}
+	}
+
+	return root, nil
+}
+
+// deleteWithChildren deletes a task, along with its subtasks if children is ChildrenCascade, or
+// moving them to the task's parent otherwise
+func (ts *Tasks) deleteWithChildren(ctx context.Context, tid int64, children string, version int64, actorUID int64) ([]int64, error) {
+	switch children {
+	case "", ChildrenReparent, ChildrenCascade:
+	default:
+		return nil, errors.Validationf("children should be either '%s' or '%s'", ChildrenReparent, ChildrenCascade)
+	}
+
+	deleted := []int64{}
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		err := ts.checkVersion(ctx, tid, version)
+		if err != nil {
+			return err
+		}
+
+		task, err := ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		// the tasks are kept aside, so their deletion can be recorded. All of them share the same
+		// deletion time, which is how they are restored together
+		now := time.Now()
+		removed := []Task{*task}
+		if children == ChildrenCascade {
+			removed, err = ts.store.Subtree(ctx, tid)
+			if err != nil {
+				return err
+			}
+
+			deleted, err = ts.store.DeleteSubtree(ctx, tid, now)
+			if err != nil {
+				return err
+			}
+		} else {
+			err = ts.store.Reparent(ctx, tid, task.ParentID)
+			if err != nil {
+				return err
+			}
+
+			err = ts.store.Delete(ctx, tid, now)
+			if err != nil {
+				return err
+			}
+			deleted = append(deleted, tid)
+		}
+
+		for i := range removed {
+			err = ts.recordEvent(ctx, EventDelete, actorUID, &removed[i], nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, nil
+}
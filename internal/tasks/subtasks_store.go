@@ -0,0 +1,156 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// subtreeCTE selects the IDs of a task and all of its subtasks which are not in the trash,
+	// recursively
+	subtreeCTE = `WITH RECURSIVE subtree(id) AS (
+		SELECT id FROM tasks WHERE id = ? AND deletedAt IS NULL
+		UNION
+		SELECT t.id FROM tasks t JOIN subtree s ON t.parentId = s.id WHERE t.deletedAt IS NULL
+	)`
+)
+
+// Ancestors returns the IDs of the task and of its ancestors, starting with the task itself and
+// returning at most limit IDs
+func (ts *taskStore) Ancestors(ctx context.Context, tid int64, limit int) ([]int64, error) {
+	query := `WITH RECURSIVE ancestors(id, parentId, depth) AS (
+		SELECT id, parentId, 1 FROM tasks WHERE id = $1
+		UNION ALL
+		SELECT t.id, t.parentId, a.depth + 1 FROM tasks t JOIN ancestors a ON t.id = a.parentId
+		WHERE a.depth < $2
+	)
+	SELECT id FROM ancestors ORDER BY depth`
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, tid, limit)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		id := int64(0)
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
+
+// Height returns the number of levels in the subtree of a task, 1 if it has no subtasks. Counting
+// stops at limit
+func (ts *taskStore) Height(ctx context.Context, tid int64, limit int) (int, error) {
+	query := `WITH RECURSIVE descendants(id, depth) AS (
+		SELECT id, 1 FROM tasks WHERE id = $1
+		UNION ALL
+		SELECT t.id, d.depth + 1 FROM tasks t JOIN descendants d ON t.parentId = d.id
+		WHERE d.depth < $2 AND t.deletedAt IS NULL
+	)
+	SELECT COALESCE(MAX(depth), 0) FROM descendants`
+
+	height := 0
+	err := datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, tid, limit).Scan(&height)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return height, nil
+}
+
+// Subtree returns a task and all of its subtasks, recursively
+func (ts *taskStore) Subtree(ctx context.Context, tid int64) ([]Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).Prefix(
+		subtreeCTE, tid,
+	).From(
+		ts.tableName,
+	).Where(
+		"id IN (SELECT id FROM subtree)",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// DeleteSubtree moves a task and all of its subtasks to the trash, recursively. It returns the IDs
+// of the deleted tasks
+func (ts *taskStore) DeleteSubtree(ctx context.Context, tid int64, deletedAt time.Time) ([]int64, error) {
+	query, args, err := ts.qbuilder.Update(
+		ts.tableName,
+	).Prefix(
+		subtreeCTE, tid,
+	).SetMap(map[string]interface{}{
+		"deletedAt": deletedAt,
+		"version":   nextVersion,
+	}).Where(
+		"id IN (SELECT id FROM subtree)",
+	).Suffix(
+		"RETURNING id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.ids(ctx, query, args...)
+}
+
+// ids runs a query returning a single column of IDs
+func (ts *taskStore) ids(ctx context.Context, query string, args ...interface{}) ([]int64, error) {
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		ids = append(ids, id)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return ids, nil
+}
+
+// Reparent moves all the direct subtasks of a task to another parent, 0 makes them top level tasks
+func (ts *taskStore) Reparent(ctx context.Context, tid int64, parentID int64) error {
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
+		"parentId": nullInt64(parentID),
+		"version":  nextVersion,
+	}).Where(squirrel.Eq{
+		"parentId": tid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
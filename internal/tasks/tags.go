@@ -0,0 +1,44 @@
+package tasks
+
+import (
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	// MaxTags is the maximum number of tags of a task
+	MaxTags = 20
+	// MaxTagLength is the maximum number of characters in a tag
+	MaxTagLength = 50
+)
+
+// initTags makes the tags of the task lowercase and unique
+func (t *Task) initTags() error {
+	seen := map[string]bool{}
+	tags := make([]string, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+
+		if len([]rune(tag)) > MaxTagLength {
+			return errors.Validationf("tags cannot be longer than %d characters", MaxTagLength)
+		}
+		// tags are separated by commas in CSV files
+		if strings.Contains(tag, ",") {
+			return errors.Validationf("tag '%s' cannot contain a comma", tag)
+		}
+
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+
+	if len(tags) > MaxTags {
+		return errors.Validationf("a task cannot have more than %d tags", MaxTags)
+	}
+
+	t.Tags = tags
+	return nil
+}
@@ -2,21 +2,53 @@ package tasks
 
 import (
 	"context"
+	"strings"
 	"time"
 
+	"task-scheduler/internal/platform/datastore"
 	"task-scheduler/internal/platform/logger"
 
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type Task struct {
-	TID        int64      `json:"tid,omitempty"`
-	UID        int64      `json:"uid,omitempty"`
-	Detail     string     `json:"detail,omitempty"`
-	CompleteBy time.Time  `json:"completeBy,omitempty"`
-	AssignedTo string     `json:"assignedTo,omitempty"`
-	CreatedAt  *time.Time `json:"createdAt,omitempty"`
-	UpdatedAt  *time.Time `json:"updatedAt,omitempty"`
+	TID        int64     `json:"tid,omitempty"`
+	UID        int64     `json:"uid,omitempty"`
+	Detail     string    `json:"detail,omitempty"`
+	CompleteBy time.Time `json:"completeBy,omitempty"`
+	// DueDate is set for all-day tasks, as YYYY-MM-DD. They are due by the end of the date in
+	// DueTimezone, and the date does not change wherever the task is viewed from
+	DueDate string `json:"dueDate,omitempty"`
+	// DueTimezone is the IANA name of the timezone of the due date, CompleteBy is returned in it
+	DueTimezone string `json:"dueTimezone,omitempty"`
+	AssignedTo  string `json:"assignedTo,omitempty"`
+	// Assignees are the email addresses the task is assigned to when it is created, AssignedTo
+	// is the first of them. They are kept as members of the task
+	Assignees []string `json:"assignees,omitempty"`
+	// Tags are lowercase labels used to group and filter tasks
+	Tags []string `json:"tags,omitempty"`
+	// Priority is one of Priorities, empty if the task has no priority
+	Priority    string      `json:"priority,omitempty"`
+	Recurrence  *Recurrence `json:"recurrence,omitempty"`
+	SeriesID    int64       `json:"seriesId,omitempty"`
+	ProjectID   int64       `json:"projectId,omitempty"`
+	Status      string      `json:"status,omitempty"`
+	CompletedAt *time.Time  `json:"completedAt,omitempty"`
+	ParentID    int64       `json:"parentId,omitempty"`
+	Progress    *Progress   `json:"progress,omitempty"`
+	// Blocked is true if the task depends on other tasks which are not complete yet
+	Blocked bool `json:"blocked,omitempty"`
+	// CommentCount is the number of comments on the task
+	CommentCount int `json:"commentCount"`
+	// TimeSpent is the total time logged on the task in seconds, running timers are not included
+	TimeSpent int64      `json:"timeSpent"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+	// DeletedAt is set while the task is in the trash
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	// Version is incremented every time the task is updated. Edits of a task at a given version
+	// fail with ErrVersionMismatch if it was changed since
+	Version int64 `json:"version,omitempty"`
 }
 
 func (u *Task) init() {
@@ -30,35 +62,178 @@ func (u *Task) init() {
 	}
 }
 
+func (t *Task) Validate() error {
+	if t.Recurrence != nil {
+		err := t.Recurrence.Validate()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 type Tasks struct {
 	logHandler logger.Logger
 	store      store
+	pqdriver   *pgxpool.Pool
 }
 
+// Create creates a task on behalf of its owner
 func (ts *Tasks) Create(ctx context.Context, t *Task) (*Task, error) {
+	return ts.create(ctx, t, EventCreate, t.UID)
+}
+
+// Assign creates a task assigned to someone else on behalf of actorUID
+func (ts *Tasks) Assign(ctx context.Context, t *Task, actorUID int64) (*Task, error) {
+	return ts.create(ctx, t, EventAssign, actorUID)
+}
+
+func (ts *Tasks) create(ctx context.Context, t *Task, action string, actorUID int64) (*Task, error) {
 	t.init()
+	err := t.initDue()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initRecurrence()
+	if err != nil {
+		return nil, err
+	}
 
-	id, err := ts.store.Create(ctx, t)
+	err = ts.initStatus(ctx, t)
 	if err != nil {
 		return nil, err
 	}
-	t.TID = id
+
+	err = ts.validateParent(ctx, 0, t.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initAssignees()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initTags()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initPriority()
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		id, err := ts.store.Create(ctx, t)
+		if err != nil {
+			return err
+		}
+		t.TID = id
+
+		err = ts.addMembers(ctx, t)
+		if err != nil {
+			return err
+		}
+
+		return ts.recordEvent(ctx, action, actorUID, nil, t)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	return t, nil
 }
 
-func (ts *Tasks) Delete(ctx context.Context, tid int64) error {
-	err := ts.store.Delete(ctx, tid)
+// Delete moves a task to the trash. children decides what happens to its subtasks, either
+// ChildrenReparent (the default) or ChildrenCascade. If version is not 0, the task is only deleted
+// if it is still at that version. It returns the IDs of all the tasks deleted
+func (ts *Tasks) Delete(ctx context.Context, tid int64, children string, version int64, actorUID int64) ([]int64, error) {
+	deleted, err := ts.deleteWithChildren(ctx, tid, children, version, actorUID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	return nil
+	return deleted, nil
 }
 
-func (ts *Tasks) Edit(ctx context.Context, tid int64, t *Task) (*Task, error) {
+// Edit replaces the fields of a task which can be edited. If t.Version is not 0, the task is only
+// edited if it is still at that version, otherwise ErrVersionMismatch is returned
+func (ts *Tasks) Edit(ctx context.Context, tid int64, t *Task, actorUID int64) (*Task, error) {
 	now := time.Now()
 	t.UpdatedAt = &now
-	err := ts.store.Edit(ctx, tid, t)
+	if t.Recurrence != nil {
+		t.Recurrence.Sanitize()
+	}
+	err := t.initDue()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initTags()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.initPriority()
+	if err != nil {
+		return nil, err
+	}
+
+	err = t.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ts.validateParent(ctx, tid, t.ParentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		err := ts.checkVersion(ctx, tid, t.Version)
+		if err != nil {
+			return err
+		}
+
+		before, err := ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		// the owner and the assignee are kept unless other ones are given
+		if t.UID == 0 {
+			t.UID = before.UID
+		}
+		if strings.TrimSpace(t.AssignedTo) == "" {
+			t.AssignedTo = before.AssignedTo
+		}
+
+		assignee, err := reassigned(before, t)
+		if err != nil {
+			return err
+		}
+
+		err = ts.store.Edit(ctx, tid, t)
+		if err != nil {
+			return err
+		}
+
+		err = ts.addAssignee(ctx, tid, assignee, now)
+		if err != nil {
+			return err
+		}
+
+		after, err := ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+		t.Version = after.Version
+
+		return ts.recordEvent(ctx, EventEdit, actorUID, before, after)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -75,8 +250,41 @@ func (ts *Tasks) Get(ctx context.Context, tid int64) (*Task, error) {
 	return task, nil
 }
 
-func (ts *Tasks) GetAll(ctx context.Context, uid int64) ([]Task, error) {
-	tasks, err := ts.store.GetAll(ctx, uid)
+// GetAll returns one page of the tasks of a user, matching the filter
+func (ts *Tasks) GetAll(ctx context.Context, uid int64, filter *Filter) (*Page, error) {
+	if filter == nil {
+		filter = new(Filter)
+	}
+
+	filter.Sanitize()
+	err := filter.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks, err := ts.store.GetAll(ctx, uid, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &Page{
+		Tasks: tasks,
+	}
+
+	// the store returns one more task than the limit, if there is a next page
+	if uint64(len(tasks)) > filter.Limit {
+		page.Tasks = tasks[:filter.Limit]
+		page.NextCursor = filter.nextCursor(&page.Tasks[filter.Limit-1])
+	}
+
+	return page, nil
+}
+
+// DueBetween returns up to limit tasks which are not complete yet, and are due within [from, to],
+// ordered by their due time. Passing the due time and id of the last task as from and afterTID
+// returns the next page
+func (ts *Tasks) DueBetween(ctx context.Context, from time.Time, afterTID int64, to time.Time, limit uint64) ([]Task, error) {
+	tasks, err := ts.store.DueBetween(ctx, from, afterTID, to, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -93,5 +301,6 @@ func NewService(l logger.Logger, pqdriver *pgxpool.Pool) (*Tasks, error) {
 	return &Tasks{
 		logHandler: l,
 		store:      tstore,
+		pqdriver:   pqdriver,
 	}, nil
 }
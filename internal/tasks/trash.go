@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	EventRestore = "restore"
+
+	// purgeBatchSize is the maximum number of tasks permanently deleted per query
+	purgeBatchSize = 500
+)
+
+// TrashConfig holds the configuration of the trash purger
+type TrashConfig struct {
+	Interval time.Duration
+	// Retention is how long deleted tasks stay in the trash before being permanently deleted
+	Retention time.Duration
+}
+
+// Trash returns the deleted tasks of a user, and those of the projects projectIDs, which can still
+// be restored, most recently deleted first
+func (ts *Tasks) Trash(ctx context.Context, uid int64, projectIDs []int64) ([]Task, error) {
+	return ts.store.Trash(ctx, uid, projectIDs)
+}
+
+// Trashed returns a task which is in the trash
+func (ts *Tasks) Trashed(ctx context.Context, tid int64) (*Task, error) {
+	return ts.store.GetTrashed(ctx, tid)
+}
+
+// Restore moves a task out of the trash, along with the subtasks which were deleted with it. If
+// its parent is not restored, it becomes a top level task. uid is the user restoring it, who is
+// expected to be allowed to
+func (ts *Tasks) Restore(ctx context.Context, tid int64, uid int64) (*Task, error) {
+	var restored *Task
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		task, err := ts.store.GetTrashed(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		parentID := task.ParentID
+		if parentID != 0 {
+			_, err = ts.store.Get(ctx, parentID)
+			if errors.HasType(err, errors.TypeNotFound) {
+				parentID = 0
+			} else if err != nil {
+				return err
+			}
+		}
+
+		tids, err := ts.store.Restore(ctx, tid, *task.DeletedAt, parentID)
+		if err != nil {
+			return err
+		}
+
+		list, err := ts.store.GetMany(ctx, tids)
+		if err != nil {
+			return err
+		}
+
+		for i := range list {
+			before := list[i]
+			if before.TID == tid {
+				before.ParentID = task.ParentID
+				restored = &list[i]
+			}
+
+			err = ts.recordEvent(ctx, EventRestore, uid, &before, &list[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return restored, nil
+}
+
+// Purge permanently deletes the tasks which have been in the trash for longer than retention,
+// and returns their IDs
+func (ts *Tasks) Purge(ctx context.Context, retention time.Duration) ([]int64, error) {
+	before := time.Now().Add(-retention)
+	purged := []int64{}
+	for {
+		tids, err := ts.store.Purge(ctx, before, purgeBatchSize)
+		if err != nil {
+			return purged, err
+		}
+
+		purged = append(purged, tids...)
+		if len(tids) < purgeBatchSize {
+			return purged, nil
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package tasks
+
+import (
+	"context"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+// GetTrashed returns a task which is in the trash
+func (ts *taskStore) GetTrashed(ctx context.Context, tid int64) (*Task, error) {
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			squirrel.Eq{
+				"id": tid,
+			},
+			squirrel.NotEq{
+				"deletedAt": nil,
+			},
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	task, err := scanTask(datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("task not found in the trash")
+		}
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	return task, nil
+}
+
+func (ts *taskStore) Trash(ctx context.Context, uid int64, projectIDs []int64) ([]Task, error) {
+	owned := squirrel.Or{
+		squirrel.Eq{
+			"uid": uid,
+		},
+	}
+	if len(projectIDs) > 0 {
+		owned = append(owned, squirrel.Eq{
+			"projectId": projectIDs,
+		})
+	}
+
+	query, args, err := ts.qbuilder.Select(
+		taskColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.And{
+			owned,
+			squirrel.NotEq{
+				"deletedAt": nil,
+			},
+		},
+	).OrderBy(
+		"deletedAt DESC",
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.list(ctx, query, args...)
+}
+
+// Restore takes a task out of the trash, along with the subtasks deleted at the same time, and
+// moves it under parentID. It returns the IDs of the restored tasks
+func (ts *taskStore) Restore(ctx context.Context, tid int64, deletedAt time.Time, parentID int64) ([]int64, error) {
+	query := `WITH RECURSIVE restored(id) AS (
+		SELECT id FROM tasks WHERE id = $1 AND deletedAt = $2
+		UNION
+		SELECT t.id FROM tasks t JOIN restored r ON t.parentId = r.id WHERE t.deletedAt = $2
+	)
+	UPDATE tasks SET
+		deletedAt = NULL,
+		parentId = CASE WHEN id = $1 THEN $3 ELSE parentId END,
+		version = version + 1
+	WHERE id IN (SELECT id FROM restored)
+	RETURNING id`
+
+	return ts.ids(ctx, query, tid, deletedAt, nullInt64(parentID))
+}
+
+// Purge permanently deletes up to limit tasks which were moved to the trash before the given time
+func (ts *taskStore) Purge(ctx context.Context, before time.Time, limit uint64) ([]int64, error) {
+	query, args, err := ts.qbuilder.Delete(
+		ts.tableName,
+	).Where(
+		squirrel.Expr(
+			"id IN (SELECT id FROM tasks WHERE deletedAt < ? ORDER BY deletedAt LIMIT ?)",
+			before, limit,
+		),
+	).Suffix(
+		"RETURNING id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return ts.ids(ctx, query, args...)
+}
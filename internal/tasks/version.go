@@ -0,0 +1,50 @@
+package tasks
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// ErrVersionMismatch is returned when a task was changed since the version a change was based on
+var ErrVersionMismatch = errors.New("the task was changed by someone else")
+
+// ETag returns the entity tag of the task, which changes with its version
+func (t *Task) ETag() string {
+	return `"` + strconv.FormatInt(t.Version, 10) + `"`
+}
+
+// ParseETag returns the version of an entity tag returned by ETag. Weak tags are accepted since
+// they are only compared
+func ParseETag(etag string) (int64, bool) {
+	etag = strings.TrimPrefix(strings.TrimSpace(etag), "W/")
+	if len(etag) < 2 || etag[0] != '"' || etag[len(etag)-1] != '"' {
+		return 0, false
+	}
+
+	version, err := strconv.ParseInt(etag[1:len(etag)-1], 10, 64)
+	if err != nil || version <= 0 {
+		return 0, false
+	}
+	return version, true
+}
+
+// checkVersion locks the task until the end of the transaction, so it cannot be changed by anyone
+// else, and checks it is still at version. A version of 0 matches any
+func (ts *Tasks) checkVersion(ctx context.Context, tid int64, version int64) error {
+	if version == 0 {
+		return nil
+	}
+
+	current, err := ts.store.LockVersion(ctx, tid)
+	if err != nil {
+		return err
+	}
+
+	if current != version {
+		return ErrVersionMismatch
+	}
+	return nil
+}
@@ -0,0 +1,307 @@
+package tasks
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/bnkamalesh/errors"
+)
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in-progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+// Workflow is the state machine of task statuses within a project. Projects without a
+// custom workflow use DefaultWorkflow
+type Workflow struct {
+	ProjectID int64    `json:"projectId,omitempty"`
+	Statuses  []string `json:"statuses,omitempty"`
+	// Initial is the status of new tasks
+	Initial string `json:"initial,omitempty"`
+	// Transitions maps a status to the list of statuses a task can move to from it
+	Transitions map[string][]string `json:"transitions,omitempty"`
+	// Completed lists the statuses in which a task is considered complete
+	Completed []string   `json:"completed,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+// Transition is a status change of a task
+type Transition struct {
+	ID        int64      `json:"id,omitempty"`
+	TID       int64      `json:"tid,omitempty"`
+	From      string     `json:"from,omitempty"`
+	To        string     `json:"to,omitempty"`
+	ActorUID  int64      `json:"actorUid,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// DefaultWorkflow returns the workflow used by tasks which are not part of a project, or whose
+// project has no custom workflow
+func DefaultWorkflow() *Workflow {
+	return &Workflow{
+		Statuses: []string{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+		Initial:  StatusTodo,
+		Transitions: map[string][]string{
+			StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+			StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+			StatusBlocked:    {StatusTodo, StatusInProgress, StatusCancelled},
+			StatusDone:       {StatusTodo, StatusInProgress},
+			StatusCancelled:  {StatusTodo},
+		},
+		Completed: []string{StatusDone},
+	}
+}
+
+func (w *Workflow) init() {
+	now := time.Now()
+	if w.CreatedAt == nil {
+		w.CreatedAt = &now
+	}
+	w.UpdatedAt = &now
+}
+
+func (w *Workflow) Sanitize() {
+	for idx := range w.Statuses {
+		w.Statuses[idx] = strings.TrimSpace(w.Statuses[idx])
+	}
+	w.Initial = strings.TrimSpace(w.Initial)
+	for idx := range w.Completed {
+		w.Completed[idx] = strings.TrimSpace(w.Completed[idx])
+	}
+
+	transitions := make(map[string][]string, len(w.Transitions))
+	for from, list := range w.Transitions {
+		to := make([]string, 0, len(list))
+		for _, status := range list {
+			to = append(to, strings.TrimSpace(status))
+		}
+		transitions[strings.TrimSpace(from)] = to
+	}
+	w.Transitions = transitions
+}
+
+func (w *Workflow) Validate() error {
+	if len(w.Statuses) == 0 {
+		return errors.Validation("workflow should have at least one status")
+	}
+
+	seen := make(map[string]bool, len(w.Statuses))
+	for _, status := range w.Statuses {
+		if status == "" {
+			return errors.Validation("workflow status cannot be empty")
+		}
+		if seen[status] {
+			return errors.Validationf("workflow status '%s' is repeated", status)
+		}
+		seen[status] = true
+	}
+
+	if !seen[w.Initial] {
+		return errors.Validationf("initial status '%s' is not a workflow status", w.Initial)
+	}
+
+	for from, list := range w.Transitions {
+		if !seen[from] {
+			return errors.Validationf("transition from unknown status '%s'", from)
+		}
+		for _, to := range list {
+			if !seen[to] {
+				return errors.Validationf("transition to unknown status '%s'", to)
+			}
+		}
+	}
+
+	for _, status := range w.Completed {
+		if !seen[status] {
+			return errors.Validationf("completed status '%s' is not a workflow status", status)
+		}
+	}
+
+	return nil
+}
+
+func (w *Workflow) HasStatus(status string) bool {
+	return containsString(w.Statuses, status)
+}
+
+// CanTransition reports whether a task can move from one status to the other. Tasks in a status
+// which is no longer part of the workflow (e.g. after it was edited) can move to any status
+func (w *Workflow) CanTransition(from string, to string) bool {
+	if !w.HasStatus(to) || from == to {
+		return false
+	}
+
+	if !w.HasStatus(from) {
+		return true
+	}
+
+	return containsString(w.Transitions[from], to)
+}
+
+func (w *Workflow) IsCompleted(status string) bool {
+	return containsString(w.Completed, status)
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Workflow returns the workflow of a project
+func (ts *Tasks) Workflow(ctx context.Context, projectID int64) (*Workflow, error) {
+	if projectID == 0 {
+		return DefaultWorkflow(), nil
+	}
+
+	w, err := ts.store.GetWorkflow(ctx, projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	if w == nil {
+		w = DefaultWorkflow()
+		w.ProjectID = projectID
+	}
+
+	return w, nil
+}
+
+// SaveWorkflow creates or replaces the custom workflow of a project
+func (ts *Tasks) SaveWorkflow(ctx context.Context, projectID int64, w *Workflow) (*Workflow, error) {
+	if projectID == 0 {
+		return nil, errors.Validation("the default workflow cannot be changed")
+	}
+
+	w.ProjectID = projectID
+	w.Sanitize()
+	err := w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	w.init()
+	err = ts.store.SaveWorkflow(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Transition moves a task to another status, as allowed by the workflow of its project, and
+// records the change in its history. Completing a recurring task creates its next occurrence
+func (ts *Tasks) Transition(ctx context.Context, tid int64, to string, actorUID int64, note string) (*Task, error) {
+	to = strings.TrimSpace(to)
+	var task *Task
+
+	err := datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		var err error
+		task, err = ts.store.Get(ctx, tid)
+		if err != nil {
+			return err
+		}
+
+		w, err := ts.Workflow(ctx, task.ProjectID)
+		if err != nil {
+			return err
+		}
+
+		if !w.CanTransition(task.Status, to) {
+			return errors.Validationf("task cannot move from '%s' to '%s'", task.Status, to)
+		}
+
+		err = checkBlocked(task, w, to)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		var completedAt *time.Time
+		if w.IsCompleted(to) {
+			completedAt = &now
+		}
+
+		err = ts.store.SetStatus(ctx, tid, task.Status, to, completedAt, now)
+		if err != nil {
+			return err
+		}
+
+		err = ts.store.CreateTransition(ctx, &Transition{
+			TID:       tid,
+			From:      task.Status,
+			To:        to,
+			ActorUID:  actorUID,
+			Note:      strings.TrimSpace(note),
+			CreatedAt: &now,
+		})
+		if err != nil {
+			return err
+		}
+
+		task.Status = to
+		task.CompletedAt = completedAt
+		task.UpdatedAt = &now
+
+		if completedAt != nil && task.Recurrence != nil {
+			_, err = ts.materialize(ctx, task, now)
+			if err != nil && !errors.HasType(err, errors.TypeDuplicate) {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return task, nil
+}
+
+// Transitions returns the status history of a task, oldest first
+func (ts *Tasks) Transitions(ctx context.Context, tid int64) ([]Transition, error) {
+	return ts.store.GetTransitions(ctx, tid)
+}
+
+// initStatus checks the status of a new task against the workflow of its project
+func (ts *Tasks) initStatus(ctx context.Context, t *Task) error {
+	w, err := ts.Workflow(ctx, t.ProjectID)
+	if err != nil {
+		return err
+	}
+
+	return w.initStatus(t)
+}
+
+// initStatus sets the status of a new task to the initial one if it has none, and checks if it is
+// a status of the workflow
+func (w *Workflow) initStatus(t *Task) error {
+	t.Status = strings.TrimSpace(t.Status)
+	if t.Status == "" {
+		t.Status = w.Initial
+	}
+
+	if !w.HasStatus(t.Status) {
+		return errors.Validationf("'%s' is not a status of the task's workflow", t.Status)
+	}
+
+	if w.IsCompleted(t.Status) && t.CompletedAt == nil {
+		now := time.Now()
+		t.CompletedAt = &now
+	}
+
+	return nil
+}
@@ -0,0 +1,184 @@
+package tasks
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+)
+
+const (
+	workflowsTable   = "workflows"
+	transitionsTable = "task_transitions"
+)
+
+// SetStatus changes the status of a task, provided it is still in status from. This prevents
+// two concurrent transitions from both succeeding
+func (ts *taskStore) SetStatus(ctx context.Context, tid int64, from string, to string, completedAt *time.Time, updatedAt time.Time) error {
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
+		"status":      to,
+		"completedAt": completedAt,
+		"updatedAt":   updatedAt,
+		"version":     nextVersion,
+	}).Where(squirrel.Eq{
+		"id":     tid,
+		"status": from,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.Duplicate("task status was changed by someone else, please retry")
+	}
+
+	return nil
+}
+
+func (ts *taskStore) CreateTransition(ctx context.Context, tr *Transition) error {
+	query, args, err := ts.qbuilder.Insert(transitionsTable).SetMap(map[string]interface{}{
+		"tid":        tr.TID,
+		"fromStatus": tr.From,
+		"toStatus":   tr.To,
+		"actorUid":   tr.ActorUID,
+		"note":       tr.Note,
+		"createdAt":  tr.CreatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	err = datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...).Scan(&tr.ID)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ts *taskStore) GetTransitions(ctx context.Context, tid int64) ([]Transition, error) {
+	query, args, err := ts.qbuilder.Select(
+		"id",
+		"fromStatus",
+		"toStatus",
+		"actorUid",
+		"note",
+		"createdAt",
+	).From(
+		transitionsTable,
+	).Where(
+		squirrel.Eq{
+			"tid": tid,
+		},
+	).OrderBy(
+		"createdAt", "id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	transitions := []Transition{}
+	for rows.Next() {
+		tr := Transition{TID: tid}
+		note := new(sql.NullString)
+		err = rows.Scan(
+			&tr.ID,
+			&tr.From,
+			&tr.To,
+			&tr.ActorUID,
+			note,
+			&tr.CreatedAt,
+		)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		tr.Note = note.String
+		transitions = append(transitions, tr)
+	}
+
+	return transitions, nil
+}
+
+// GetWorkflow returns the custom workflow of a project, or nil if it has none
+func (ts *taskStore) GetWorkflow(ctx context.Context, projectID int64) (*Workflow, error) {
+	query, args, err := ts.qbuilder.Select(
+		"statuses",
+		"initial",
+		"transitions",
+		"completed",
+		"createdAt",
+		"updatedAt",
+	).From(
+		workflowsTable,
+	).Where(
+		squirrel.Eq{
+			"projectId": projectID,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	w := &Workflow{ProjectID: projectID}
+	err = datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...).Scan(
+		&w.Statuses,
+		&w.Initial,
+		&w.Transitions,
+		&w.Completed,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	return w, nil
+}
+
+func (ts *taskStore) SaveWorkflow(ctx context.Context, w *Workflow) error {
+	query, args, err := ts.qbuilder.Insert(workflowsTable).SetMap(map[string]interface{}{
+		"projectId":   w.ProjectID,
+		"statuses":    w.Statuses,
+		"initial":     w.Initial,
+		"transitions": w.Transitions,
+		"completed":   w.Completed,
+		"createdAt":   w.CreatedAt,
+		"updatedAt":   w.UpdatedAt,
+	}).Suffix(
+		`ON CONFLICT (projectId) DO UPDATE SET
+		statuses = EXCLUDED.statuses,
+		initial = EXCLUDED.initial,
+		transitions = EXCLUDED.transitions,
+		completed = EXCLUDED.completed,
+		updatedAt = EXCLUDED.updatedAt`,
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
@@ -0,0 +1,199 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+
+	"task-scheduler/internal/platform/datastore"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Create(ctx context.Context, t *Template) (int64, error)
+	Edit(ctx context.Context, t *Template) error
+	Get(ctx context.Context, id int64) (*Template, error)
+	GetAll(ctx context.Context, uid int64) ([]Template, error)
+	Delete(ctx context.Context, id int64) error
+}
+
+type templateStore struct {
+	qbuilder  squirrel.StatementBuilderType
+	pqdriver  *pgxpool.Pool
+	tableName string
+}
+
+var templateColumns = []string{
+	"id",
+	"uid",
+	"name",
+	"items",
+	"createdAt",
+	"updatedAt",
+}
+
+func (ts *templateStore) Create(ctx context.Context, t *Template) (int64, error) {
+	items, err := json.Marshal(t.Items)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	query, args, err := ts.qbuilder.Insert(ts.tableName).SetMap(map[string]interface{}{
+		"uid":       t.UID,
+		"name":      t.Name,
+		"items":     string(items),
+		"createdAt": t.CreatedAt,
+		"updatedAt": t.UpdatedAt,
+	}).Suffix("RETURNING id").ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	var id int64
+	err = datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return id, nil
+}
+
+func (ts *templateStore) Edit(ctx context.Context, t *Template) error {
+	items, err := json.Marshal(t.Items)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	query, args, err := ts.qbuilder.Update(ts.tableName).SetMap(map[string]interface{}{
+		"name":      t.Name,
+		"items":     string(items),
+		"updatedAt": t.UpdatedAt,
+	}).Where(squirrel.Eq{
+		"id": t.ID,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+func (ts *templateStore) Get(ctx context.Context, id int64) (*Template, error) {
+	query, args, err := ts.qbuilder.Select(
+		templateColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": id,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	t, err := scanTemplate(datastore.Conn(ctx, ts.pqdriver).QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("template not found")
+		}
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	return t, nil
+}
+
+func (ts *templateStore) GetAll(ctx context.Context, uid int64) ([]Template, error) {
+	query, args, err := ts.qbuilder.Select(
+		templateColumns...,
+	).From(
+		ts.tableName,
+	).Where(
+		squirrel.Eq{
+			"uid": uid,
+		},
+	).OrderBy(
+		"name",
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := datastore.Conn(ctx, ts.pqdriver).Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Template{}
+	for rows.Next() {
+		t, err := scanTemplate(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *t)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+func (ts *templateStore) Delete(ctx context.Context, id int64) error {
+	query, args, err := ts.qbuilder.Delete(ts.tableName).Where(squirrel.Eq{
+		"id": id,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	_, err = datastore.Conn(ctx, ts.pqdriver).Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return nil
+}
+
+// scanTemplate scans a row selected with templateColumns
+func scanTemplate(row pgx.Row) (*Template, error) {
+	t := new(Template)
+	items := []byte{}
+	err := row.Scan(
+		&t.ID,
+		&t.UID,
+		&t.Name,
+		&items,
+		&t.CreatedAt,
+		&t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(items, &t.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*templateStore, error) {
+	return &templateStore{
+		pqdriver:  pqdriver,
+		qbuilder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName: "task_templates",
+	}, nil
+}
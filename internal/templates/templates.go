@@ -0,0 +1,306 @@
+package templates
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/datastore"
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// MaxItems is the maximum number of tasks in a template
+	MaxItems = 100
+	// MaxDueOffset is the latest a task can be due after a template is instantiated
+	MaxDueOffset = time.Hour * 24 * 365
+)
+
+// placeholderPattern matches placeholders like {{name}} or {{ release version }}
+var placeholderPattern = regexp.MustCompile(`{{\s*([\w.\- ]+?)\s*}}`)
+
+// DueOffset is a duration represented in JSON as a string, e.g. "36h". Days are supported as well,
+// e.g. "3d"
+type DueOffset time.Duration
+
+func (o DueOffset) MarshalJSON() ([]byte, error) {
+	d := time.Duration(o)
+	if d != 0 && d%(time.Hour*24) == 0 {
+		return json.Marshal(strconv.FormatInt(int64(d/(time.Hour*24)), 10) + "d")
+	}
+	return json.Marshal(d.String())
+}
+
+func (o *DueOffset) UnmarshalJSON(b []byte) error {
+	str := ""
+	err := json.Unmarshal(b, &str)
+	if err != nil {
+		return err
+	}
+
+	str = strings.TrimSpace(str)
+	if strings.HasSuffix(str, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(str, "d"))
+		if err != nil {
+			return errors.ValidationErrf(err, "invalid due offset '%s'", str)
+		}
+		*o = DueOffset(time.Duration(days) * time.Hour * 24)
+		return nil
+	}
+
+	d, err := time.ParseDuration(str)
+	if err != nil {
+		return errors.ValidationErrf(err, "invalid due offset '%s'", str)
+	}
+	*o = DueOffset(d)
+	return nil
+}
+
+// Item is a task created when the template is instantiated. Detail and AssignedTo can have
+// {{placeholders}}
+type Item struct {
+	Detail string `json:"detail"`
+	// DueOffset is when the task is due, relative to the time the template is instantiated
+	DueOffset  DueOffset `json:"dueOffset"`
+	AssignedTo string    `json:"assignedTo,omitempty"`
+}
+
+type Template struct {
+	ID        int64      `json:"id,omitempty"`
+	UID       int64      `json:"uid,omitempty"`
+	Name      string     `json:"name,omitempty"`
+	Items     []Item     `json:"items"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (t *Template) Sanitize() {
+	t.Name = strings.TrimSpace(t.Name)
+	for i := range t.Items {
+		t.Items[i].Detail = strings.TrimSpace(t.Items[i].Detail)
+		t.Items[i].AssignedTo = strings.TrimSpace(t.Items[i].AssignedTo)
+	}
+}
+
+func (t *Template) Validate() error {
+	if t.Name == "" {
+		return errors.Validation("template name is required")
+	}
+
+	if len(t.Items) == 0 {
+		return errors.Validation("a template should have at least one task")
+	}
+
+	if len(t.Items) > MaxItems {
+		return errors.Validationf("a template cannot have more than %d tasks", MaxItems)
+	}
+
+	for i, item := range t.Items {
+		if item.Detail == "" {
+			return errors.Validationf("detail of task %d is required", i+1)
+		}
+
+		if item.DueOffset < 0 || time.Duration(item.DueOffset) > MaxDueOffset {
+			return errors.Validationf("due offset of task %d should be between 0 and %s", i+1, MaxDueOffset)
+		}
+	}
+
+	return nil
+}
+
+// Placeholders returns the names of all the placeholders used in the template, sorted
+func (t *Template) Placeholders() []string {
+	seen := map[string]bool{}
+	for _, item := range t.Items {
+		for _, text := range []string{item.Detail, item.AssignedTo} {
+			for _, match := range placeholderPattern.FindAllStringSubmatch(text, -1) {
+				seen[match[1]] = true
+			}
+		}
+	}
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// fill replaces the placeholders of text with their values
+func fill(text string, values map[string]string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		return values[name]
+	})
+}
+
+// Instantiation is a request to create the tasks of a template
+type Instantiation struct {
+	// Values are the values of the placeholders
+	Values map[string]string `json:"values"`
+	// Start is the time due offsets are relative to, now if it is not provided
+	Start *time.Time `json:"start,omitempty"`
+}
+
+type Templates struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+	pqdriver   *pgxpool.Pool
+}
+
+func (ts *Templates) Create(ctx context.Context, t *Template) (*Template, error) {
+	t.Sanitize()
+	err := t.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t.CreatedAt = &now
+	t.UpdatedAt = &now
+
+	t.ID, err = ts.store.Create(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+func (ts *Templates) Edit(ctx context.Context, id int64, uid int64, t *Template) (*Template, error) {
+	existing, err := ts.Get(ctx, id, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	t.ID = existing.ID
+	t.UID = existing.UID
+	t.CreatedAt = existing.CreatedAt
+	t.Sanitize()
+	err = t.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	t.UpdatedAt = &now
+	err = ts.store.Edit(ctx, t)
+	if err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// Get returns a template of the user. Templates of other users are not found
+func (ts *Templates) Get(ctx context.Context, id int64, uid int64) (*Template, error) {
+	t, err := ts.store.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if t.UID != uid {
+		return nil, errors.NotFound("template not found")
+	}
+
+	return t, nil
+}
+
+func (ts *Templates) GetAll(ctx context.Context, uid int64) ([]Template, error) {
+	return ts.store.GetAll(ctx, uid)
+}
+
+func (ts *Templates) Delete(ctx context.Context, id int64, uid int64) error {
+	_, err := ts.Get(ctx, id, uid)
+	if err != nil {
+		return err
+	}
+
+	return ts.store.Delete(ctx, id)
+}
+
+// Instantiate creates the tasks of a template for the user, all of them or none. The placeholder
+// {{date}} is filled with the start date, unless a value is provided for it. prepare, if not nil,
+// is called with every task before it is created
+func (ts *Templates) Instantiate(ctx context.Context, id int64, uid int64, in *Instantiation, prepare func(ctx context.Context, t *tasks.Task) error) ([]tasks.Task, error) {
+	t, err := ts.Get(ctx, id, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	if in.Start != nil {
+		start = *in.Start
+	}
+
+	values := map[string]string{
+		"date": start.Format("2006-01-02"),
+	}
+	for name, value := range in.Values {
+		values[strings.TrimSpace(name)] = value
+	}
+
+	missing := []string{}
+	for _, name := range t.Placeholders() {
+		if _, ok := values[name]; !ok {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, errors.Validationf("values are missing for the placeholders: %s", strings.Join(missing, ", "))
+	}
+
+	created := make([]tasks.Task, 0, len(t.Items))
+	err = datastore.WithTx(ctx, ts.pqdriver, func(ctx context.Context) error {
+		for _, item := range t.Items {
+			task := &tasks.Task{
+				UID:        uid,
+				Detail:     fill(item.Detail, values),
+				AssignedTo: strings.TrimSpace(fill(item.AssignedTo, values)),
+				CompleteBy: start.Add(time.Duration(item.DueOffset)),
+			}
+			if prepare != nil {
+				err := prepare(ctx, task)
+				if err != nil {
+					return err
+				}
+			}
+
+			task, err := ts.tasks.Create(ctx, task)
+			if err != nil {
+				return err
+			}
+			created = append(created, *task)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func NewService(l logger.Logger, pqdriver *pgxpool.Pool, ts *tasks.Tasks) (*Templates, error) {
+	tstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Templates{
+		logHandler: l,
+		store:      tstore,
+		tasks:      ts,
+		pqdriver:   pqdriver,
+	}, nil
+}
@@ -0,0 +1,55 @@
+package users
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+
+	"github.com/bnkamalesh/errors"
+)
+
+// calendarTokenSize is the number of random bytes in a calendar token
+const calendarTokenSize = 32
+
+// CalendarToken returns the secret token of the user's calendar feed, creating it on first use
+func (us *Users) CalendarToken(ctx context.Context, uid int64) (string, error) {
+	token, err := us.store.GetCalendarToken(ctx, uid)
+	if err != nil {
+		return "", err
+	}
+
+	if token != "" {
+		return token, nil
+	}
+
+	return us.RegenerateCalendarToken(ctx, uid)
+}
+
+// RegenerateCalendarToken replaces the calendar token of the user, so the previous feed URL stops
+// working
+func (us *Users) RegenerateCalendarToken(ctx context.Context, uid int64) (string, error) {
+	b := make([]byte, calendarTokenSize)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	token := base64.RawURLEncoding.EncodeToString(b)
+	err = us.store.SetCalendarToken(ctx, uid, token)
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// GetUserByCalendarToken returns the user who owns the calendar token
+func (us *Users) GetUserByCalendarToken(ctx context.Context, token string) (*User, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, errors.NotFound("calendar not found")
+	}
+
+	return us.store.GetUserByCalendarToken(ctx, token)
+}
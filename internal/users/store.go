@@ -3,15 +3,22 @@ package users
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
 )
 
 type store interface {
 	Create(ctx context.Context, u *User) error
 	GetUser(ctx context.Context, email string) (*User, error)
+	GetUserByID(ctx context.Context, uid int64) (*User, error)
+	GetCalendarToken(ctx context.Context, uid int64) (string, error)
+	SetCalendarToken(ctx context.Context, uid int64, token string) error
+	SetTimezone(ctx context.Context, uid int64, timezone string) error
+	GetUserByCalendarToken(ctx context.Context, token string) (*User, error)
 }
 
 type userStore struct {
@@ -25,6 +32,7 @@ func (us *userStore) Create(ctx context.Context, u *User) error {
 		"fullName":  u.Name,
 		"email":     u.Email,
 		"pwd":       u.Password,
+		"timezone":  u.Timezone,
 		"createdAt": u.CreatedAt,
 		"updatedAt": u.UpdatedAt,
 	}).ToSql()
@@ -45,6 +53,7 @@ func (us *userStore) GetUser(ctx context.Context, email string) (*User, error) {
 		"id",
 		"fullName",
 		"pwd",
+		"timezone",
 		"createdAt",
 		"updatedAt",
 	).From(
@@ -63,16 +72,21 @@ func (us *userStore) GetUser(ctx context.Context, email string) (*User, error) {
 	id := new(sql.NullInt64)
 	fullname := new(sql.NullString)
 	pwd := new(sql.NullString)
+	timezone := new(sql.NullString)
 
 	row := us.pqdriver.QueryRow(ctx, query, args...)
 	err = row.Scan(
 		id,
 		fullname,
 		pwd,
+		timezone,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 	)
 
+	if err == pgx.ErrNoRows {
+		return nil, errors.NotFound("user not found")
+	}
 	if err != nil {
 		return nil, errors.InternalErr(err, err.Error())
 	}
@@ -81,6 +95,167 @@ func (us *userStore) GetUser(ctx context.Context, email string) (*User, error) {
 	user.Name = fullname.String
 	user.Email = email
 	user.Password = pwd.String
+	user.Timezone = timezone.String
+
+	return user, nil
+}
+
+func (us *userStore) GetUserByID(ctx context.Context, uid int64) (*User, error) {
+	query, args, err := us.qbuilder.Select(
+		"fullName",
+		"email",
+		"timezone",
+		"createdAt",
+		"updatedAt",
+	).From(
+		us.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": uid,
+		},
+	).ToSql()
+
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	user := new(User)
+	fullname := new(sql.NullString)
+	email := new(sql.NullString)
+	timezone := new(sql.NullString)
+
+	row := us.pqdriver.QueryRow(ctx, query, args...)
+	err = row.Scan(
+		fullname,
+		email,
+		timezone,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == pgx.ErrNoRows {
+		return nil, errors.NotFound("user not found")
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	user.UID = uid
+	user.Name = fullname.String
+	user.Email = email.String
+	user.Timezone = timezone.String
+
+	return user, nil
+}
+
+func (us *userStore) GetCalendarToken(ctx context.Context, uid int64) (string, error) {
+	query, args, err := us.qbuilder.Select(
+		"calendarToken",
+	).From(
+		us.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": uid,
+		},
+	).ToSql()
+	if err != nil {
+		return "", errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	token := new(sql.NullString)
+	err = us.pqdriver.QueryRow(ctx, query, args...).Scan(token)
+	if err == pgx.ErrNoRows {
+		return "", errors.NotFound("user not found")
+	}
+	if err != nil {
+		return "", errors.InternalErr(err, err.Error())
+	}
+
+	return token.String, nil
+}
+
+func (us *userStore) SetCalendarToken(ctx context.Context, uid int64, token string) error {
+	query, args, err := us.qbuilder.Update(us.tableName).Set(
+		"calendarToken", token,
+	).Where(
+		squirrel.Eq{
+			"id": uid,
+		},
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := us.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("user not found")
+	}
+
+	return nil
+}
+
+func (us *userStore) SetTimezone(ctx context.Context, uid int64, timezone string) error {
+	query, args, err := us.qbuilder.Update(us.tableName).SetMap(map[string]interface{}{
+		"timezone":  timezone,
+		"updatedAt": time.Now(),
+	}).Where(
+		squirrel.Eq{
+			"id": uid,
+		},
+	).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := us.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("user not found")
+	}
+
+	return nil
+}
+
+func (us *userStore) GetUserByCalendarToken(ctx context.Context, token string) (*User, error) {
+	query, args, err := us.qbuilder.Select(
+		"id",
+		"fullName",
+		"email",
+	).From(
+		us.tableName,
+	).Where(
+		squirrel.Eq{
+			"calendarToken": token,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	user := new(User)
+	fullname := new(sql.NullString)
+	email := new(sql.NullString)
+	err = us.pqdriver.QueryRow(ctx, query, args...).Scan(
+		&user.UID,
+		fullname,
+		email,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, errors.NotFound("calendar not found")
+	}
+	if err != nil {
+		return nil, errors.InternalErr(err, err.Error())
+	}
+
+	user.Name = fullname.String
+	user.Email = email.String
 
 	return user, nil
 }
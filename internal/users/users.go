@@ -15,11 +15,17 @@ import (
 	"golang.org/x/crypto/bcrypt"
 )
 
+// DefaultTimezone is the timezone of users who have not set one
+const DefaultTimezone = "UTC"
+
 type User struct {
-	UID       int64      `json:"uid,omitempty"`
-	Name      string     `json:"name,omitempty"`
-	Password  string     `json:"password,omitempty"`
-	Email     string     `json:"email,omitempty"`
+	UID      int64  `json:"uid,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	// Timezone is the IANA name of the user's timezone, dates in emails to the user are shown in
+	// it. It defaults to UTC
+	Timezone  string     `json:"timezone,omitempty"`
 	CreatedAt *time.Time `json:"createdAt,omitempty"`
 	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
 }
@@ -49,14 +55,23 @@ func (u *User) init() {
 func (u *User) Sanitize() {
 	u.Name = strings.TrimSpace(u.Name)
 	u.Email = strings.TrimSpace(u.Email)
+	u.Timezone = strings.TrimSpace(u.Timezone)
+	if u.Timezone == "" {
+		u.Timezone = DefaultTimezone
+	}
 }
 
 func (u *User) Validate() error {
+	err := validateTimezone(u.Timezone)
+	if err != nil {
+		return err
+	}
+
 	if u.Email == "" {
 		return nil
 	}
 
-	err := validateEmail(u.Email)
+	err = validateEmail(u.Email)
 	if err != nil {
 		return err
 	}
@@ -64,6 +79,15 @@ func (u *User) Validate() error {
 	return nil
 }
 
+// Location returns the timezone of the user, UTC if it is not set
+func (u *User) Location() *time.Location {
+	loc, err := time.LoadLocation(u.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
 	return string(bytes), err
@@ -74,6 +98,18 @@ func CheckPasswordHash(password, hash string) bool {
 	return err == nil
 }
 
+func validateTimezone(name string) error {
+	if name == "" {
+		return nil
+	}
+
+	_, err := time.LoadLocation(name)
+	if err != nil {
+		return errors.ValidationErrf(err, "invalid timezone '%s', expected an IANA name like Europe/Berlin", name)
+	}
+	return nil
+}
+
 func validateEmail(email string) error {
 	parts := strings.Split(email, "@")
 	if len(parts) != 2 {
@@ -144,12 +180,50 @@ func (us *Users) GetUserByEmail(ctx context.Context, email string) (*User, error
 	return u, nil
 }
 
-func (us *Users) Login(ctx context.Context, email string, password string) (JWT, error) {
-	emptyJWT := JWT{}
+// SetTimezone changes the timezone of the user, an empty name resets it to UTC
+func (us *Users) SetTimezone(ctx context.Context, uid int64, timezone string) (*User, error) {
+	timezone = strings.TrimSpace(timezone)
+	if timezone == "" {
+		timezone = DefaultTimezone
+	}
 
+	err := validateTimezone(timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	err = us.store.SetTimezone(ctx, uid, timezone)
+	if err != nil {
+		return nil, err
+	}
+
+	return us.GetUserByID(ctx, uid)
+}
+
+func (us *Users) GetUserByID(ctx context.Context, uid int64) (*User, error) {
+	u, err := us.store.GetUserByID(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// Authenticate returns the user if the password is correct, it is used by clients which cannot
+// use a JWT, like calendar apps
+func (us *Users) Authenticate(ctx context.Context, email string, password string) (*User, error) {
 	u, err := us.GetUserByEmail(ctx, email)
 	if err != nil || !CheckPasswordHash(password, u.Password) {
-		return emptyJWT, errors.Unauthorized("Wrong username or password")
+		return nil, errors.Unauthorized("Wrong username or password")
+	}
+	return u, nil
+}
+
+func (us *Users) Login(ctx context.Context, email string, password string) (JWT, error) {
+	emptyJWT := JWT{}
+
+	u, err := us.Authenticate(ctx, email, password)
+	if err != nil {
+		return emptyJWT, err
 	}
 	token, err := CreateToken(u)
 	if err != nil {
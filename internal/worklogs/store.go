@@ -0,0 +1,302 @@
+package worklogs
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+type store interface {
+	Create(ctx context.Context, w *Worklog) (int64, error)
+	Edit(ctx context.Context, w *Worklog) error
+	Delete(ctx context.Context, wid int64) error
+	Get(ctx context.Context, wid int64) (*Worklog, error)
+	GetAll(ctx context.Context, tid int64) ([]Worklog, error)
+	Running(ctx context.Context, uid int64) (*Worklog, error)
+	Report(ctx context.Context, uid int64, filter *ReportFilter) ([]ReportRow, error)
+}
+
+type worklogStore struct {
+	qbuilder  squirrel.StatementBuilderType
+	pqdriver  *pgxpool.Pool
+	tableName string
+}
+
+var worklogColumns = []string{
+	"id",
+	"tid",
+	"uid",
+	"startedAt",
+	"endedAt",
+	"COALESCE(EXTRACT(EPOCH FROM endedAt - startedAt), 0)::BIGINT AS duration",
+	"note",
+	"createdAt",
+	"updatedAt",
+}
+
+func (ws *worklogStore) Create(ctx context.Context, w *Worklog) (int64, error) {
+	// the partial unique index on running timers makes sure a user has only one of them
+	query, args, err := ws.qbuilder.Insert(ws.tableName).SetMap(map[string]interface{}{
+		"tid":       w.TID,
+		"uid":       w.UID,
+		"startedAt": w.StartedAt,
+		"endedAt":   w.EndedAt,
+		"note":      w.Note,
+		"createdAt": w.CreatedAt,
+		"updatedAt": w.UpdatedAt,
+	}).Suffix("ON CONFLICT (uid) WHERE endedAt IS NULL DO NOTHING RETURNING id").ToSql()
+	if err != nil {
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	var id int64
+	err = ws.pqdriver.QueryRow(ctx, query, args...).Scan(&id)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return 0, errors.Duplicate("a timer is already running, stop it first")
+		}
+		return 0, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return id, nil
+}
+
+func (ws *worklogStore) Edit(ctx context.Context, w *Worklog) error {
+	query, args, err := ws.qbuilder.Update(ws.tableName).SetMap(map[string]interface{}{
+		"startedAt": w.StartedAt,
+		"endedAt":   w.EndedAt,
+		"note":      w.Note,
+		"updatedAt": w.UpdatedAt,
+	}).Where(squirrel.Eq{
+		"id": w.ID,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := ws.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("worklog not found")
+	}
+
+	return nil
+}
+
+func (ws *worklogStore) Delete(ctx context.Context, wid int64) error {
+	query, args, err := ws.qbuilder.Delete(ws.tableName).Where(squirrel.Eq{
+		"id": wid,
+	}).ToSql()
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	tag, err := ws.pqdriver.Exec(ctx, query, args...)
+	if err != nil {
+		return errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return errors.NotFound("worklog not found")
+	}
+
+	return nil
+}
+
+func (ws *worklogStore) Get(ctx context.Context, wid int64) (*Worklog, error) {
+	query, args, err := ws.qbuilder.Select(
+		worklogColumns...,
+	).From(
+		ws.tableName,
+	).Where(
+		squirrel.Eq{
+			"id": wid,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	w, err := scanWorklog(ws.pqdriver.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("worklog not found")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return w, nil
+}
+
+func (ws *worklogStore) GetAll(ctx context.Context, tid int64) ([]Worklog, error) {
+	query, args, err := ws.qbuilder.Select(
+		worklogColumns...,
+	).From(
+		ws.tableName,
+	).Where(
+		squirrel.Eq{
+			"tid": tid,
+		},
+	).OrderBy(
+		"startedAt",
+		"id",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := ws.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []Worklog{}
+	for rows.Next() {
+		w, err := scanWorklog(rows)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		list = append(list, *w)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+func (ws *worklogStore) Running(ctx context.Context, uid int64) (*Worklog, error) {
+	query, args, err := ws.qbuilder.Select(
+		worklogColumns...,
+	).From(
+		ws.tableName,
+	).Where(
+		squirrel.Eq{
+			"uid":     uid,
+			"endedAt": nil,
+		},
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	w, err := scanWorklog(ws.pqdriver.QueryRow(ctx, query, args...))
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, errors.NotFound("no timer is running")
+		}
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	return w, nil
+}
+
+func (ws *worklogStore) Report(ctx context.Context, uid int64, filter *ReportFilter) ([]ReportRow, error) {
+	// days are computed in the timezone of the report, a worklog counts for the day it started on
+	day := squirrel.Expr("to_char(w.startedAt AT TIME ZONE ?, 'YYYY-MM-DD')", filter.Timezone)
+	query, args, err := ws.qbuilder.Select().Column(
+		squirrel.Alias(day, "day"),
+	).Columns(
+		"w.tid",
+		"t.detail",
+		"w.uid",
+		"u.email",
+		"SUM(EXTRACT(EPOCH FROM w.endedAt - w.startedAt))::BIGINT AS seconds",
+	).From(
+		ws.tableName+" w",
+	).Join(
+		"tasks t ON t.id = w.tid",
+	).Join(
+		"users u ON u.id = w.uid",
+	).Where(
+		squirrel.And{
+			squirrel.NotEq{"w.endedAt": nil},
+			squirrel.GtOrEq{"w.startedAt": filter.from},
+			squirrel.Lt{"w.startedAt": filter.to},
+			squirrel.Or{
+				squirrel.Eq{"w.uid": uid},
+				squirrel.Eq{"t.uid": uid},
+			},
+		},
+	).GroupBy(
+		"day",
+		"w.tid",
+		"t.detail",
+		"w.uid",
+		"u.email",
+	).OrderBy(
+		"day",
+		"w.tid",
+		"u.email",
+	).ToSql()
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+
+	rows, err := ws.pqdriver.Query(ctx, query, args...)
+	if err != nil {
+		return nil, errors.InternalErr(err, errors.DefaultMessage)
+	}
+	defer rows.Close()
+
+	list := []ReportRow{}
+	for rows.Next() {
+		r := ReportRow{}
+		err := rows.Scan(
+			&r.Date,
+			&r.TID,
+			&r.Detail,
+			&r.UID,
+			&r.Email,
+			&r.Seconds,
+		)
+		if err != nil {
+			return nil, errors.InternalErr(err, err.Error())
+		}
+		r.Hours = float64(r.Seconds) / 3600
+		list = append(list, r)
+	}
+
+	if rows.Err() != nil {
+		return nil, errors.InternalErr(rows.Err(), errors.DefaultMessage)
+	}
+
+	return list, nil
+}
+
+// scanWorklog scans a row selected with worklogColumns
+func scanWorklog(row pgx.Row) (*Worklog, error) {
+	w := new(Worklog)
+	err := row.Scan(
+		&w.ID,
+		&w.TID,
+		&w.UID,
+		&w.StartedAt,
+		&w.EndedAt,
+		&w.Duration,
+		&w.Note,
+		&w.CreatedAt,
+		&w.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+func newStore(pqdriver *pgxpool.Pool) (*worklogStore, error) {
+	return &worklogStore{
+		pqdriver:  pqdriver,
+		qbuilder:  squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar),
+		tableName: "worklogs",
+	}, nil
+}
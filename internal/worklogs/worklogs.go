@@ -0,0 +1,310 @@
+package worklogs
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/recurrence"
+	"task-scheduler/internal/tasks"
+
+	"github.com/bnkamalesh/errors"
+	"github.com/jackc/pgx/v4/pgxpool"
+)
+
+const (
+	// MaxDuration is the longest a single worklog can be
+	MaxDuration = time.Hour * 24
+	// MaxReportRange is the longest period covered by a report
+	MaxReportRange = time.Hour * 24 * 366
+	// MaxNoteLength is the maximum number of characters in a note
+	MaxNoteLength = 2000
+)
+
+// Worklog is time spent on a task. A worklog without an end is a running timer, a user can only
+// have one running timer at a time
+type Worklog struct {
+	ID        int64      `json:"id,omitempty"`
+	TID       int64      `json:"tid,omitempty"`
+	UID       int64      `json:"uid,omitempty"`
+	StartedAt time.Time  `json:"startedAt"`
+	EndedAt   *time.Time `json:"endedAt,omitempty"`
+	// Duration is the time spent in seconds, it is computed from StartedAt and EndedAt
+	Duration  int64      `json:"duration"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt *time.Time `json:"updatedAt,omitempty"`
+}
+
+func (w *Worklog) Sanitize() {
+	w.Note = strings.TrimSpace(w.Note)
+}
+
+// Validate validates a worklog entered manually, or a timer which was stopped
+func (w *Worklog) Validate() error {
+	if w.StartedAt.IsZero() {
+		return errors.Validation("start time is required")
+	}
+
+	// a manual entry can be provided with its duration rather than its end
+	if w.EndedAt == nil && w.Duration > 0 {
+		end := w.StartedAt.Add(time.Duration(w.Duration) * time.Second)
+		w.EndedAt = &end
+	}
+
+	if w.EndedAt == nil {
+		return errors.Validation("either the end time or the duration is required")
+	}
+
+	d := w.EndedAt.Sub(w.StartedAt)
+	if d <= 0 || d > MaxDuration {
+		return errors.Validationf("a worklog should last between 0 and %s", MaxDuration)
+	}
+
+	if w.EndedAt.After(time.Now().Add(time.Minute)) {
+		return errors.Validation("a worklog cannot end in the future")
+	}
+
+	if len([]rune(w.Note)) > MaxNoteLength {
+		return errors.Validationf("note cannot be longer than %d characters", MaxNoteLength)
+	}
+
+	w.Duration = int64(d / time.Second)
+	return nil
+}
+
+// ReportRow is the time spent by a user on a task on a given day
+type ReportRow struct {
+	Date    string  `json:"date"`
+	TID     int64   `json:"tid"`
+	Detail  string  `json:"detail"`
+	UID     int64   `json:"uid"`
+	Email   string  `json:"email"`
+	Hours   float64 `json:"hours"`
+	Seconds int64   `json:"seconds"`
+}
+
+// ReportFilter is the period covered by a report, From and To are dates (YYYY-MM-DD) in Timezone,
+// both included
+type ReportFilter struct {
+	From     string
+	To       string
+	Timezone string
+
+	from time.Time
+	to   time.Time
+}
+
+func (rf *ReportFilter) Validate() error {
+	loc, err := recurrence.LoadLocation(rf.Timezone)
+	if err != nil {
+		return err
+	}
+	rf.Timezone = loc.String()
+
+	rf.from, err = time.ParseInLocation("2006-01-02", strings.TrimSpace(rf.From), loc)
+	if err != nil {
+		return errors.ValidationErr(err, "from should be a date, e.g. 2021-06-01")
+	}
+
+	to, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(rf.To), loc)
+	if err != nil {
+		return errors.ValidationErr(err, "to should be a date, e.g. 2021-06-30")
+	}
+	// the end date is included
+	rf.to = to.AddDate(0, 0, 1)
+
+	if !rf.from.Before(rf.to) || rf.to.Sub(rf.from) > MaxReportRange {
+		return errors.Validationf("from should be before to, and the period cannot be longer than %d days", MaxReportRange/(time.Hour*24))
+	}
+
+	return nil
+}
+
+type Worklogs struct {
+	logHandler logger.Logger
+	store      store
+	tasks      *tasks.Tasks
+}
+
+// Start starts a timer on a task for the user
+func (ws *Worklogs) Start(ctx context.Context, tid int64, uid int64, note string) (*Worklog, error) {
+	_, err := ws.tasks.Get(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w := &Worklog{
+		TID:       tid,
+		UID:       uid,
+		StartedAt: now,
+		Note:      note,
+		CreatedAt: &now,
+		UpdatedAt: &now,
+	}
+	w.Sanitize()
+	if len([]rune(w.Note)) > MaxNoteLength {
+		return nil, errors.Validationf("note cannot be longer than %d characters", MaxNoteLength)
+	}
+
+	w.ID, err = ws.store.Create(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Stop stops the running timer of the user
+func (ws *Worklogs) Stop(ctx context.Context, uid int64) (*Worklog, error) {
+	w, err := ws.store.Running(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w.EndedAt = &now
+	w.UpdatedAt = &now
+	// timers left running for longer than a worklog can last are capped
+	if now.Sub(w.StartedAt) > MaxDuration {
+		end := w.StartedAt.Add(MaxDuration)
+		w.EndedAt = &end
+	}
+
+	err = w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	err = ws.store.Edit(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Running returns the running timer of the user
+func (ws *Worklogs) Running(ctx context.Context, uid int64) (*Worklog, error) {
+	w, err := ws.store.Running(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Duration = int64(time.Since(w.StartedAt) / time.Second)
+	return w, nil
+}
+
+// Create adds a worklog entered manually
+func (ws *Worklogs) Create(ctx context.Context, w *Worklog) (*Worklog, error) {
+	_, err := ws.tasks.Get(ctx, w.TID)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Sanitize()
+	err = w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w.CreatedAt = &now
+	w.UpdatedAt = &now
+	w.ID, err = ws.store.Create(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Edit changes the times or the note of a worklog, only its author can edit it
+func (ws *Worklogs) Edit(ctx context.Context, tid int64, wid int64, uid int64, w *Worklog) (*Worklog, error) {
+	existing, err := ws.authored(ctx, tid, wid, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing.EndedAt == nil {
+		return nil, errors.Validation("a running timer cannot be edited, stop it first")
+	}
+
+	w.ID = existing.ID
+	w.TID = existing.TID
+	w.UID = existing.UID
+	w.CreatedAt = existing.CreatedAt
+	w.Sanitize()
+	err = w.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	w.UpdatedAt = &now
+	err = ws.store.Edit(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+
+	return w, nil
+}
+
+// Delete deletes a worklog, only its author can delete it
+func (ws *Worklogs) Delete(ctx context.Context, tid int64, wid int64, uid int64) error {
+	_, err := ws.authored(ctx, tid, wid, uid)
+	if err != nil {
+		return err
+	}
+
+	return ws.store.Delete(ctx, wid)
+}
+
+// GetAll returns the worklogs of a task, oldest first
+func (ws *Worklogs) GetAll(ctx context.Context, tid int64) ([]Worklog, error) {
+	return ws.store.GetAll(ctx, tid)
+}
+
+// Report returns the time spent per task, user and day within the period, on the tasks owned by
+// the user or by the user on any task
+func (ws *Worklogs) Report(ctx context.Context, uid int64, filter *ReportFilter) ([]ReportRow, error) {
+	err := filter.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return ws.store.Report(ctx, uid, filter)
+}
+
+// authored returns the worklog if it belongs to the task and to the user
+func (ws *Worklogs) authored(ctx context.Context, tid int64, wid int64, uid int64) (*Worklog, error) {
+	w, err := ws.store.Get(ctx, wid)
+	if err != nil {
+		return nil, err
+	}
+
+	if w.TID != tid {
+		return nil, errors.NotFound("worklog not found")
+	}
+
+	if w.UID != uid {
+		return nil, errors.Unauthorized("only the author of a worklog can change it")
+	}
+
+	return w, nil
+}
+
+func NewService(l logger.Logger, pqdriver *pgxpool.Pool, ts *tasks.Tasks) (*Worklogs, error) {
+	wstore, err := newStore(pqdriver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Worklogs{
+		logHandler: l,
+		store:      wstore,
+		tasks:      ts,
+	}, nil
+}
@@ -1,14 +1,25 @@
 package main
 
 import (
+	"context"
+
 	"task-scheduler/internal/api"
+	"task-scheduler/internal/attachments"
+	"task-scheduler/internal/caldav"
+	"task-scheduler/internal/comments"
 	"task-scheduler/internal/configs"
 	"task-scheduler/internal/emailService"
+	"task-scheduler/internal/platform/blobstore"
 	"task-scheduler/internal/platform/datastore"
 	"task-scheduler/internal/platform/logger"
+	"task-scheduler/internal/platform/worker"
+	"task-scheduler/internal/projects"
+	"task-scheduler/internal/reminders"
 	"task-scheduler/internal/server/http"
 	"task-scheduler/internal/tasks"
+	"task-scheduler/internal/templates"
 	"task-scheduler/internal/users"
+	"task-scheduler/internal/worklogs"
 
 	"github.com/joho/godotenv"
 )
@@ -56,12 +67,91 @@ func main() {
 		return
 	}
 
-	a, err := api.NewService(l, us, ts, es)
+	remindersCfg, err := cfg.Reminders()
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	rs, err := reminders.NewService(l, pqdriver, ts, us, es, remindersCfg)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	cs, err := comments.NewService(l, pqdriver, ts, us, es)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	attachmentsCfg, err := cfg.Attachments()
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	blobs, err := blobstore.NewLocal(attachmentsCfg.Dir)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	as, err := attachments.NewService(l, pqdriver, blobs, ts, attachmentsCfg)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	tps, err := templates.NewService(l, pqdriver, ts)
 	if err != nil {
 		l.Fatal(err.Error())
 		return
 	}
 
+	wls, err := worklogs.NewService(l, pqdriver, ts)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	ps, err := projects.NewService(l, pqdriver, ts, us)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	cds, err := caldav.NewService(l, pqdriver, ts)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	a, err := api.NewService(l, us, ts, es, rs, cs, as, tps, wls, ps, cds)
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	recurrenceCfg, err := cfg.Recurrence()
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	trashCfg, err := cfg.Trash()
+	if err != nil {
+		l.Fatal(err.Error())
+		return
+	}
+
+	ctx := context.Background()
+	go worker.Every(ctx, l, "recurrence", recurrenceCfg.Interval, ts.MaterializeDue)
+	go worker.Every(ctx, l, "reminders", remindersCfg.Interval, rs.Run)
+	go worker.Every(ctx, l, "trash", trashCfg.Interval, func(ctx context.Context) error {
+		return a.PurgeTrash(ctx, trashCfg.Retention)
+	})
+
 	httpCfg, err := cfg.HTTP()
 	if err != nil {
 		l.Fatal(err.Error())
@@ -0,0 +1,16 @@
+-- tid is deliberately not a foreign key: the rows have to outlive their task until the blobs
+-- are deleted from the blob store
+CREATE TABLE IF NOT EXISTS Task_Attachments (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL,
+    uid BIGINT NOT NULL,
+    filename TEXT NOT NULL,
+    contentType TEXT NOT NULL,
+    size BIGINT NOT NULL,
+    -- hex encoded SHA-256 of the content
+    checksum TEXT NOT NULL,
+    blobKey TEXT NOT NULL UNIQUE,
+    createdAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_attachments_tid_idx ON Task_Attachments (tid);
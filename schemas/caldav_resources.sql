@@ -0,0 +1,8 @@
+-- resources created by CalDAV clients keep the name and UID the client chose, all the other tasks
+-- are served as '<task id>.ics' with the UID of the ICS feed
+CREATE TABLE IF NOT EXISTS CalDAV_Resources (
+    tid BIGINT PRIMARY KEY REFERENCES Tasks (id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL UNIQUE,
+    icalUid TEXT NOT NULL,
+    createdAt timestamptz DEFAULT now()
+);
@@ -0,0 +1,12 @@
+CREATE TABLE IF NOT EXISTS Task_Comments (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    uid BIGINT NOT NULL,
+    body TEXT NOT NULL,
+    -- email addresses mentioned in the body
+    mentions TEXT[] NOT NULL DEFAULT '{}',
+    createdAt timestamptz DEFAULT now(),
+    updatedAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_comments_tid_idx ON Task_Comments (tid, createdAt);
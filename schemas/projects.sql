@@ -0,0 +1,24 @@
+CREATE TABLE IF NOT EXISTS Projects (
+    id BIGSERIAL PRIMARY KEY,
+    uid BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    -- email address new tasks are assigned to when they are not assigned to anyone
+    defaultAssignee VARCHAR(255) NOT NULL DEFAULT '',
+    -- reminder offsets in seconds for the tasks of the project, NULL uses the default ones
+    reminderOffsets BIGINT[],
+    archivedAt timestamptz,
+    createdAt timestamptz DEFAULT now(),
+    updatedAt timestamptz DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS Project_Members (
+    pid BIGINT NOT NULL REFERENCES Projects (id) ON DELETE CASCADE,
+    uid BIGINT NOT NULL,
+    -- owner, editor or viewer
+    role VARCHAR(16) NOT NULL,
+    createdAt timestamptz DEFAULT now(),
+    PRIMARY KEY (pid, uid)
+);
+
+CREATE INDEX IF NOT EXISTS project_members_uid_idx ON Project_Members (uid);
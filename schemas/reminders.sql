@@ -0,0 +1,16 @@
+-- reminders which have been sent, so they are never sent twice
+CREATE TABLE IF NOT EXISTS Task_Reminders (
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    kind TEXT NOT NULL,
+    offsetSeconds BIGINT NOT NULL,
+    dueAt timestamptz NOT NULL,
+    sentAt timestamptz DEFAULT now(),
+    PRIMARY KEY (tid, kind, offsetSeconds, dueAt)
+);
+
+-- per task reminder offsets, replacing the default ones
+CREATE TABLE IF NOT EXISTS Task_Reminder_Overrides (
+    tid BIGINT PRIMARY KEY REFERENCES Tasks (id) ON DELETE CASCADE,
+    offsets BIGINT[] NOT NULL DEFAULT '{}',
+    updatedAt timestamptz DEFAULT now()
+);
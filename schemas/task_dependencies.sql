@@ -0,0 +1,10 @@
+-- task tid cannot start until task blockedBy is complete
+CREATE TABLE IF NOT EXISTS Task_Dependencies (
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    blockedBy BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    createdAt timestamptz DEFAULT now(),
+    PRIMARY KEY (tid, blockedBy),
+    CHECK (tid <> blockedBy)
+);
+
+CREATE INDEX IF NOT EXISTS task_dependencies_blockedby_idx ON Task_Dependencies (blockedBy);
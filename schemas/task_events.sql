@@ -0,0 +1,19 @@
+-- audit trail of the tasks. tid is not a foreign key, so the history of deleted tasks is kept
+CREATE TABLE IF NOT EXISTS Task_Events (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL,
+    -- owner of the task when the event happened
+    ownerUid BIGINT,
+    -- user who made the change, 0 for changes made by the system
+    actorUid BIGINT NOT NULL DEFAULT 0,
+    action TEXT NOT NULL,
+    -- changed fields, as {"field": {"from": ..., "to": ...}}
+    diff JSONB NOT NULL DEFAULT '{}',
+    createdAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_events_tid_idx ON Task_Events (tid, id);
+
+CREATE INDEX IF NOT EXISTS task_events_owner_idx ON Task_Events (ownerUid, id);
+
+CREATE INDEX IF NOT EXISTS task_events_actor_idx ON Task_Events (actorUid, id);
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS Task_Members (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    -- members are identified by their email address in lowercase, so people can be added before
+    -- they register
+    email VARCHAR(255) NOT NULL,
+    -- owner, assignee or watcher
+    role VARCHAR(16) NOT NULL,
+    createdAt timestamptz DEFAULT now(),
+    UNIQUE (tid, email)
+);
+
+CREATE INDEX IF NOT EXISTS task_members_email_idx ON Task_Members (email);
@@ -4,6 +4,75 @@ CREATE TABLE IF NOT EXISTS Tasks (
     detail TEXT,
     assignedTo TEXT,
     completeBy timestamptz,
+    -- all-day tasks are due by the end of their date, completeBy, in dueTimezone
+    allDay BOOLEAN NOT NULL DEFAULT false,
+    -- IANA name of the timezone of the due date, NULL for UTC
+    dueTimezone TEXT,
+    -- RRULE or cron expression, NULL for tasks which do not repeat
+    recurrenceRule TEXT,
+    recurrenceTimezone TEXT,
+    recurrenceStart timestamptz,
+    -- id of the first task of a recurring series
+    seriesId BIGINT,
+    -- id of the occurrence created after this one, 0 once the series has ended
+    nextOccurrenceId BIGINT,
+    status TEXT NOT NULL DEFAULT 'todo',
+    projectId BIGINT,
+    completedAt timestamptz,
+    parentId BIGINT REFERENCES Tasks (id) ON DELETE SET NULL,
+    -- lowercase labels
+    tags TEXT[] NOT NULL DEFAULT '{}',
+    -- low, medium, high or urgent, NULL for tasks without a priority
+    priority TEXT,
     createdAt timestamptz DEFAULT now(),
-    updatedAt timestamptz DEFAULT now()
-);
\ No newline at end of file
+    updatedAt timestamptz DEFAULT now(),
+    -- set while the task is in the trash
+    deletedAt timestamptz,
+    -- incremented on every update, it is the ETag of the task
+    version BIGINT NOT NULL DEFAULT 1,
+    searchVector tsvector GENERATED ALWAYS AS (to_tsvector('english', coalesce(detail, ''))) STORED
+);
+
+-- columns added after the table was first created, for existing databases
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS allDay BOOLEAN NOT NULL DEFAULT false;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS dueTimezone TEXT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS recurrenceRule TEXT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS recurrenceTimezone TEXT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS recurrenceStart timestamptz;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS seriesId BIGINT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS nextOccurrenceId BIGINT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS status TEXT NOT NULL DEFAULT 'todo';
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS projectId BIGINT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS completedAt timestamptz;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS parentId BIGINT REFERENCES Tasks (id) ON DELETE SET NULL;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS priority TEXT;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS deletedAt timestamptz;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 1;
+ALTER TABLE Tasks ADD COLUMN IF NOT EXISTS searchVector tsvector
+    GENERATED ALWAYS AS (to_tsvector('english', coalesce(detail, ''))) STORED;
+
+CREATE INDEX IF NOT EXISTS tasks_due_occurrences_idx ON Tasks (completeBy)
+    WHERE recurrenceRule IS NOT NULL AND nextOccurrenceId IS NULL;
+
+CREATE INDEX IF NOT EXISTS tasks_uid_status_idx ON Tasks (uid, status);
+
+CREATE INDEX IF NOT EXISTS tasks_parent_idx ON Tasks (parentId);
+
+-- keyset pagination of the tasks of a user, for each sort order
+CREATE INDEX IF NOT EXISTS tasks_uid_id_idx ON Tasks (uid, id);
+
+CREATE INDEX IF NOT EXISTS tasks_uid_completeby_idx ON Tasks (uid, completeBy, id);
+
+CREATE INDEX IF NOT EXISTS tasks_uid_createdat_idx ON Tasks (uid, createdAt, id);
+
+CREATE INDEX IF NOT EXISTS tasks_uid_updatedat_idx ON Tasks (uid, updatedAt, id);
+
+CREATE INDEX IF NOT EXISTS tasks_tags_idx ON Tasks USING GIN (tags);
+
+CREATE INDEX IF NOT EXISTS tasks_search_idx ON Tasks USING GIN (searchVector);
+
+CREATE INDEX IF NOT EXISTS tasks_trash_idx ON Tasks (deletedAt) WHERE deletedAt IS NOT NULL;
+
+-- tasks of a project, sorted by ID
+CREATE INDEX IF NOT EXISTS tasks_project_id_idx ON Tasks (projectId, id) WHERE projectId IS NOT NULL;
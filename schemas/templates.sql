@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS Task_Templates (
+    id BIGSERIAL PRIMARY KEY,
+    uid BIGINT NOT NULL,
+    name TEXT NOT NULL,
+    -- list of {"detail", "dueOffset", "assignedTo"}, detail and assignedTo can have {{placeholders}}
+    items JSONB NOT NULL DEFAULT '[]',
+    createdAt timestamptz DEFAULT now(),
+    updatedAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_templates_uid_idx ON Task_Templates (uid);
@@ -3,6 +3,14 @@ CREATE TABLE IF NOT EXISTS Users (
     fullName TEXT,
     email TEXT UNIQUE,
     pwd TEXT,
+    -- secret token of the user's calendar feed
+    calendarToken TEXT UNIQUE,
+    -- IANA name of the user's timezone
+    timezone TEXT NOT NULL DEFAULT 'UTC',
     createdAt timestamptz DEFAULT now(),
     updatedAt timestamptz DEFAULT now()
-);
\ No newline at end of file
+);
+
+-- columns added after the table was first created, for existing databases
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS calendarToken TEXT UNIQUE;
+ALTER TABLE Users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
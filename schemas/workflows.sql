@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS Workflows (
+    projectId BIGINT PRIMARY KEY,
+    statuses TEXT[] NOT NULL,
+    initial TEXT NOT NULL,
+    -- map of status to the list of statuses a task can move to from it
+    transitions JSONB NOT NULL DEFAULT '{}',
+    completed TEXT[] NOT NULL DEFAULT '{}',
+    createdAt timestamptz DEFAULT now(),
+    updatedAt timestamptz DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS Task_Transitions (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    fromStatus TEXT NOT NULL,
+    toStatus TEXT NOT NULL,
+    actorUid BIGINT,
+    note TEXT,
+    createdAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS task_transitions_tid_idx ON Task_Transitions (tid, createdAt);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS Worklogs (
+    id BIGSERIAL PRIMARY KEY,
+    tid BIGINT NOT NULL REFERENCES Tasks (id) ON DELETE CASCADE,
+    uid BIGINT NOT NULL,
+    startedAt timestamptz NOT NULL,
+    -- a worklog without an end is a running timer
+    endedAt timestamptz,
+    note TEXT NOT NULL DEFAULT '',
+    createdAt timestamptz DEFAULT now(),
+    updatedAt timestamptz DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS worklogs_tid_idx ON Worklogs (tid, startedAt);
+CREATE INDEX IF NOT EXISTS worklogs_uid_idx ON Worklogs (uid, startedAt);
+-- a user can have only one running timer
+CREATE UNIQUE INDEX IF NOT EXISTS worklogs_running_idx ON Worklogs (uid) WHERE endedAt IS NULL;